@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -34,12 +35,60 @@ import (
 	"github.com/blacktop/go-macho"
 	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
 	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/dyld"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// archDir returns the per-architecture output folder name for a fat MachO slice (e.g. "x86_64 (haswell)" -> "x86_64_haswell")
+func archDir(arch string) string {
+	return strings.NewReplacer(" (", "_", ")", "", " ", "_").Replace(arch)
+}
+
+// openPrevious opens the previous version of the dumped MachO m for --ivar-diff: the same architecture
+// slice of a (fat) MachO or the same image of a DSC
+func openPrevious(path string, m *macho.File, image string) (*macho.File, func(), error) {
+	if ok, _ := magic.IsMachO(path); ok {
+		fat, err := macho.OpenFat(path)
+		if err == macho.ErrNotFat {
+			pm, err := macho.Open(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			return pm, func() { pm.Close() }, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		for _, arch := range fat.Arches {
+			if arch.CPU == m.CPU && arch.SubCPU == m.SubCPU {
+				return arch.File, func() { fat.Close() }, nil
+			}
+		}
+		fat.Close()
+		return nil, nil, fmt.Errorf("%s has no %s slice", path, m.SubCPU.String(m.CPU))
+	}
+	if len(image) == 0 {
+		return nil, nil, fmt.Errorf("--ivar-diff of a MachO needs a MachO to diff against (not a DSC)")
+	}
+	f, err := dyld.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := f.Image(image)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	pm, err := img.GetMacho()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return pm, func() { pm.Close(); f.Close() }, nil
+}
+
 func getImages(dscPath string) []string {
 	if ok, _ := magic.IsMachO(dscPath); ok {
 		return nil
@@ -59,8 +108,27 @@ func init() {
 
 	classDumpCmd.Flags().Bool("headers", false, "Dump ObjC headers")
 	classDumpCmd.Flags().Bool("deps", false, "Dump imported private frameworks")
+	classDumpCmd.Flags().Int("deps-depth", 1, "Levels of imported private frameworks to dump with --deps")
+	classDumpCmd.Flags().String("dsc", "", "dyld_shared_cache a MachO was extracted from (for Foundation scanning, name resolution and --deps)")
+	classDumpCmd.Flags().String("line-ending", "lf", "Line ending to use in headers (lf, crlf)")
+	classDumpCmd.RegisterFlagCompletionFunc("line-ending", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"lf", "crlf"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	classDumpCmd.Flags().String("indent", "", "Normalize header indentation (tabs, spaces:N)")
+	classDumpCmd.RegisterFlagCompletionFunc("indent", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"tabs", "spaces:2", "spaces:4"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	classDumpCmd.Flags().String("baseline", "", "Folder of previous --headers output to diff against (only writes new/changed headers and a changes.txt)")
+	classDumpCmd.MarkFlagDirname("baseline")
+	classDumpCmd.Flags().Bool("baseline-all", false, "Report the --baseline headers of images this run doesn't generate as removed (if it dumps every image the baseline has)")
+	classDumpCmd.Flags().Bool("stable-header", false, "Omit the version-bearing banner from headers (for diffing across ipsw versions)")
+	classDumpCmd.Flags().Bool("modules", true, "Import Foundation as a module in headers (@import vs #import)")
+	classDumpCmd.Flags().Bool("import-forwards", false, "Import the generated headers of forward declared (@class/@protocol) classes and protocols in headers")
+	classDumpCmd.Flags().Bool("inline-foundation", false, "Forward declare referenced Foundation symbols instead of importing Foundation in headers")
 	classDumpCmd.Flags().BoolP("xcfw", "x", false, "🚧 Generate a XCFramework for the dylib")
-	classDumpCmd.Flags().StringP("output", "o", "", "Folder to write headers to")
+	classDumpCmd.Flags().StringP("output", "o", "", "Folder (or .tar.gz/.tgz archive) to write headers to")
+	classDumpCmd.Flags().String("header-ext", ".h", "Header file name extension (e.g. .hpp)")
+	classDumpCmd.Flags().String("filename-tmpl", "", "Go template for header file names (fields: .Name, .Kind, .Class, .Category)")
 	classDumpCmd.MarkFlagDirname("output")
 	classDumpCmd.Flags().String("theme", "nord", "Color theme (nord, github, etc)")
 	classDumpCmd.RegisterFlagCompletionFunc("theme", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -69,21 +137,178 @@ func init() {
 	classDumpCmd.Flags().StringP("class", "c", "", "Dump class (regex)")
 	classDumpCmd.Flags().StringP("proto", "p", "", "Dump protocol (regex)")
 	classDumpCmd.Flags().StringP("cat", "a", "", "Dump category (regex)")
+	classDumpCmd.Flags().Bool("literal", false, "Match --class/--proto/--cat etc. as literal substrings instead of regexes")
+	classDumpCmd.Flags().String("imports", "", "Dump computed header imports/forward declarations for class (regex) as JSON")
+	classDumpCmd.Flags().Bool("cat-by-class", false, "Group dumped categories by their target class")
 	classDumpCmd.Flags().Bool("refs", false, "Dump ObjC references too")
+	classDumpCmd.Flags().Bool("image-info", false, "Dump decoded ObjC image info")
+	classDumpCmd.Flags().IntP("threads", "t", 0, "Max images to process concurrently for --inventory, --conformers, --sel and --addr (default: GOMAXPROCS, up to 8)")
+	classDumpCmd.Flags().Bool("inventory", false, "Stream every ObjC class/protocol/category as JSON Lines (all images if DSC)")
+	classDumpCmd.Flags().Bool("swift-style", false, "Render method signatures in their Swift-imported form (best-effort)")
+	classDumpCmd.Flags().Bool("skip-swift-synthetic", false, "Omit compiler generated Swift classes (stdlib, generic, private and local types)")
+	classDumpCmd.Flags().Bool("skip-empty", false, "Skip classes with no ivars, methods or properties (e.g. stubs)")
+	classDumpCmd.Flags().Bool("only-empty", false, "Only dump classes with no ivars, methods or properties")
+	classDumpCmd.Flags().Bool("exported-only", false, "Only dump the classes with an _OBJC_CLASS_$_ export (approximates the public API)")
+	classDumpCmd.Flags().Bool("named-params", false, "Name method parameters after their selector keywords (e.g. forKeyPath: -> keyPath, synthetic but unique)")
+	classDumpCmd.Flags().Bool("overrides", false, "Mark methods that override a superclass method with // override")
+	classDumpCmd.Flags().Int("min-methods", 0, "Only dump classes with at least this many (class and instance) methods")
+	classDumpCmd.Flags().String("swift-module", "", "Only dump the classes of this Swift module (from their mangled ObjC names)")
+	classDumpCmd.Flags().Bool("metaclass", false, "Also dump each class's metaclass (ivars, root metaclass and superclass chain)")
+	classDumpCmd.Flags().Bool("adopters", false, "List the classes (incl. --deps) adopting each dumped protocol")
+	classDumpCmd.Flags().Bool("dedup-protos", false, "Print protocols with identical methods and properties once (noting the names of the others)")
+	classDumpCmd.Flags().Bool("hide-nsobject", false, "Omit the (inherited) NSObject protocol methods and properties from classes")
+	classDumpCmd.Flags().Bool("sort-methods", false, "Sort methods (and --json properties) alphabetically (instead of their on-disk list order)")
+	classDumpCmd.Flags().Bool("swift-alias", false, "Emit @compatibility_alias for Swift classes with mangled ObjC names in headers")
+	classDumpCmd.Flags().Bool("accessors", false, "Annotate properties with their getter/setter selectors")
+	classDumpCmd.Flags().Bool("kvc", false, "List each class's KVC keys (derived from properties and getters)")
+	classDumpCmd.Flags().Bool("table", false, "Dump a table of class ivar/method/property/protocol counts (filtered by --class)")
+	classDumpCmd.Flags().String("sort", "name", "Column to sort --table by (name, ivars, imethods, cmethods, props, protos)")
+	classDumpCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"name", "ivars", "imethods", "cmethods", "props", "protos"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	classDumpCmd.Flags().String("ivar", "", "Dump classes with an ivar of name or type (regex)")
+	classDumpCmd.Flags().String("ivar-diff", "", "Dump the ivar layout changes since the previous version of the MachO (or DSC of the DYLIB) at this path")
+	classDumpCmd.Flags().String("addr", "", "Dump the ObjC method whose IMP contains the virtual address")
+	classDumpCmd.Flags().String("conformers", "", "Dump classes that conform to protocol (regex)")
+	classDumpCmd.Flags().String("sel", "", "Dump the classes, categories and protocols that declare a selector (regex)")
+	classDumpCmd.Flags().String("cat-methods", "", "Dump only the methods categories add to the classes matching a name (regex), tagged with their category")
+	classDumpCmd.Flags().String("sel-addrs", "", "Dump the selector to IMP address map of the classes matching a name (regex) as JSON")
+	classDumpCmd.Flags().Bool("json", false, "Output as JSON")
+	classDumpCmd.Flags().String("protobuf", "", "Write the dump as length-delimited Protobuf messages to this file (- for stdout)")
 	classDumpCmd.Flags().Bool("re", false, "RE verbosity (with addresses)")
-	classDumpCmd.Flags().String("arch", "", "Which architecture to use for fat/universal MachO")
+	classDumpCmd.Flags().String("arch", "", "Which architecture to use for fat/universal MachO (or 'all' with --headers)")
+	classDumpCmd.Flags().String("foundation", "", "Path to Foundation binary or SDK/root folder (used by --headers for a MachO)")
+	classDumpCmd.Flags().Bool("umbrella-only", false, "Only write the umbrella header listing the headers that would be generated (used by --headers)")
+	classDumpCmd.Flags().Bool("tags", false, "Also write a ctags 'tags' file for the headers at the output root (used by --headers)")
+	classDumpCmd.Flags().StringSlice("sel-allowlist", []string{}, "Only emit the methods/properties with these selectors, skipping classes without any (used by --headers)")
+	classDumpCmd.Flags().Bool("split-private", false, "Move underscore-prefixed methods/properties into <Class>-Private.h headers (used by --headers)")
+	classDumpCmd.Flags().Bool("verify", false, "Parse the generated umbrella header(s) with clang -fsyntax-only (requires clang, used by --headers)")
+	classDumpCmd.Flags().Bool("impl", false, "Also write a <Class>.m with stubbed @implementation method bodies next to each class header (used by --headers)")
+	classDumpCmd.Flags().Bool("bridging-header", false, "Also write a <Name>-Bridging-Header.h importing the umbrella header (used by --headers)")
+	classDumpCmd.Flags().String("foundation-cache", "", "Path to cache DSC Foundation class/protocol names in (used by --headers)")
 
 	viper.BindPFlag("class-dump.headers", classDumpCmd.Flags().Lookup("headers"))
 	viper.BindPFlag("class-dump.deps", classDumpCmd.Flags().Lookup("deps"))
+	viper.BindPFlag("class-dump.deps-depth", classDumpCmd.Flags().Lookup("deps-depth"))
+	viper.BindPFlag("class-dump.dsc", classDumpCmd.Flags().Lookup("dsc"))
+	viper.BindPFlag("class-dump.line-ending", classDumpCmd.Flags().Lookup("line-ending"))
+	viper.BindPFlag("class-dump.indent", classDumpCmd.Flags().Lookup("indent"))
+	viper.BindPFlag("class-dump.modules", classDumpCmd.Flags().Lookup("modules"))
+	viper.BindPFlag("class-dump.import-forwards", classDumpCmd.Flags().Lookup("import-forwards"))
+	viper.BindPFlag("class-dump.inline-foundation", classDumpCmd.Flags().Lookup("inline-foundation"))
 	viper.BindPFlag("class-dump.xcfw", classDumpCmd.Flags().Lookup("xcfw"))
 	viper.BindPFlag("class-dump.output", classDumpCmd.Flags().Lookup("output"))
+	viper.BindPFlag("class-dump.header-ext", classDumpCmd.Flags().Lookup("header-ext"))
+	viper.BindPFlag("class-dump.filename-tmpl", classDumpCmd.Flags().Lookup("filename-tmpl"))
 	viper.BindPFlag("class-dump.class", classDumpCmd.Flags().Lookup("class"))
 	viper.BindPFlag("class-dump.proto", classDumpCmd.Flags().Lookup("proto"))
 	viper.BindPFlag("class-dump.cat", classDumpCmd.Flags().Lookup("cat"))
+	viper.BindPFlag("class-dump.imports", classDumpCmd.Flags().Lookup("imports"))
+	viper.BindPFlag("class-dump.cat-by-class", classDumpCmd.Flags().Lookup("cat-by-class"))
 	viper.BindPFlag("class-dump.theme", classDumpCmd.Flags().Lookup("theme"))
 	viper.BindPFlag("class-dump.refs", classDumpCmd.Flags().Lookup("refs"))
+	viper.BindPFlag("class-dump.image-info", classDumpCmd.Flags().Lookup("image-info"))
+	viper.BindPFlag("class-dump.threads", classDumpCmd.Flags().Lookup("threads"))
+	viper.BindPFlag("class-dump.inventory", classDumpCmd.Flags().Lookup("inventory"))
+	viper.BindPFlag("class-dump.swift-style", classDumpCmd.Flags().Lookup("swift-style"))
+	viper.BindPFlag("class-dump.skip-swift-synthetic", classDumpCmd.Flags().Lookup("skip-swift-synthetic"))
+	viper.BindPFlag("class-dump.skip-empty", classDumpCmd.Flags().Lookup("skip-empty"))
+	viper.BindPFlag("class-dump.only-empty", classDumpCmd.Flags().Lookup("only-empty"))
+	viper.BindPFlag("class-dump.exported-only", classDumpCmd.Flags().Lookup("exported-only"))
+	viper.BindPFlag("class-dump.overrides", classDumpCmd.Flags().Lookup("overrides"))
+	viper.BindPFlag("class-dump.named-params", classDumpCmd.Flags().Lookup("named-params"))
+	viper.BindPFlag("class-dump.min-methods", classDumpCmd.Flags().Lookup("min-methods"))
+	viper.BindPFlag("class-dump.swift-module", classDumpCmd.Flags().Lookup("swift-module"))
+	viper.BindPFlag("class-dump.metaclass", classDumpCmd.Flags().Lookup("metaclass"))
+	viper.BindPFlag("class-dump.adopters", classDumpCmd.Flags().Lookup("adopters"))
+	viper.BindPFlag("class-dump.dedup-protos", classDumpCmd.Flags().Lookup("dedup-protos"))
+	viper.BindPFlag("class-dump.hide-nsobject", classDumpCmd.Flags().Lookup("hide-nsobject"))
+	viper.BindPFlag("class-dump.sort-methods", classDumpCmd.Flags().Lookup("sort-methods"))
+	viper.BindPFlag("class-dump.swift-alias", classDumpCmd.Flags().Lookup("swift-alias"))
+	viper.BindPFlag("class-dump.accessors", classDumpCmd.Flags().Lookup("accessors"))
+	viper.BindPFlag("class-dump.literal", classDumpCmd.Flags().Lookup("literal"))
+	viper.BindPFlag("class-dump.kvc", classDumpCmd.Flags().Lookup("kvc"))
+	viper.BindPFlag("class-dump.table", classDumpCmd.Flags().Lookup("table"))
+	viper.BindPFlag("class-dump.sort", classDumpCmd.Flags().Lookup("sort"))
+	viper.BindPFlag("class-dump.ivar", classDumpCmd.Flags().Lookup("ivar"))
+	viper.BindPFlag("class-dump.ivar-diff", classDumpCmd.Flags().Lookup("ivar-diff"))
+	viper.BindPFlag("class-dump.addr", classDumpCmd.Flags().Lookup("addr"))
+	viper.BindPFlag("class-dump.conformers", classDumpCmd.Flags().Lookup("conformers"))
+	viper.BindPFlag("class-dump.sel", classDumpCmd.Flags().Lookup("sel"))
+	viper.BindPFlag("class-dump.cat-methods", classDumpCmd.Flags().Lookup("cat-methods"))
+	viper.BindPFlag("class-dump.sel-addrs", classDumpCmd.Flags().Lookup("sel-addrs"))
+	viper.BindPFlag("class-dump.json", classDumpCmd.Flags().Lookup("json"))
+	viper.BindPFlag("class-dump.protobuf", classDumpCmd.Flags().Lookup("protobuf"))
 	viper.BindPFlag("class-dump.re", classDumpCmd.Flags().Lookup("re"))
 	viper.BindPFlag("class-dump.arch", classDumpCmd.Flags().Lookup("arch"))
+	viper.BindPFlag("class-dump.foundation", classDumpCmd.Flags().Lookup("foundation"))
+	viper.BindPFlag("class-dump.baseline", classDumpCmd.Flags().Lookup("baseline"))
+	viper.BindPFlag("class-dump.baseline-all", classDumpCmd.Flags().Lookup("baseline-all"))
+	viper.BindPFlag("class-dump.stable-header", classDumpCmd.Flags().Lookup("stable-header"))
+	viper.BindPFlag("class-dump.umbrella-only", classDumpCmd.Flags().Lookup("umbrella-only"))
+	viper.BindPFlag("class-dump.tags", classDumpCmd.Flags().Lookup("tags"))
+	viper.BindPFlag("class-dump.sel-allowlist", classDumpCmd.Flags().Lookup("sel-allowlist"))
+	viper.BindPFlag("class-dump.split-private", classDumpCmd.Flags().Lookup("split-private"))
+	viper.BindPFlag("class-dump.verify", classDumpCmd.Flags().Lookup("verify"))
+	viper.BindPFlag("class-dump.impl", classDumpCmd.Flags().Lookup("impl"))
+	viper.BindPFlag("class-dump.bridging-header", classDumpCmd.Flags().Lookup("bridging-header"))
+	viper.BindPFlag("class-dump.foundation-cache", classDumpCmd.Flags().Lookup("foundation-cache"))
+}
+
+// classDumpConfig returns the ObjC parser config of the class-dump flags
+func classDumpConfig() mcmd.ObjcConfig {
+	return mcmd.ObjcConfig{
+		Verbose:            Verbose,
+		Addrs:              viper.GetBool("class-dump.re"),
+		Headers:            viper.GetBool("class-dump.headers"),
+		ObjcRefs:           viper.GetBool("class-dump.refs"),
+		Deps:               viper.GetBool("class-dump.deps"),
+		DepsDepth:          viper.GetInt("class-dump.deps-depth"),
+		CatsByClass:        viper.GetBool("class-dump.cat-by-class"),
+		JSON:               viper.GetBool("class-dump.json"),
+		SwiftStyle:         viper.GetBool("class-dump.swift-style"),
+		KVCKeys:            viper.GetBool("class-dump.kvc"),
+		SkipSwiftSynthetic: viper.GetBool("class-dump.skip-swift-synthetic"),
+		MinMethods:         viper.GetInt("class-dump.min-methods"),
+		SkipEmpty:          viper.GetBool("class-dump.skip-empty"),
+		OnlyEmpty:          viper.GetBool("class-dump.only-empty"),
+		MarkOverrides:      viper.GetBool("class-dump.overrides"),
+		NamedParams:        viper.GetBool("class-dump.named-params"),
+		ExportedOnly:       viper.GetBool("class-dump.exported-only"),
+		Module:             viper.GetString("class-dump.swift-module"),
+		Metaclass:          viper.GetBool("class-dump.metaclass"),
+		ShowAdopters:       viper.GetBool("class-dump.adopters"),
+		DedupProtocols:     viper.GetBool("class-dump.dedup-protos"),
+		HideNSObject:       viper.GetBool("class-dump.hide-nsobject"),
+		SortMethods:        viper.GetBool("class-dump.sort-methods"),
+		SwiftAliases:       viper.GetBool("class-dump.swift-alias"),
+		PropertyAccessors:  viper.GetBool("class-dump.accessors"),
+		Literal:            viper.GetBool("class-dump.literal"),
+		Threads:            viper.GetInt("class-dump.threads"),
+		TableSort:          viper.GetString("class-dump.sort"),
+		IpswVersion:        fmt.Sprintf("Version: %s, BuildTime: %s", strings.TrimSpace(AppVersion), strings.TrimSpace(AppBuildTime)),
+		FoundationPath:     viper.GetString("class-dump.foundation"),
+		FoundationCache:    viper.GetString("class-dump.foundation-cache"),
+		BridgingHeader:     viper.GetBool("class-dump.bridging-header"),
+		EmitImpl:           viper.GetBool("class-dump.impl"),
+		UmbrellaOnly:       viper.GetBool("class-dump.umbrella-only"),
+		SplitPrivate:       viper.GetBool("class-dump.split-private"),
+		SelectorAllowlist:  viper.GetStringSlice("class-dump.sel-allowlist"),
+		Tags:               viper.GetBool("class-dump.tags"),
+		FilenameTemplate:   viper.GetString("class-dump.filename-tmpl"),
+		HeaderExt:          viper.GetString("class-dump.header-ext"),
+		UseModules:         viper.GetBool("class-dump.modules"),
+		InlineFoundation:   viper.GetBool("class-dump.inline-foundation"),
+		ImportsNotForwards: viper.GetBool("class-dump.import-forwards"),
+		LineEnding:         viper.GetString("class-dump.line-ending"),
+		IndentStyle:        viper.GetString("class-dump.indent"),
+		NoBanner:           viper.GetBool("class-dump.stable-header"),
+		Baseline:           viper.GetString("class-dump.baseline"),
+		BaselineAll:        viper.GetBool("class-dump.baseline-all"),
+		Color:              viper.GetBool("color") && !viper.GetBool("no-color"),
+		Theme:              viper.GetString("class-dump.theme"),
+		Output:             viper.GetString("class-dump.output"),
+	}
 }
 
 // classDumpCmd represents the classDump command
@@ -103,6 +328,7 @@ var classDumpCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var m *macho.File
 		var o *mcmd.ObjC
+		var image string // the dumped DSC image (for --ivar-diff)
 
 		if viper.GetBool("verbose") {
 			log.SetLevel(log.DebugLevel)
@@ -116,30 +342,38 @@ var classDumpCmd = &cobra.Command{
 			return fmt.Errorf("cannot dump --headers and use --class, --protocol or --category flags")
 		} else if viper.GetBool("class-dump.headers") && viper.GetBool("class-dump.xcfw") {
 			return fmt.Errorf("cannot dump --headers and use --xcfw flag")
+		} else if viper.GetBool("class-dump.headers") && viper.GetBool("class-dump.swift-style") {
+			return fmt.Errorf("cannot dump --headers and use --swift-style flag")
 		} else if viper.GetBool("class-dump.re") && !Verbose {
 			return fmt.Errorf("cannot use --re without --verbose")
+		} else if viper.GetBool("class-dump.verify") &&
+			(!viper.GetBool("class-dump.headers") ||
+				len(viper.GetString("class-dump.baseline")) > 0 ||
+				viper.GetBool("class-dump.umbrella-only") ||
+				mcmd.IsTarGz(viper.GetString("class-dump.output"))) {
+			return fmt.Errorf("--verify is only supported with --headers (to an output folder and w/o --baseline or --umbrella-only)")
+		} else if viper.GetBool("class-dump.baseline-all") && len(viper.GetString("class-dump.baseline")) == 0 {
+			return fmt.Errorf("--baseline-all requires --baseline")
+		} else if viper.GetBool("class-dump.skip-empty") && viper.GetBool("class-dump.only-empty") {
+			return fmt.Errorf("cannot use --skip-empty and --only-empty flags together")
 		}
 
-		if len(viper.GetString("class-dump.output")) > 0 {
-			if err := os.MkdirAll(viper.GetString("class-dump.output"), 0o750); err != nil {
+		if output := viper.GetString("class-dump.output"); len(output) > 0 {
+			if mcmd.IsTarGz(output) {
+				output = filepath.Dir(output)
+			}
+			if err := os.MkdirAll(output, 0o750); err != nil {
 				return err
 			}
 		}
 
-		conf := mcmd.ObjcConfig{
-			Verbose:     Verbose,
-			Addrs:       viper.GetBool("class-dump.re"),
-			Headers:     viper.GetBool("class-dump.headers"),
-			ObjcRefs:    viper.GetBool("class-dump.refs"),
-			Deps:        viper.GetBool("class-dump.deps"),
-			IpswVersion: fmt.Sprintf("Version: %s, BuildTime: %s", strings.TrimSpace(AppVersion), strings.TrimSpace(AppBuildTime)),
-			Color:       viper.GetBool("color") && !viper.GetBool("no-color"),
-			Theme:       viper.GetString("class-dump.theme"),
-			Output:      viper.GetString("class-dump.output"),
-		}
+		conf := classDumpConfig()
 
 		if ok, _ := magic.IsMachO(args[0]); ok { /* MachO binary */
 			machoPath := filepath.Clean(args[0])
+			var shortOptions []string
+			var archName string // selected slice of a fat MachO
+			var allArches bool
 			// first check for fat file
 			fat, err := macho.OpenFat(machoPath)
 			if err != nil && err != macho.ErrNotFat {
@@ -152,17 +386,22 @@ var classDumpCmd = &cobra.Command{
 				}
 			} else {
 				var options []string
-				var shortOptions []string
 				for _, arch := range fat.Arches {
 					options = append(options, fmt.Sprintf("%s, %s", arch.CPU, arch.SubCPU.String(arch.CPU)))
 					shortOptions = append(shortOptions, strings.ToLower(arch.SubCPU.String(arch.CPU)))
 				}
 
-				if len(viper.GetString("class-dump.arch")) > 0 {
+				if strings.EqualFold(viper.GetString("class-dump.arch"), "all") {
+					if !viper.GetBool("class-dump.headers") || mcmd.IsTarGz(viper.GetString("class-dump.output")) {
+						return fmt.Errorf("--arch all is only supported with --headers (to an output folder)")
+					}
+					allArches = true
+				} else if len(viper.GetString("class-dump.arch")) > 0 {
 					found := false
 					for i, opt := range shortOptions {
 						if strings.Contains(strings.ToLower(opt), strings.ToLower(viper.GetString("class-dump.arch"))) {
 							m = fat.Arches[i].File
+							archName = opt
 							found = true
 							break
 						}
@@ -178,21 +417,55 @@ var classDumpCmd = &cobra.Command{
 					}
 					survey.AskOne(prompt, &choice)
 					m = fat.Arches[choice].File
+					archName = shortOptions[choice]
 				}
 			}
-			if viper.GetBool("class-dump.deps") {
-				log.Error("cannot dump imported private frameworks from a MachO file (only from a DSC)")
+			var f *dyld.File
+			if dscPath := viper.GetString("class-dump.dsc"); len(dscPath) > 0 {
+				f, err = dyld.Open(dscPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+			} else if viper.GetBool("class-dump.deps") {
+				log.Error("cannot dump imported private frameworks from a MachO file (only from a DSC or with --dsc)")
 			}
 
 			conf.Name = filepath.Base(machoPath)
 
-			o, err = mcmd.NewObjC(m, nil, &conf)
+			if allArches { // dump each slice's headers side by side
+				for i, arch := range fat.Arches {
+					aconf := conf
+					aconf.Output = filepath.Join(conf.Output, archDir(shortOptions[i]))
+					ao, err := mcmd.NewObjC(arch.File, f, &aconf)
+					if err != nil {
+						return fmt.Errorf("failed to parse %s slice: %v", shortOptions[i], err)
+					}
+					if err := ao.Headers(); err != nil {
+						return fmt.Errorf("failed to generate %s slice headers: %v", shortOptions[i], err)
+					}
+					if viper.GetBool("class-dump.verify") {
+						if err := ao.VerifyHeaders(); err != nil {
+							return fmt.Errorf("failed to verify %s slice headers: %v", shortOptions[i], err)
+						}
+					}
+				}
+				return nil
+			}
+			if len(archName) > 0 && viper.GetBool("class-dump.headers") && !mcmd.IsTarGz(conf.Output) {
+				conf.Output = filepath.Join(conf.Output, archDir(archName))
+			}
+
+			o, err = mcmd.NewObjC(m, f, &conf)
 			if err != nil {
 				return err
 			}
 		} else { /* DSC file */
 			if len(args) < 2 {
-				return fmt.Errorf("must provide an in-cache DYLIB to dump")
+				if !viper.GetBool("class-dump.inventory") {
+					return fmt.Errorf("must provide an in-cache DYLIB to dump")
+				}
+				args = append(args, "/usr/lib/libobjc.A.dylib") // inventory is cache-wide (any ObjC image will do)
 			}
 
 			f, err := dyld.Open(args[0])
@@ -212,6 +485,7 @@ var classDumpCmd = &cobra.Command{
 			}
 
 			conf.Name = filepath.Base(img.Name)
+			image = img.Name
 
 			o, err = mcmd.NewObjC(m, f, &conf)
 			if err != nil {
@@ -219,14 +493,87 @@ var classDumpCmd = &cobra.Command{
 			}
 		}
 
+		if viper.GetBool("verbose") {
+			defer func() { // summarize the images that had nothing to dump
+				skipped := o.Skipped()
+				images := make([]string, 0, len(skipped))
+				for image := range skipped {
+					images = append(images, image)
+				}
+				slices.Sort(images)
+				for _, image := range images {
+					log.Debugf("Skipped %s (no ObjC %s)", image, strings.Join(skipped[image], ", "))
+				}
+			}()
+		}
+
 		if viper.GetBool("class-dump.headers") {
-			return o.Headers()
+			if err := o.Headers(); err != nil {
+				return err
+			}
+			if viper.GetBool("class-dump.verify") {
+				return o.VerifyHeaders()
+			}
+			return nil
 		}
 
 		if viper.GetBool("class-dump.xcfw") {
 			return o.XCFramework()
 		}
 
+		if viper.GetBool("class-dump.image-info") {
+			return o.DumpImageInfo()
+		}
+
+		if viper.GetBool("class-dump.inventory") {
+			return o.DumpInventory()
+		}
+
+		if viper.GetString("class-dump.conformers") != "" {
+			return o.DumpConformers(viper.GetString("class-dump.conformers"))
+		}
+
+		if viper.GetString("class-dump.sel") != "" {
+			return o.DumpSelector(viper.GetString("class-dump.sel"))
+		}
+
+		if viper.GetString("class-dump.cat-methods") != "" {
+			return o.DumpCategoryMethods(viper.GetString("class-dump.cat-methods"))
+		}
+
+		if viper.GetString("class-dump.sel-addrs") != "" {
+			return o.SelectorAddressMap(viper.GetString("class-dump.sel-addrs"))
+		}
+
+		if viper.GetBool("class-dump.table") {
+			return o.DumpTable(viper.GetString("class-dump.class"))
+		}
+
+		if viper.GetString("class-dump.addr") != "" {
+			addr, err := utils.ConvertStrToInt(viper.GetString("class-dump.addr"))
+			if err != nil {
+				return fmt.Errorf("invalid --addr: %v", err)
+			}
+			return o.DumpMethodForAddress(addr)
+		}
+
+		if prev := viper.GetString("class-dump.ivar-diff"); prev != "" {
+			pm, closePrev, err := openPrevious(filepath.Clean(prev), m, image)
+			if err != nil {
+				return fmt.Errorf("failed to open --ivar-diff MachO: %v", err)
+			}
+			defer closePrev()
+			return o.DumpIvarLayoutDiff(pm)
+		}
+
+		if viper.GetString("class-dump.ivar") != "" {
+			return o.DumpClassesWithIvar(viper.GetString("class-dump.ivar"))
+		}
+
+		if viper.GetString("class-dump.imports") != "" {
+			return o.DumpImports(viper.GetString("class-dump.imports"))
+		}
+
 		if viper.GetString("class-dump.class") != "" {
 			if err := o.DumpClass(viper.GetString("class-dump.class")); err != nil {
 				return err
@@ -248,6 +595,19 @@ var classDumpCmd = &cobra.Command{
 		if viper.GetString("class-dump.class") == "" &&
 			viper.GetString("class-dump.proto") == "" &&
 			viper.GetString("class-dump.cat") == "" {
+			if viper.GetBool("class-dump.json") {
+				return o.DumpJSON()
+			}
+			if pb := viper.GetString("class-dump.protobuf"); pb == "-" {
+				return o.DumpProtobuf(os.Stdout)
+			} else if pb != "" {
+				pbFile, err := os.Create(filepath.Clean(pb))
+				if err != nil {
+					return fmt.Errorf("failed to create Protobuf dump file %s: %v", pb, err)
+				}
+				defer pbFile.Close()
+				return o.DumpProtobuf(pbFile)
+			}
 			return o.Dump()
 		}
 
@@ -0,0 +1,21 @@
+package cmd
+
+import "testing"
+
+func Test_classDumpConfig(t *testing.T) {
+	flags := classDumpCmd.Flags()
+	for _, flag := range []string{"json", "named-params", "exported-only", "baseline-all"} {
+		if err := flags.Set(flag, "true"); err != nil {
+			t.Fatalf("failed to set --%s: %v", flag, err)
+		}
+		flag := flag
+		t.Cleanup(func() { flags.Set(flag, "false") })
+	}
+	conf := classDumpConfig()
+	if !conf.JSON {
+		t.Errorf("classDumpConfig().JSON = false with --json")
+	}
+	if !conf.NamedParams || !conf.ExportedOnly || !conf.BaselineAll {
+		t.Errorf("classDumpConfig() = %+v, want NamedParams, ExportedOnly and BaselineAll set", conf)
+	}
+}
@@ -48,6 +48,19 @@ type dscFunc struct {
 	Size  uint64 `json:"size,omitempty"`
 	Name  string `json:"name,omitempty"`
 	Image string `json:"image,omitempty"`
+	// Source is where Name came from: symtab, export, objc, synthesized, region (see a2f --region-fallback) or data (see a2f --data)
+	Source string `json:"source,omitempty"`
+	// Block is the block invoke/Swift closure the function implements (see a2f --objc and --swift)
+	Block *dscBlock `json:"block,omitempty"`
+	// ObjC is the class (and its ivars) of the ObjC method the function implements (see a2f --objc-context)
+	ObjC *dscObjC `json:"objc,omitempty"`
+	// File and Line are the source location of Addr (see a2f --dsym)
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Context is the neighboring functions (see a2f --context)
+	Context []dscFunc `json:"context,omitempty"`
+	// Error is why the address couldn't be resolved (see a2f --in)
+	Error string `json:"error,omitempty"`
 }
 
 func getDSCs(path string) []string {
@@ -23,12 +23,22 @@ package dyld
 
 import (
 	"bufio"
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/bits"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/internal/demangle"
 	"github.com/blacktop/ipsw/internal/utils"
 	"github.com/blacktop/ipsw/pkg/dyld"
 	"github.com/fatih/color"
@@ -39,24 +49,60 @@ import (
 
 func init() {
 	DyldCmd.AddCommand(AddrToFuncCmd)
-	AddrToFuncCmd.Flags().Uint64P("slide", "s", 0, "dyld_shared_cache slide to apply")
+	AddrToFuncCmd.Flags().Uint64P("slide", "s", 0, "dyld_shared_cache slide to apply (addresses can be PAC signed on arm64e or Thumb tagged on armv7)")
 	AddrToFuncCmd.Flags().StringP("in", "i", "", "Path to file containing list of addresses to lookup")
 	AddrToFuncCmd.Flags().StringP("out", "o", "", "Path to output JSON file")
+	AddrToFuncCmd.Flags().Bool("names-only", false, "Only output the function name of each --in address, one per line in input order (? if unresolved)")
+	AddrToFuncCmd.Flags().String("format", "json", "--in output format: json, ghidra or ida (a Python script naming the functions)")
+	AddrToFuncCmd.Flags().String("report", "", "Path to write the --in coverage report JSON to (default: stderr)")
 	AddrToFuncCmd.Flags().BoolP("json", "j", false, "Output as JSON")
-	AddrToFuncCmd.Flags().StringP("cache", "c", "", "Path to .a2s addr to sym cache file (speeds up analysis)")
+	AddrToFuncCmd.Flags().Bool("json-indent", false, "Indent JSON output (default is compact for pipelines)")
+	AddrToFuncCmd.Flags().StringP("cache", "c", "", "Path to .a2s addr to sym cache file (speeds up analysis; default: $"+a2sCacheEnv+", else <DSC>.a2s)")
+	AddrToFuncCmd.Flags().Bool("resolve-stubs", false, "Resolve symbol/ObjC stubs to their targets")
+	AddrToFuncCmd.Flags().BoolP("demangle", "d", false, "Demangle symbol names")
+	AddrToFuncCmd.Flags().Bool("repl", false, "Interactively lookup addresses read from stdin")
+	AddrToFuncCmd.Flags().String("symbol", "", "Lookup the function bounds of a symbol (instead of an address)")
+	AddrToFuncCmd.Flags().String("image", "", "Image to lookup --symbol in (default: all images)")
+	AddrToFuncCmd.Flags().Int("context", 0, "Also list the N functions before and after the containing function")
+	AddrToFuncCmd.Flags().String("serve", "", "Serve lookups of addresses POSTed (as a JSON array) to http://<addr>/a2f (e.g. :3993)")
+	AddrToFuncCmd.Flags().Bool("objc", false, "Annotate (clang) block invoke functions with the function that creates the block")
+	AddrToFuncCmd.Flags().Bool("swift", false, "Annotate Swift closures with the function they are defined in")
+	AddrToFuncCmd.Flags().Bool("objc-context", false, "List the ivars of the class of functions that are ObjC method IMPs")
+	AddrToFuncCmd.Flags().String("dsym", "", "Path to a dSYM (or folder of dSYMs) of the cache's images to add the file:line of addresses")
+	AddrToFuncCmd.Flags().Bool("mappings", false, "List the cache's mappings and subcache boundaries (instead of looking up addresses)")
+	AddrToFuncCmd.Flags().Bool("region-fallback", false, "Report the cache region (e.g. objc selector table) of addresses not in any image instead of erroring")
+	AddrToFuncCmd.Flags().Bool("data", false, "Report the data symbol (e.g. global or ObjC class object) containing non-code addresses")
 
 	viper.BindPFlag("dyld.a2f.slide", AddrToFuncCmd.Flags().Lookup("slide"))
 	viper.BindPFlag("dyld.a2f.in", AddrToFuncCmd.Flags().Lookup("in"))
 	viper.BindPFlag("dyld.a2f.out", AddrToFuncCmd.Flags().Lookup("out"))
+	viper.BindPFlag("dyld.a2f.names-only", AddrToFuncCmd.Flags().Lookup("names-only"))
+	viper.BindPFlag("dyld.a2f.format", AddrToFuncCmd.Flags().Lookup("format"))
+	viper.BindPFlag("dyld.a2f.report", AddrToFuncCmd.Flags().Lookup("report"))
 	viper.BindPFlag("dyld.a2f.json", AddrToFuncCmd.Flags().Lookup("json"))
+	viper.BindPFlag("dyld.a2f.json-indent", AddrToFuncCmd.Flags().Lookup("json-indent"))
 	viper.BindPFlag("dyld.a2f.cache", AddrToFuncCmd.Flags().Lookup("cache"))
+	viper.BindPFlag("dyld.a2f.resolve-stubs", AddrToFuncCmd.Flags().Lookup("resolve-stubs"))
+	viper.BindPFlag("dyld.a2f.demangle", AddrToFuncCmd.Flags().Lookup("demangle"))
+	viper.BindPFlag("dyld.a2f.repl", AddrToFuncCmd.Flags().Lookup("repl"))
+	viper.BindPFlag("dyld.a2f.symbol", AddrToFuncCmd.Flags().Lookup("symbol"))
+	viper.BindPFlag("dyld.a2f.image", AddrToFuncCmd.Flags().Lookup("image"))
+	viper.BindPFlag("dyld.a2f.context", AddrToFuncCmd.Flags().Lookup("context"))
+	viper.BindPFlag("dyld.a2f.serve", AddrToFuncCmd.Flags().Lookup("serve"))
+	viper.BindPFlag("dyld.a2f.objc", AddrToFuncCmd.Flags().Lookup("objc"))
+	viper.BindPFlag("dyld.a2f.swift", AddrToFuncCmd.Flags().Lookup("swift"))
+	viper.BindPFlag("dyld.a2f.objc-context", AddrToFuncCmd.Flags().Lookup("objc-context"))
+	viper.BindPFlag("dyld.a2f.dsym", AddrToFuncCmd.Flags().Lookup("dsym"))
+	viper.BindPFlag("dyld.a2f.mappings", AddrToFuncCmd.Flags().Lookup("mappings"))
+	viper.BindPFlag("dyld.a2f.region-fallback", AddrToFuncCmd.Flags().Lookup("region-fallback"))
+	viper.BindPFlag("dyld.a2f.data", AddrToFuncCmd.Flags().Lookup("data"))
 }
 
 // AddrToFuncCmd represents the a2f command
 var AddrToFuncCmd = &cobra.Command{
-	Use:   "a2f <DSC> <ADDR>",
+	Use:   "a2f <DSC> [ADDR]",
 	Short: "Lookup function containing unslid address",
-	Args:  cobra.ExactArgs(2),
+	Args:  cobra.RangeArgs(1, 2),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -74,8 +120,26 @@ var AddrToFuncCmd = &cobra.Command{
 		slide := viper.GetUint64("dyld.a2f.slide")
 		ptrFile := viper.GetString("dyld.a2f.in")
 		jsonFile := viper.GetString("dyld.a2f.out")
+		format := viper.GetString("dyld.a2f.format")
+		namesOnly := viper.GetBool("dyld.a2f.names-only")
+		reportFile := viper.GetString("dyld.a2f.report")
 		asJSON := viper.GetBool("dyld.a2f.json")
+		jsonIndent := viper.GetBool("dyld.a2f.json-indent")
 		cacheFile := viper.GetString("dyld.a2f.cache")
+		resolveStubs := viper.GetBool("dyld.a2f.resolve-stubs")
+		doDemangle := viper.GetBool("dyld.a2f.demangle")
+		repl := viper.GetBool("dyld.a2f.repl")
+		context := viper.GetInt("dyld.a2f.context")
+		symbol := viper.GetString("dyld.a2f.symbol")
+		imageName := viper.GetString("dyld.a2f.image")
+		serve := viper.GetString("dyld.a2f.serve")
+		regionFallback := viper.GetBool("dyld.a2f.region-fallback")
+		dataFallback := viper.GetBool("dyld.a2f.data")
+		objcBlocks := viper.GetBool("dyld.a2f.objc")
+		swiftClosures := viper.GetBool("dyld.a2f.swift")
+		objcCtx := viper.GetBool("dyld.a2f.objc-context")
+		mappings := viper.GetBool("dyld.a2f.mappings")
+		dsymPath := viper.GetString("dyld.a2f.dsym")
 
 		dscPath := filepath.Clean(args[0])
 
@@ -103,11 +167,69 @@ var AddrToFuncCmd = &cobra.Command{
 		}
 		defer f.Close()
 
+		if context < 0 {
+			return fmt.Errorf("--context must not be negative")
+		} else if context > 0 && len(ptrFile) > 0 {
+			return fmt.Errorf("--context is not supported with --in")
+		} else if len(reportFile) > 0 && len(ptrFile) == 0 {
+			return fmt.Errorf("--report requires --in")
+		} else if _, ok := a2fScriptFormats[format]; !ok && format != "json" {
+			return fmt.Errorf("--format must be json, ghidra or ida")
+		} else if format != "json" && len(ptrFile) == 0 {
+			return fmt.Errorf("--format requires --in")
+		} else if namesOnly && (len(ptrFile) == 0 || format != "json") {
+			return fmt.Errorf("--names-only requires --in (and cannot be used with --format)")
+		} else if len(imageName) > 0 && len(symbol) == 0 {
+			return fmt.Errorf("--image requires --symbol")
+		} else if len(serve) > 0 && (len(ptrFile) > 0 || repl || len(symbol) > 0 || context > 0 || len(args) > 1) {
+			return fmt.Errorf("--serve cannot be used with an ADDR, --in, --repl, --symbol or --context")
+		} else if mappings && (len(ptrFile) > 0 || repl || len(symbol) > 0 || len(serve) > 0 || len(args) > 1) {
+			return fmt.Errorf("--mappings cannot be used with an ADDR, --in, --repl, --symbol or --serve")
+		}
+
+		var dsyms dscDSYMs
+		if len(dsymPath) > 0 {
+			if dsyms, err = openDSYMs(filepath.Clean(dsymPath)); err != nil {
+				return err
+			}
+		}
+
+		if mappings {
+			if asJSON {
+				return newJSONEncoder(os.Stdout, jsonIndent).Encode(cacheMappings(f))
+			}
+			return writeMappings(os.Stdout, cacheMappings(f))
+		}
+
+		if len(serve) > 0 {
+			cacheFile = a2sCachePath(cacheFile, dscPath, f.UUID.String())
+			if err := f.OpenOrCreateA2SCache(cacheFile); err != nil {
+				return err
+			}
+			return serveA2F(&a2fServer{
+				f:              f,
+				exports:        newDSCExports(f),
+				slide:          slide,
+				resolveStubs:   resolveStubs,
+				doDemangle:     doDemangle,
+				regionFallback: regionFallback,
+				dataFallback:   dataFallback,
+				jsonIndent:     jsonIndent,
+				objcBlocks:     objcBlocks,
+				swiftClosures:  swiftClosures,
+				objcCtx:        objcCtx,
+				dsyms:          dsyms,
+			}, serve)
+		}
+
 		if len(ptrFile) > 0 {
 			var fs []dscFunc
-			var enc *json.Encoder
+			var out io.Writer = os.Stdout
+			report := a2fReport{Unresolved: []string{}}
 
 			imap := make(map[*dyld.CacheImage][]uint64)
+			imageBases := make(map[string]uint64)
+			var order []uint64 // the unslid input addresses (for --names-only)
 
 			pfile, err := os.Open(ptrFile)
 			if err != nil {
@@ -121,20 +243,36 @@ var AddrToFuncCmd = &cobra.Command{
 			for scanner.Scan() {
 				addr, err := utils.ConvertStrToInt(scanner.Text())
 				if err != nil {
+					if namesOnly { // keep the output lines 1:1 with the input (0 never resolves)
+						log.Errorf("invalid address '%s' in %s: %v", scanner.Text(), ptrFile, err)
+						order = append(order, 0)
+						continue
+					}
 					return err
 				}
 
-				var unslidAddr uint64 = addr
-				if slide > 0 {
-					unslidAddr = addr - slide
-				}
+				unslidAddr := unslideAddr(f, addr, slide)
+				order = append(order, unslidAddr)
+
+				report.Total++
 
 				image, err := f.GetImageContainingVMAddr(unslidAddr)
+				if err != nil && regionFallback {
+					if fn, rerr := regionFunc(f, unslidAddr); rerr == nil {
+						fs = append(fs, fn)
+						report.Region++
+						continue
+					}
+				}
 				if err != nil {
-					return err
+					log.Errorf("failed to lookup %s in %s: %v", scanner.Text(), ptrFile, err)
+					fs = append(fs, dscFunc{Addr: unslidAddr, Error: err.Error()})
+					report.Unresolved = append(report.Unresolved, fmt.Sprintf("%#x", unslidAddr))
+					continue
 				}
 
 				imap[image] = append(imap[image], unslidAddr)
+				imageBases[filepath.Base(image.Name)] = image.Info.Address
 			}
 
 			if err := scanner.Err(); err != nil {
@@ -147,18 +285,15 @@ var AddrToFuncCmd = &cobra.Command{
 					return err
 				}
 				defer jFile.Close()
-				enc = json.NewEncoder(jFile)
-			} else {
-				enc = json.NewEncoder(os.Stdout)
+				out = jFile
 			}
 
-			if len(cacheFile) == 0 {
-				cacheFile = dscPath + ".a2s"
-			}
+			cacheFile = a2sCachePath(cacheFile, dscPath, f.UUID.String())
 			if err := f.OpenOrCreateA2SCache(cacheFile); err != nil {
 				return err
 			}
 
+			exports := newDSCExports(f)
 			for img, ptrs := range imap {
 				m, err := img.GetMacho()
 				if err != nil {
@@ -166,87 +301,487 @@ var AddrToFuncCmd = &cobra.Command{
 				}
 				defer m.Close()
 
+				var classes []objc.Class
+				if objcCtx {
+					classes = objcClasses(m)
+				}
+
 				for _, ptr := range ptrs {
-					if fn, err := m.GetFunctionForVMAddr(ptr); err == nil {
-						if symName, ok := f.AddressToSymbol[fn.StartAddr]; ok {
-							fn.Name = symName
+					fn := resolveFunc(f, exports, img, m, ptr, resolveStubs)
+					if len(fn.Error) > 0 && dataFallback {
+						if data, err := dataFunc(img, m, ptr); err == nil {
+							fn = data
 						}
-						fs = append(fs, dscFunc{
-							Addr:  ptr,
-							Start: fn.StartAddr,
-							End:   fn.EndAddr,
-							Size:  fn.EndAddr - fn.StartAddr,
-							Name:  fn.Name,
-							Image: filepath.Base(img.Name),
-						})
 					}
+					if fn.Source != "synthesized" && len(fn.Error) == 0 {
+						fn.Block = blockInfo(img, fn.Name, objcBlocks, swiftClosures)
+					}
+					dsyms.addSourceLine(&fn, img, m)
+					if objcCtx && len(fn.Error) == 0 {
+						fn.ObjC = objcContext(classes, fn.Name, fn.Start)
+					}
+					if len(fn.Error) > 0 {
+						report.Unresolved = append(report.Unresolved, fmt.Sprintf("%#x", ptr))
+					} else {
+						report.add(fn.Source)
+					}
+					fs = append(fs, fn)
 				}
 			}
 
-			if err := enc.Encode(fs); err != nil {
-				return err
-			}
-		} else {
-			if len(args) < 2 {
-				return fmt.Errorf("you must supply an virtual address")
-			}
-			addr, err := utils.ConvertStrToInt(args[1])
-			if err != nil {
-				return err
+			if doDemangle {
+				for i := range fs {
+					fs[i].Name = demangle.Do(fs[i].Name, false, false)
+					if fs[i].Block != nil {
+						fs[i].Block.Parent = demangle.Do(fs[i].Block.Parent, false, false)
+					}
+				}
 			}
 
-			var unslidAddr uint64 = addr
-			if slide > 0 {
-				unslidAddr = addr - slide
-			}
+			sortFuncs(fs)
 
-			image, err := f.GetImageContainingVMAddr(unslidAddr)
-			if err != nil {
-				return err
+			if namesOnly {
+				if err := writeNames(out, order, fs); err != nil {
+					return fmt.Errorf("failed to write names: %v", err)
+				}
+			} else if format == "json" {
+				if err := newJSONEncoder(out, jsonIndent).Encode(fs); err != nil {
+					return err
+				}
+			} else if err := writeA2FScript(out, format, f.Headers[f.UUID].SharedRegionStart, imageBases, fs); err != nil {
+				return fmt.Errorf("failed to write %s script: %v", format, err)
 			}
 
-			m, err := image.GetMacho()
-			if err != nil {
-				return err
+			rout := os.Stderr
+			if len(reportFile) > 0 {
+				rout, err = os.Create(reportFile)
+				if err != nil {
+					return fmt.Errorf("failed to create report file %s: %v", reportFile, err)
+				}
+				defer rout.Close()
 			}
-			defer m.Close()
-
-			// Load all symbols
-			if err := image.Analyze(); err != nil {
-				return err
+			if err := newJSONEncoder(rout, jsonIndent).Encode(report); err != nil {
+				return fmt.Errorf("failed to write report: %v", err)
 			}
+		} else {
+			analyzed := make(map[*dyld.CacheImage]bool)
+			exports := newDSCExports(f)
 
-			if fn, err := m.GetFunctionForVMAddr(unslidAddr); err == nil {
-				if asJSON {
-					if symName, ok := f.AddressToSymbol[fn.StartAddr]; ok {
-						fn.Name = symName
+			lookup := func(addr uint64) error {
+				unslidAddr := unslideAddr(f, addr, slide)
+
+				image, err := f.GetImageContainingVMAddr(unslidAddr)
+				if err != nil {
+					if !regionFallback {
+						return err
 					}
-					if err := json.NewEncoder(os.Stdout).Encode(dscFunc{
-						Addr:  addr,
-						Start: fn.StartAddr,
-						End:   fn.EndAddr,
-						Size:  fn.EndAddr - fn.StartAddr,
-						Name:  fn.Name,
-						Image: filepath.Base(image.Name),
-					}); err != nil {
+					fn, rerr := regionFunc(f, unslidAddr)
+					if rerr != nil {
 						return err
 					}
-				} else {
-					if symName, ok := f.AddressToSymbol[fn.StartAddr]; ok {
-						if unslidAddr-fn.StartAddr == 0 {
-							fmt.Printf("\n%#x: %s (start: %#x, end: %#x)\n", addr, symName, fn.StartAddr, fn.EndAddr)
+					if asJSON {
+						fn.Addr = addr
+						return newJSONEncoder(os.Stdout, jsonIndent).Encode(fn)
+					}
+					fmt.Printf("\n%#x: not in any image, in %s + %d (start: %#x, end: %#x)\n", addr, fn.Name, unslidAddr-fn.Start, fn.Start, fn.End)
+					return nil
+				}
+
+				m, err := image.GetMacho()
+				if err != nil {
+					return err
+				}
+
+				// Load all symbols
+				if !analyzed[image] {
+					if err := image.Analyze(); err != nil {
+						return err
+					}
+					analyzed[image] = true
+				}
+
+				if resolveStubs {
+					stub, err := dsc.LookupStub(f, image, unslidAddr)
+					if err == nil {
+						source := exports.stubSource(stub)
+						if doDemangle {
+							stub.Name = demangle.Do(stub.Name, false, false)
+						}
+						if asJSON {
+							return newJSONEncoder(os.Stdout, jsonIndent).Encode(dscFunc{
+								Addr:   addr,
+								Start:  stub.Address,
+								End:    stub.Address + stub.Size,
+								Size:   stub.Size,
+								Name:   stub.Name,
+								Image:  filepath.Base(image.Name),
+								Source: source,
+							})
+						}
+						if unslidAddr-stub.Address == 0 {
+							fmt.Printf("\n%#x: %s (%s stub: %#x, target: %#x)\n", addr, stub.Name, stub.Section, stub.Address, stub.Target)
 						} else {
-							fmt.Printf("\n%#x: %s + %d (start: %#x, end: %#x)\n", addr, symName, unslidAddr-fn.StartAddr, fn.StartAddr, fn.EndAddr)
+							fmt.Printf("\n%#x: %s + %d (%s stub: %#x, target: %#x)\n", addr, stub.Name, unslidAddr-stub.Address, stub.Section, stub.Address, stub.Target)
 						}
 						return nil
 					}
-					fmt.Printf("\n%#x: func_%x (start: %#x, end: %#x)\n", addr, addr, fn.StartAddr, fn.EndAddr)
+					log.Debugf("%#x is not a stub: %v", unslidAddr, err)
 				}
-			} else {
-				log.Errorf("%#x is not in any known function", unslidAddr)
+
+				if fn, err := m.GetFunctionForVMAddr(unslidAddr); err == nil {
+					var block *dscBlock
+					source := "synthesized"
+					if symName, ok := funcSymbol(f, fn.StartAddr); ok {
+						fn.Name = symName
+						source = exports.source(image, fn.StartAddr, fn.Name)
+						block = blockInfo(image, fn.Name, objcBlocks, swiftClosures)
+						if doDemangle {
+							fn.Name = demangle.Do(fn.Name, false, false)
+							if block != nil {
+								block.Parent = demangle.Do(block.Parent, false, false)
+							}
+						}
+					}
+					var class *dscObjC
+					if objcCtx {
+						name, _ := funcSymbol(f, fn.StartAddr)
+						class = objcContext(objcClasses(m), name, fn.StartAddr)
+					}
+					file, line, _ := dsyms.sourceLine(image, m, unslidAddr)
+					var before, after []dscFunc
+					if context > 0 {
+						before, after = neighborFuncs(f, exports, image, m, fn.StartAddr, context, doDemangle)
+					}
+					if asJSON {
+						if source == "synthesized" {
+							fn.Name = fmt.Sprintf("func_%x", fn.StartAddr)
+						}
+						if err := newJSONEncoder(os.Stdout, jsonIndent).Encode(dscFunc{
+							Addr:    addr,
+							Start:   fn.StartAddr,
+							End:     fn.EndAddr,
+							Size:    fn.EndAddr - fn.StartAddr,
+							Name:    fn.Name,
+							Image:   filepath.Base(image.Name),
+							Source:  source,
+							Block:   block,
+							ObjC:    class,
+							File:    file,
+							Line:    line,
+							Context: append(before, after...),
+						}); err != nil {
+							return err
+						}
+					} else {
+						for i, ctx := range before {
+							fmt.Printf("\n    -%d %#x: %s (size: %#x)", len(before)-i, ctx.Start, ctx.Name, ctx.Size)
+						}
+						if _, ok := funcSymbol(f, fn.StartAddr); ok {
+							if unslidAddr-fn.StartAddr == 0 {
+								fmt.Printf("\n%#x: %s (start: %#x, end: %#x)%s\n", addr, fn.Name, fn.StartAddr, fn.EndAddr, sourceLocation(file, line))
+							} else {
+								fmt.Printf("\n%#x: %s + %d (start: %#x, end: %#x)%s\n", addr, fn.Name, unslidAddr-fn.StartAddr, fn.StartAddr, fn.EndAddr, sourceLocation(file, line))
+							}
+						} else {
+							fmt.Printf("\n%#x: func_%x (start: %#x, end: %#x)%s\n", addr, addr, fn.StartAddr, fn.EndAddr, sourceLocation(file, line))
+						}
+						if block != nil {
+							fmt.Printf("    (%s)\n", block)
+						}
+						if class != nil {
+							fmt.Printf("    (%s)\n", class)
+						}
+						for i, ctx := range after {
+							fmt.Printf("    +%d %#x: %s (size: %#x)\n", i+1, ctx.Start, ctx.Name, ctx.Size)
+						}
+					}
+				} else if !dataFallback {
+					log.Errorf("%#x is not in any known function", unslidAddr)
+				} else if data, err := dataFunc(image, m, unslidAddr); err == nil {
+					if asJSON {
+						data.Addr = addr
+						return newJSONEncoder(os.Stdout, jsonIndent).Encode(data)
+					}
+					fmt.Printf("\n%#x: %s + %d (data, start: %#x, end: %#x)\n", addr, data.Name, unslidAddr-data.Start, data.Start, data.End)
+				} else {
+					log.Errorf("%#x is not in any known function or data symbol: %v", unslidAddr, err)
+				}
+				return nil
+			}
+
+			if repl {
+				cacheFile = a2sCachePath(cacheFile, dscPath, f.UUID.String())
+				if err := f.OpenOrCreateA2SCache(cacheFile); err != nil {
+					return err
+				}
+				// read addresses from stdin until EOF
+				scanner := bufio.NewScanner(os.Stdin)
+				log.Info("Enter addresses to lookup (Ctrl+D to exit)")
+				for {
+					fmt.Fprint(os.Stderr, "a2f> ")
+					if !scanner.Scan() {
+						break
+					}
+					line := strings.TrimSpace(scanner.Text())
+					if len(line) == 0 {
+						continue
+					}
+					addr, err := utils.ConvertStrToInt(line)
+					if err != nil {
+						log.Errorf("invalid address '%s': %v", line, err)
+						continue
+					}
+					if err := lookup(addr); err != nil {
+						log.Error(err.Error())
+					}
+				}
+				fmt.Fprintln(os.Stderr)
+				return scanner.Err()
+			}
+
+			if len(symbol) > 0 {
+				var addr uint64
+				if len(imageName) > 0 {
+					image, err := f.Image(imageName)
+					if err != nil {
+						return fmt.Errorf("image not in %s: %v", dscPath, err)
+					}
+					sym, err := image.GetSymbol(symbol)
+					if err != nil {
+						return fmt.Errorf("failed to find symbol %s in %s: %v", symbol, image.Name, err)
+					}
+					addr = sym.Address
+				} else {
+					addr, _, err = f.GetSymbolAddress(symbol)
+					if err != nil {
+						return fmt.Errorf("failed to find symbol %s: %v", symbol, err)
+					}
+				}
+				return lookup(addr + slide) // lookup expects a slid address
+			}
+
+			if len(args) < 2 {
+				return fmt.Errorf("you must supply an virtual address")
+			}
+			addr, err := utils.ConvertStrToInt(args[1])
+			if err != nil {
+				return err
 			}
+
+			return lookup(addr)
 		}
 
 		return nil
 	},
 }
+
+// a2fReport is the coverage summary of an a2f --in batch
+type a2fReport struct {
+	Total       int      `json:"total"`
+	Resolved    int      `json:"resolved"`         // addresses resolved to a function (or stub)
+	Symbol      int      `json:"symbol"`           // resolved functions with a symbol name
+	Synthesized int      `json:"synthesized"`      // resolved functions with a synthesized func_<addr> name
+	Region      int      `json:"region,omitempty"` // addresses not in any image, but in a known cache region (see --region-fallback)
+	Data        int      `json:"data,omitempty"`   // addresses not in any function, but in a data symbol (see --data)
+	Unresolved  []string `json:"unresolved"`       // addresses not in any known image or function
+}
+
+// a2sCacheEnv is the environment variable a2f reads the .a2s cache path (or a folder of <UUID>.a2s caches) from
+const a2sCacheEnv = "IPSW_A2S_CACHE"
+
+// a2sCachePath returns the .a2s cache to use for the DSC with the given UUID, in order of precedence:
+//  1. the --cache path
+//  2. $IPSW_A2S_CACHE, either a cache file or a folder of shared caches named <UUID>.a2s
+//  3. <DSC>.a2s next to the DSC
+func a2sCachePath(cacheFile, dscPath, uuid string) string {
+	if len(cacheFile) > 0 {
+		return cacheFile
+	}
+	if env := os.Getenv(a2sCacheEnv); len(env) > 0 {
+		if info, err := os.Stat(env); err == nil && info.IsDir() {
+			return filepath.Join(env, uuid+".a2s")
+		}
+		return env
+	}
+	return dscPath + ".a2s"
+}
+
+// unslideAddr strips the tag bits of a (slid) address (see untagAddr) and removes the slide
+func unslideAddr(f *dyld.File, addr, slide uint64) uint64 {
+	end := f.Headers[f.UUID].SharedRegionStart + f.Headers[f.UUID].SharedRegionSize + slide
+	return untagAddr(cacheArch(f), bits.Len64(end), addr) - slide
+}
+
+// untagAddr strips the bits a code address can be tagged with on the DSC's architecture:
+//   - arm64e: the pointer authentication code (PAC) of signed pointers, i.e. the bits above the
+//     vaBits the shared region spans (or above 47 if the shared region is unknown)
+//   - armv7*: the low Thumb bit (Thumb functions are called w/ bit 0 set)
+//   - otherwise (arm64, arm64_32, x86_64 etc.) the address is unchanged
+func untagAddr(arch string, vaBits int, addr uint64) uint64 {
+	switch {
+	case arch == "arm64e":
+		if vaBits == 0 {
+			vaBits = 47
+		}
+		return addr & (1<<vaBits - 1)
+	case strings.HasPrefix(arch, "armv7"):
+		return addr &^ 1
+	default:
+		return addr
+	}
+}
+
+// funcSymbol returns the symbol name of the function starting at start (incl. Thumb symbols tagged with the low bit)
+func funcSymbol(f *dyld.File, start uint64) (string, bool) {
+	if name, ok := f.AddressToSymbol[start]; ok {
+		return name, ok
+	}
+	if strings.HasPrefix(cacheArch(f), "armv7") {
+		name, ok := f.AddressToSymbol[start|1]
+		return name, ok
+	}
+	return "", false
+}
+
+// cacheArch returns the architecture of the DSC (e.g. arm64e)
+func cacheArch(f *dyld.File) string {
+	return strings.TrimSpace(strings.TrimPrefix(f.Headers[f.UUID].Magic.String(), "dyld_v1"))
+}
+
+// sortFuncs sorts the looked up functions by address (as the image they were looked up in is random map order)
+func sortFuncs(fs []dscFunc) {
+	slices.SortStableFunc(fs, func(a, b dscFunc) int {
+		return cmp.Compare(a.Addr, b.Addr)
+	})
+}
+
+// resolveFunc returns the function (or stub if resolveStubs) of img containing the unslid address ptr (or an entry with its Error)
+func resolveFunc(f *dyld.File, exports *dscExports, img *dyld.CacheImage, m *macho.File, ptr uint64, resolveStubs bool) dscFunc {
+	if resolveStubs {
+		if stub, err := dsc.LookupStub(f, img, ptr); err == nil {
+			return dscFunc{
+				Addr:   ptr,
+				Start:  stub.Address,
+				End:    stub.Address + stub.Size,
+				Size:   stub.Size,
+				Name:   stub.Name,
+				Image:  filepath.Base(img.Name),
+				Source: exports.stubSource(stub),
+			}
+		}
+	}
+	fn, err := m.GetFunctionForVMAddr(ptr)
+	if err != nil {
+		return dscFunc{
+			Addr:  ptr,
+			Image: filepath.Base(img.Name),
+			Error: err.Error(),
+		}
+	}
+	if symName, ok := funcSymbol(f, fn.StartAddr); ok {
+		fn.Name = symName
+	}
+	source := exports.source(img, fn.StartAddr, fn.Name)
+	if source == "synthesized" {
+		fn.Name = fmt.Sprintf("func_%x", fn.StartAddr)
+	}
+	return dscFunc{
+		Addr:   ptr,
+		Start:  fn.StartAddr,
+		End:    fn.EndAddr,
+		Size:   fn.EndAddr - fn.StartAddr,
+		Name:   fn.Name,
+		Image:  filepath.Base(img.Name),
+		Source: source,
+	}
+}
+
+// writeNames writes the name of the function (or region) each of addrs resolved to in fs, one per line in order (? if unresolved)
+func writeNames(w io.Writer, addrs []uint64, fs []dscFunc) error {
+	names := make(map[uint64]string, len(fs))
+	for _, fn := range fs {
+		if len(fn.Error) == 0 && len(fn.Name) > 0 {
+			names[fn.Addr] = fn.Name
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for _, addr := range addrs {
+		name, ok := names[addr]
+		if !ok {
+			name = "?"
+		}
+		fmt.Fprintln(bw, name)
+	}
+	return bw.Flush()
+}
+
+// newJSONEncoder returns a JSON encoder for w that indents its output if indent is set
+func newJSONEncoder(w io.Writer, indent bool) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc
+}
+
+// regionFunc returns the (non-image) cache region containing addr as a dscFunc named after the region
+func regionFunc(f *dyld.File, addr uint64) (dscFunc, error) {
+	region, err := f.GetRegionContainingVMAddr(addr)
+	if err != nil {
+		return dscFunc{}, err
+	}
+	return dscFunc{
+		Addr:   addr,
+		Start:  region.Start,
+		End:    region.Start + region.Size,
+		Size:   region.Size,
+		Name:   region.Name,
+		Source: "region",
+	}, nil
+}
+
+func (r *a2fReport) add(source string) {
+	if source == "data" {
+		r.Data++
+		return
+	}
+	r.Resolved++
+	if source == "synthesized" {
+		r.Synthesized++
+	} else {
+		r.Symbol++
+	}
+}
+
+// neighborFuncs returns the n function starts immediately before and after the function starting at start
+func neighborFuncs(f *dyld.File, exports *dscExports, img *dyld.CacheImage, m *macho.File, start uint64, n int, doDemangle bool) (before, after []dscFunc) {
+	funcs := m.GetFunctions()
+	idx := slices.IndexFunc(funcs, func(fn types.Function) bool { return fn.StartAddr == start })
+	if idx < 0 {
+		return nil, nil
+	}
+	toDSCFunc := func(fn types.Function) dscFunc {
+		name, ok := funcSymbol(f, fn.StartAddr)
+		source := exports.source(img, fn.StartAddr, name)
+		if !ok {
+			name = fmt.Sprintf("func_%x", fn.StartAddr)
+		} else if doDemangle {
+			name = demangle.Do(name, false, false)
+		}
+		return dscFunc{
+			Start:  fn.StartAddr,
+			End:    fn.EndAddr,
+			Size:   fn.EndAddr - fn.StartAddr,
+			Name:   name,
+			Image:  filepath.Base(img.Name),
+			Source: source,
+		}
+	}
+	for _, fn := range funcs[max(idx-n, 0):idx] {
+		before = append(before, toDSCFunc(fn))
+	}
+	for _, fn := range funcs[idx+1 : min(idx+1+n, len(funcs))] {
+		after = append(after, toDSCFunc(fn))
+	}
+	return before, after
+}
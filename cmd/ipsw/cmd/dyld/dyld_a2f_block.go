@@ -0,0 +1,132 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// maxClosureParents bounds the symbol lookups done to find the function a Swift closure is defined in
+const maxClosureParents = 8
+
+var (
+	// e.g. ___22-[Foo bar:]_block_invoke_2, ___main_block_invoke or ____ZN3Foo3barEv_block_invoke.15
+	blockInvokeRE = regexp.MustCompile(`^_?__(\d*)(.+?)_block_invoke(?:[_.]\d+)*$`)
+	// explicit (fU) and implicit (fu) closure entities, e.g. $s4main3fooyyFyyXEfU_
+	swiftClosureRE = regexp.MustCompile(`f[Uu]\d*_`)
+	// the mangled entity kinds a closure can be defined in (functions, inits, deinits and accessors)
+	swiftEntitySuffixes = []string{"F", "fC", "fc", "fD", "fd", "vg", "vs", "vM", "vW", "vw"}
+)
+
+// dscBlock is the block (or Swift closure) a function implements (see a2f --objc and --swift)
+type dscBlock struct {
+	Kind       string `json:"kind"`                  // objc (block invoke) or swift (closure)
+	Parent     string `json:"parent,omitempty"`      // the function that creates the block (if known)
+	ParentAddr uint64 `json:"parent_addr,omitempty"` // the address of Parent (if it's in the image's symbols)
+}
+
+func (b dscBlock) String() string {
+	kind := "block invoke"
+	if b.Kind == "swift" {
+		kind = "swift closure"
+	}
+	switch {
+	case len(b.Parent) == 0:
+		return kind + " (enclosing function unknown)"
+	case b.ParentAddr == 0:
+		return fmt.Sprintf("%s in %s", kind, b.Parent)
+	default:
+		return fmt.Sprintf("%s in %s (%#x)", kind, b.Parent, b.ParentAddr)
+	}
+}
+
+// blockInfo returns the block img's function symbol name implements (or nil if it isn't a block invoke/closure of an enabled kind)
+// NOTE: this relies on the symbol name, so the block invokes of stripped images stay func_<addr>
+func blockInfo(img *dyld.CacheImage, name string, objcBlocks, swiftClosures bool) *dscBlock {
+	if objcBlocks {
+		if parent, ok := blockInvokeParent(name); ok {
+			b := &dscBlock{Kind: "objc", Parent: parent}
+			if sym, err := img.GetSymbol(parent); err == nil {
+				b.ParentAddr = sym.Address
+			}
+			return b
+		}
+	}
+	if swiftClosures {
+		if parents, ok := swiftClosureParents(name); ok {
+			b := &dscBlock{Kind: "swift"}
+			for _, parent := range parents {
+				if sym, err := img.GetSymbol(parent); err == nil {
+					b.Parent = parent
+					b.ParentAddr = sym.Address
+					break
+				}
+			}
+			return b
+		}
+	}
+	return nil
+}
+
+// blockInvokeParent returns the symbol of the function a clang block invoke symbol is named after
+func blockInvokeParent(name string) (string, bool) {
+	match := blockInvokeRE.FindStringSubmatch(name)
+	if match == nil {
+		return "", false
+	}
+	if len(match[1]) > 0 && (strings.HasPrefix(match[2], "-[") || strings.HasPrefix(match[2], "+[")) {
+		return match[2], true // ObjC method names are prefixed with their length
+	}
+	return "_" + match[1] + match[2], true
+}
+
+// swiftClosureParents returns the candidate (mangled) symbols of the function a Swift closure is defined in (longest first),
+// i.e. the prefixes of the closure's symbol that end in an entity (or an outer closure) before its closure entity
+func swiftClosureParents(name string) ([]string, bool) {
+	if mangled := strings.TrimPrefix(name, "_"); !strings.HasPrefix(mangled, "$s") && !strings.HasPrefix(mangled, "$S") && !strings.HasPrefix(mangled, "_T0") {
+		return nil, false
+	}
+	markers := swiftClosureRE.FindAllStringIndex(name, -1)
+	if len(markers) == 0 {
+		return nil, false
+	}
+	closure := markers[len(markers)-1][0]
+	outer := make(map[int]bool) // the ends of outer closure entities
+	for _, marker := range markers[:len(markers)-1] {
+		outer[marker[1]] = true
+	}
+	var parents []string
+	for end := closure; end > 2 && len(parents) < maxClosureParents; end-- {
+		prefix := name[:end]
+		if outer[end] || slices.ContainsFunc(swiftEntitySuffixes, func(suffix string) bool {
+			return strings.HasSuffix(prefix, suffix)
+		}) {
+			parents = append(parents, prefix)
+		}
+	}
+	return parents, true
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// dataFunc returns the data symbol (e.g. a global variable or ObjC class object) of img containing the
+// non-code address addr, labeled with the data Source (see a2f --data)
+// NOTE: data symbols have no size so a symbol is assumed to span up to the next one in its section
+func dataFunc(img *dyld.CacheImage, m *macho.File, addr uint64) (dscFunc, error) {
+	sec := m.FindSectionForVMAddr(addr)
+	if sec == nil {
+		return dscFunc{}, fmt.Errorf("address %#x not in any section of %s", addr, filepath.Base(img.Name))
+	}
+	if sec.Flags.IsPureInstructions() || sec.Flags.IsSomeInstructions() {
+		return dscFunc{}, fmt.Errorf("address %#x is in code section %s.%s", addr, sec.Seg, sec.Name)
+	}
+	syms := make(map[uint64]string)
+	if m.Symtab != nil {
+		for _, sym := range m.Symtab.Syms {
+			if !sym.Type.IsDebugSym() && sym.Type.IsDefinedInSection() && len(sym.Name) > 0 && addrInSection(sym.Value, sec.Addr, sec.Size) {
+				syms[sym.Value] = sym.Name
+			}
+		}
+	}
+	for _, sym := range img.LocalSymbols { // the cache's local symbols (if parsed)
+		if _, ok := syms[sym.Value]; !ok && len(sym.Name) > 0 && addrInSection(sym.Value, sec.Addr, sec.Size) {
+			syms[sym.Value] = sym.Name
+		}
+	}
+	start, end, name, ok := containingSymbol(syms, addr, sec.Addr+sec.Size)
+	if !ok {
+		return dscFunc{}, fmt.Errorf("address %#x not in any data symbol of %s.%s", addr, sec.Seg, sec.Name)
+	}
+	return dscFunc{
+		Addr:   addr,
+		Start:  start,
+		End:    end,
+		Size:   end - start,
+		Name:   name,
+		Image:  filepath.Base(img.Name),
+		Source: "data",
+	}, nil
+}
+
+// addrInSection returns whether addr is in the section starting at start of the given size
+func addrInSection(addr, start, size uint64) bool {
+	return start <= addr && addr < start+size
+}
+
+// containingSymbol returns the symbol of syms (start address to name) that starts at or before addr and
+// the address it ends at: the start of the next symbol or end (the end of their section)
+func containingSymbol(syms map[uint64]string, addr, end uint64) (uint64, uint64, string, bool) {
+	var start uint64
+	var name string
+	var found bool
+	for saddr, sname := range syms {
+		switch {
+		case saddr <= addr && (!found || saddr > start):
+			start, name, found = saddr, sname, true
+		case saddr > addr && saddr < end:
+			end = saddr
+		}
+	}
+	return start, end, name, found
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-dwarf"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/ipsw/internal/magic"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// dsymDWARF is the DWARF of a dSYM and the __TEXT vmaddr its addresses are relative to
+type dsymDWARF struct {
+	dwarf *dwarf.Data
+	text  uint64
+}
+
+// dscDSYMs are the dSYMs given to a2f --dsym keyed by the UUID of the image they were built for
+type dscDSYMs map[types.UUID]*dsymDWARF
+
+// openDSYMs reads the DWARF of the dSYM at path: a dSYM bundle, its DWARF MachO or a folder of them
+func openDSYMs(path string) (dscDSYMs, error) {
+	ds := make(dscDSYMs)
+	if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ok, _ := magic.IsMachO(p); !ok {
+			return nil
+		}
+		return ds.add(p)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read dSYM %s: %v", path, err)
+	}
+	if len(ds) == 0 {
+		return nil, fmt.Errorf("no DWARF found in dSYM %s", path)
+	}
+	return ds, nil
+}
+
+// add adds the DWARF of each architecture of the dSYM MachO at path
+func (ds dscDSYMs) add(path string) error {
+	var ms []*macho.File
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		for _, arch := range fat.Arches {
+			ms = append(ms, arch.File)
+		}
+	} else if err == macho.ErrNotFat {
+		m, err := macho.Open(path)
+		if err != nil {
+			return err
+		}
+		defer m.Close()
+		ms = append(ms, m)
+	} else {
+		return err
+	}
+	for _, m := range ms {
+		u := m.UUID()
+		if u == nil {
+			continue
+		}
+		d, err := m.DWARF()
+		if err != nil {
+			log.Debugf("%s has no DWARF: %v", path, err)
+			continue
+		}
+		dd := &dsymDWARF{dwarf: d}
+		if text := m.Segment("__TEXT"); text != nil {
+			dd.text = text.Addr
+		}
+		ds[u.UUID] = dd
+	}
+	return nil
+}
+
+// sourceLine returns the source file and line of the unslid address addr of img (m) from its dSYM
+// (the dSYM's addresses are relative to the image's __TEXT as it was linked, not as it is in the cache)
+func (ds dscDSYMs) sourceLine(img *dyld.CacheImage, m *macho.File, addr uint64) (string, int, bool) {
+	if len(ds) == 0 {
+		return "", 0, false
+	}
+	u := m.UUID()
+	if u == nil {
+		return "", 0, false
+	}
+	dd, ok := ds[u.UUID]
+	if !ok {
+		return "", 0, false
+	}
+	pc := addr - img.Info.Address + dd.text
+	cu, err := dd.dwarf.Reader().SeekPC(pc)
+	if err != nil || cu == nil {
+		return "", 0, false
+	}
+	lr, err := dd.dwarf.LineReader(cu)
+	if err != nil || lr == nil {
+		return "", 0, false
+	}
+	var le dwarf.LineEntry
+	if err := lr.SeekPC(pc, &le); err != nil || le.File == nil {
+		return "", 0, false
+	}
+	return le.File.Name, le.Line, true
+}
+
+// addSourceLine sets the File and Line of fn from its image's dSYM (if any)
+func (ds dscDSYMs) addSourceLine(fn *dscFunc, img *dyld.CacheImage, m *macho.File) {
+	if len(fn.Error) > 0 {
+		return
+	}
+	if file, line, ok := ds.sourceLine(img, m, fn.Addr); ok {
+		fn.File, fn.Line = file, line
+	}
+}
+
+// sourceLocation returns the " at file:line" suffix of a resolved address's output (or "" w/o line info)
+func sourceLocation(file string, line int) string {
+	if len(file) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" at %s:%d", file, line)
+}
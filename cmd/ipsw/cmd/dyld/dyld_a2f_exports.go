@@ -0,0 +1,86 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/internal/commands/dsc"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// dscExports lazily collects the exported symbols of the DSC's images by address from their export tries,
+// as the .a2s cache only maps addresses to names (without their kind)
+type dscExports struct {
+	f    *dyld.File
+	sets map[*dyld.CacheImage]map[uint64]string
+}
+
+func newDSCExports(f *dyld.File) *dscExports {
+	return &dscExports{f: f, sets: make(map[*dyld.CacheImage]map[uint64]string)}
+}
+
+// exports returns the exported (non re-exported) symbols of img by address, parsing its export trie on first use
+func (e *dscExports) exports(img *dyld.CacheImage) map[uint64]string {
+	if set, ok := e.sets[img]; ok {
+		return set
+	}
+	set := make(map[uint64]string)
+	syms, err := e.f.GetExportTrieSymbols(img)
+	if err != nil {
+		log.Debugf("failed to get the exports of %s: %v", filepath.Base(img.Name), err)
+	}
+	for _, sym := range syms {
+		if !sym.Flags.ReExport() {
+			set[sym.Address] = sym.Name
+		}
+	}
+	e.sets[img] = set
+	return set
+}
+
+// source returns where the raw (NOT demangled) name of the symbol at addr of img came from (symtab, export, objc or synthesized)
+func (e *dscExports) source(img *dyld.CacheImage, addr uint64, name string) string {
+	if len(name) == 0 {
+		return "synthesized"
+	}
+	if strings.HasPrefix(name, "-[") || strings.HasPrefix(name, "+[") || strings.HasPrefix(name, "_objc_msgSend$") {
+		return "objc"
+	}
+	if img != nil {
+		if export, ok := e.exports(img)[addr]; ok && export == name {
+			return "export"
+		}
+	}
+	return "symtab" // local symbols, the dylib's symtab or the .a2s cache
+}
+
+// stubSource returns where the raw name of stub came from, i.e. the source of its target's symbol
+func (e *dscExports) stubSource(stub *dsc.Stub) string {
+	img, err := e.f.GetImageContainingVMAddr(stub.Target)
+	if err != nil {
+		return e.source(nil, stub.Target, stub.Name)
+	}
+	return e.source(img, stub.Target, stub.Name)
+}
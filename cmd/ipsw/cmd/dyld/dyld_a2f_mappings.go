@@ -0,0 +1,115 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// dscMapping is a cache mapping (see a2f --mappings)
+type dscMapping struct {
+	Name       string `json:"name"`
+	Start      uint64 `json:"start"`
+	End        uint64 `json:"end"`
+	FileOffset uint64 `json:"file_offset"`
+	Size       uint64 `json:"size"`
+	InitProt   string `json:"init_prot"`
+	MaxProt    string `json:"max_prot"`
+}
+
+// dscSubCache is the address range and mappings of the main cache or one of its subcaches
+type dscSubCache struct {
+	UUID      string       `json:"uuid"`
+	Extension string       `json:"extension,omitempty"` // empty for the main cache
+	Start     uint64       `json:"start"`
+	End       uint64       `json:"end"`
+	Mappings  []dscMapping `json:"mappings"`
+}
+
+func (sc dscSubCache) name() string {
+	if len(sc.Extension) == 0 {
+		return "main cache"
+	}
+	return "subcache " + sc.Extension
+}
+
+// cacheMappings returns the (address sorted) mappings of the main cache and its subcaches
+func cacheMappings(f *dyld.File) []dscSubCache {
+	var scs []dscSubCache
+	for uuid, mappings := range f.MappingsWithSlideInfo {
+		if len(mappings) == 0 {
+			continue
+		}
+		sc := dscSubCache{UUID: uuid.String()}
+		if uuid != f.UUID {
+			if ext, err := f.GetSubCacheExtensionFromUUID(uuid); err == nil {
+				sc.Extension = ext
+			} else {
+				sc.Extension = ".symbols" // the only cache file not in the main cache's subcache array
+			}
+		}
+		for _, m := range mappings {
+			sc.Mappings = append(sc.Mappings, dscMapping{
+				Name:       m.Name,
+				Start:      m.Address,
+				End:        m.Address + m.Size,
+				FileOffset: m.FileOffset,
+				Size:       m.Size,
+				InitProt:   m.InitProt.String(),
+				MaxProt:    m.MaxProt.String(),
+			})
+		}
+		slices.SortStableFunc(sc.Mappings, func(a, b dscMapping) int {
+			return cmp.Compare(a.Start, b.Start)
+		})
+		sc.Start = sc.Mappings[0].Start
+		for _, m := range sc.Mappings {
+			sc.End = max(sc.End, m.End)
+		}
+		scs = append(scs, sc)
+	}
+	slices.SortStableFunc(scs, func(a, b dscSubCache) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return scs
+}
+
+// writeMappings writes the subcache boundaries and mappings as a table
+func writeMappings(w io.Writer, scs []dscSubCache) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, sc := range scs {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s (%s): %#x -> %#x\n", sc.name(), sc.UUID, sc.Start, sc.End)
+		for _, m := range sc.Mappings {
+			fmt.Fprintf(tw, "  %s\t%#x -> %#x\toffset: %#x\tsize: %#x\t%s/%s\n", m.Name, m.Start, m.End, m.FileOffset, m.Size, m.InitProt, m.MaxProt)
+		}
+	}
+	return tw.Flush()
+}
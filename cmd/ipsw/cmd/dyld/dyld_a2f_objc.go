@@ -0,0 +1,136 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// dscObjC is the ObjC class a function is a method IMP of (see a2f --objc-context)
+type dscObjC struct {
+	Class        string    `json:"class"`
+	Category     string    `json:"category,omitempty"`
+	InstanceSize uint64    `json:"instance_size,omitempty"`
+	Ivars        []dscIvar `json:"ivars,omitempty"` // empty if the class isn't defined in the function's image
+}
+
+// dscIvar is an ivar of a dscObjC class
+type dscIvar struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+func (o dscObjC) String() string {
+	var out strings.Builder
+	out.WriteString("objc class " + o.Class)
+	if len(o.Category) > 0 {
+		fmt.Fprintf(&out, " (category %s)", o.Category)
+	}
+	if o.InstanceSize > 0 {
+		fmt.Fprintf(&out, ", instance size: %#x", o.InstanceSize)
+	}
+	if len(o.Ivars) == 0 {
+		out.WriteString(", no known ivars")
+	}
+	for _, ivar := range o.Ivars {
+		fmt.Fprintf(&out, "\n      +%#x %s %s (size: %d)", ivar.Offset, ivar.Type, ivar.Name, ivar.Size)
+	}
+	return out.String()
+}
+
+// objcClasses returns the ObjC classes of an image (or none if it has no ObjC)
+func objcClasses(m *macho.File) []objc.Class {
+	if !m.HasObjC() {
+		return nil
+	}
+	classes, err := m.GetObjCClasses()
+	if err != nil {
+		log.Debugf("failed to get ObjC classes: %v", err)
+	}
+	return classes
+}
+
+// objcContext returns the class of the ObjC method the function name starting at start implements (or nil if it isn't one),
+// matched by the method's symbol name or else by the IMPs of the image's classes (for stripped functions)
+// NOTE: this is best-effort, e.g. the ivars of a category's class from another image are unknown
+func objcContext(classes []objc.Class, name string, start uint64) *dscObjC {
+	class, category, isMethod := objcMethodClass(name)
+	for i := range classes {
+		if (isMethod && classes[i].Name == class) || (!isMethod && hasImp(&classes[i], start)) {
+			return newObjCContext(&classes[i], category)
+		}
+	}
+	if isMethod {
+		return &dscObjC{Class: class, Category: category}
+	}
+	return nil
+}
+
+// objcMethodClass returns the class (and category) of an ObjC method symbol, e.g. -[Foo(Bar) baz:] -> Foo, Bar
+func objcMethodClass(name string) (class, category string, ok bool) {
+	rest, found := strings.CutPrefix(name, "-[")
+	if !found {
+		if rest, found = strings.CutPrefix(name, "+["); !found {
+			return "", "", false
+		}
+	}
+	owner, _, found := strings.Cut(rest, " ")
+	if !found || len(owner) == 0 {
+		return "", "", false
+	}
+	if class, category, found = strings.Cut(owner, "("); found {
+		return class, strings.TrimSuffix(category, ")"), true
+	}
+	return owner, "", true
+}
+
+// hasImp returns true if one of the class's methods is implemented at addr
+func hasImp(c *objc.Class, addr uint64) bool {
+	for _, meths := range [][]objc.Method{c.InstanceMethods, c.ClassMethods} {
+		for _, meth := range meths {
+			if meth.ImpVMAddr == addr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newObjCContext(c *objc.Class, category string) *dscObjC {
+	ctx := &dscObjC{Class: c.Name, Category: category, InstanceSize: c.ReadOnlyData.InstanceSize}
+	for _, ivar := range c.Ivars {
+		ctx.Ivars = append(ctx.Ivars, dscIvar{
+			Name:   ivar.Name,
+			Type:   strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(ivar.Verbose(), ";"), ivar.Name)),
+			Offset: ivar.Offset,
+			Size:   ivar.Size,
+		})
+	}
+	return ctx
+}
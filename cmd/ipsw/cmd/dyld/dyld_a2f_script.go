@@ -0,0 +1,101 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// a2fScriptFormats are the disassembler scripts a2f --format can write (and the Python defining their a2f() helper)
+var a2fScriptFormats = map[string]string{
+	"ghidra": `from ghidra.program.model.symbol import SourceType
+
+def a2f(addr, name):
+    addr = toAddr(addr + SLIDE)
+    fn = getFunctionAt(addr)
+    if fn is None:
+        fn = createFunction(addr, name)
+    if fn is not None and name is not None:
+        try:
+            fn.setName(name, SourceType.IMPORTED)
+        except Exception as e:
+            print("failed to name %s %s: %s" % (addr, name, e))
+`,
+	"ida": `import ida_funcs
+import ida_name
+
+def a2f(addr, name):
+    addr += SLIDE
+    if ida_funcs.get_func(addr) is None:
+        ida_funcs.add_func(addr)
+    if name is not None:
+        ida_name.set_name(addr, name, ida_name.SN_NOWARN | ida_name.SN_FORCE)
+`,
+}
+
+// writeA2FScript writes a Python script for format (ghidra or ida) that creates the resolved functions of fs at their
+// start addresses and names them (functions w/o a symbol are only created; regions and data symbols are skipped)
+func writeA2FScript(w io.Writer, format string, cacheBase uint64, imageBases map[string]uint64, fs []dscFunc) error {
+	helper, ok := a2fScriptFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported a2f script format '%s'", format)
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "# Generated by 'ipsw dyld a2f --format %s'\n#\n", format)
+	fmt.Fprintf(&out, "# dyld_shared_cache base: %#x\n", cacheBase)
+	if len(imageBases) > 0 {
+		out.WriteString("# image bases:\n")
+		images := make([]string, 0, len(imageBases))
+		for image := range imageBases {
+			images = append(images, image)
+		}
+		slices.Sort(images)
+		for _, image := range images {
+			fmt.Fprintf(&out, "#   %#x %s\n", imageBases[image], image)
+		}
+	}
+	out.WriteString("#\n# The addresses are unslid cache addresses, to rebase them (e.g. for an image loaded at another address)\n")
+	out.WriteString("# set SLIDE to <load address> - <image base>\nSLIDE = 0\n\n")
+	out.WriteString(helper + "\n")
+	seen := make(map[uint64]bool)
+	for _, fn := range fs {
+		if len(fn.Error) > 0 || fn.Source == "region" || fn.Source == "data" || fn.Start == 0 || seen[fn.Start] {
+			continue
+		}
+		seen[fn.Start] = true
+		name := "None"
+		if fn.Source != "synthesized" {
+			name = strconv.QuoteToASCII(fn.Name)
+		}
+		fmt.Fprintf(&out, "a2f(%#x, %s)", fn.Start, name)
+		if len(fn.Image) > 0 {
+			fmt.Fprintf(&out, "  # %s", fn.Image)
+		}
+		out.WriteString("\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
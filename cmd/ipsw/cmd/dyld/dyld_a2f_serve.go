@@ -0,0 +1,190 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/internal/utils"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// a2fServer answers a2f lookups over HTTP, keeping the DSC (and the parsed images) in memory between requests
+type a2fServer struct {
+	f              *dyld.File
+	exports        *dscExports
+	slide          uint64
+	resolveStubs   bool
+	doDemangle     bool
+	regionFallback bool // resolve addresses not in any image to their cache region (see a2f --region-fallback)
+	dataFallback   bool // resolve non-code addresses to their data symbol (see a2f --data)
+	jsonIndent     bool
+	objcBlocks     bool // annotate block invokes (see a2f --objc)
+	swiftClosures  bool // annotate Swift closures (see a2f --swift)
+	objcCtx        bool // list the ivars of ObjC method IMPs' classes (see a2f --objc-context)
+	dsyms          dscDSYMs
+
+	mu      sync.Mutex // the DSC, its MachOs and exports aren't safe for concurrent use
+	machos  map[*dyld.CacheImage]*macho.File
+	classes map[*dyld.CacheImage][]objc.Class // the ObjC classes of the images (with objcCtx)
+}
+
+// ServeHTTP resolves the JSON array of (slid) addresses POSTed to it and responds with their functions as JSON
+func (s *a2fServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST a JSON array of addresses (e.g. [\"0x1800b1234\"])", http.StatusMethodNotAllowed)
+		return
+	}
+	var addrs []string
+	if err := json.NewDecoder(r.Body).Decode(&addrs); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode addresses: %v", err), http.StatusBadRequest)
+		return
+	}
+	var ptrs []uint64
+	for _, a := range addrs {
+		addr, err := utils.ConvertStrToInt(a)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid address '%s': %v", a, err), http.StatusBadRequest)
+			return
+		}
+		ptrs = append(ptrs, addr)
+	}
+	fs := s.lookup(ptrs)
+	w.Header().Set("Content-Type", "application/json")
+	if err := newJSONEncoder(w, s.jsonIndent).Encode(fs); err != nil {
+		log.Errorf("failed to write a2f response: %v", err)
+	}
+}
+
+func (s *a2fServer) lookup(addrs []uint64) []dscFunc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fs := make([]dscFunc, 0, len(addrs))
+	for _, addr := range addrs {
+		unslidAddr := unslideAddr(s.f, addr, s.slide)
+		img, err := s.f.GetImageContainingVMAddr(unslidAddr)
+		if err != nil && s.regionFallback {
+			if fn, rerr := regionFunc(s.f, unslidAddr); rerr == nil {
+				fs = append(fs, fn)
+				continue
+			}
+		}
+		if err != nil {
+			fs = append(fs, dscFunc{Addr: unslidAddr, Error: err.Error()})
+			continue
+		}
+		m, ok := s.machos[img]
+		if !ok {
+			m, err = img.GetMacho()
+			if err != nil {
+				fs = append(fs, dscFunc{Addr: unslidAddr, Error: err.Error()})
+				continue
+			}
+			s.machos[img] = m
+		}
+		fn := resolveFunc(s.f, s.exports, img, m, unslidAddr, s.resolveStubs)
+		if len(fn.Error) > 0 && s.dataFallback {
+			if data, err := dataFunc(img, m, unslidAddr); err == nil {
+				fn = data
+			}
+		}
+		if fn.Source != "synthesized" && len(fn.Error) == 0 {
+			fn.Block = blockInfo(img, fn.Name, s.objcBlocks, s.swiftClosures)
+		}
+		if s.objcCtx && len(fn.Error) == 0 {
+			classes, ok := s.classes[img]
+			if !ok {
+				classes = objcClasses(m)
+				s.classes[img] = classes
+			}
+			fn.ObjC = objcContext(classes, fn.Name, fn.Start)
+		}
+		s.dsyms.addSourceLine(&fn, img, m)
+		if s.doDemangle {
+			fn.Name = demangle.Do(fn.Name, false, false)
+			if fn.Block != nil {
+				fn.Block.Parent = demangle.Do(fn.Block.Parent, false, false)
+			}
+		}
+		fs = append(fs, fn)
+	}
+	return fs
+}
+
+// serveA2F serves s's a2f lookups on addr until interrupted
+func serveA2F(s *a2fServer, addr string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	s.machos = make(map[*dyld.CacheImage]*macho.File)
+	s.classes = make(map[*dyld.CacheImage][]objc.Class)
+	defer func() {
+		for _, m := range s.machos {
+			m.Close()
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/a2f", s)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Infof("Serving a2f lookups on http://%s/a2f", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errc <- fmt.Errorf("failed to serve a2f: %v", err)
+		}
+		close(errc)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Warn("Shutting down gracefully: Press Ctrl+C again to force")
+
+	sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(sctx); err != nil {
+		return fmt.Errorf("a2f server forced to shutdown: %v", err)
+	}
+	return nil
+}
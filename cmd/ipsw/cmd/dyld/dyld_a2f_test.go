@@ -0,0 +1,388 @@
+package dyld
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/demangle"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+func Test_sortFuncs(t *testing.T) {
+	fs := []dscFunc{
+		{Addr: 0x180030000, Image: "UIKitCore"},
+		{Addr: 0x180010000, Error: "not in any image"},
+		{Addr: 0x180020000, Image: "Foundation"},
+		{Addr: 0x180010000, Image: "libobjc.A.dylib"},
+	}
+	sortFuncs(fs)
+	want := []uint64{0x180010000, 0x180010000, 0x180020000, 0x180030000}
+	for i, fn := range fs {
+		if fn.Addr != want[i] {
+			t.Fatalf("sortFuncs()[%d].Addr = %#x, want %#x", i, fn.Addr, want[i])
+		}
+	}
+	if fs[0].Error == "" || fs[1].Image != "libobjc.A.dylib" {
+		t.Errorf("sortFuncs() did not keep the order of equal addresses: %v", fs[:2])
+	}
+}
+
+func Test_untagAddr(t *testing.T) {
+	tests := []struct {
+		name   string
+		arch   string
+		vaBits int
+		addr   uint64
+		want   uint64
+	}{
+		{"arm64e signed", "arm64e", 34, 0x8c3b0001a2b3c4d8, 0x1a2b3c4d8},
+		{"arm64e signed (unknown shared region)", "arm64e", 0, 0x8c3b0001a2b3c4d8, 0x1a2b3c4d8},
+		{"arm64e unsigned", "arm64e", 34, 0x1a2b3c4d8, 0x1a2b3c4d8},
+		{"armv7 thumb", "armv7k", 30, 0x2a3b4c5d, 0x2a3b4c5c},
+		{"armv7 arm", "armv7", 30, 0x2a3b4c5c, 0x2a3b4c5c},
+		{"arm64 unchanged", "arm64", 34, 0x8c3b0001a2b3c4d8, 0x8c3b0001a2b3c4d8},
+		{"x86_64 unchanged", "x86_64", 47, 0x7ff81a2b3c4d, 0x7ff81a2b3c4d},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := untagAddr(tt.arch, tt.vaBits, tt.addr); got != tt.want {
+				t.Errorf("untagAddr() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_blockInvokeParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{"___22-[Foo bar:]_block_invoke", "-[Foo bar:]", true},
+		{"___22-[Foo bar:]_block_invoke_2", "-[Foo bar:]", true},
+		{"___29+[Foo sharedInstanceWith:]_block_invoke.15", "+[Foo sharedInstanceWith:]", true},
+		{"___main_block_invoke", "_main", true},
+		{"____ZN3Foo3barEv_block_invoke", "__ZN3Foo3barEv", true},
+		{"_main", "", false},
+		{"-[Foo bar:]", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := blockInvokeParent(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("blockInvokeParent() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_swiftClosureParents(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string // the first (longest) candidate
+		wantOk bool
+	}{
+		{"_$s4main3fooyyFyyXEfU_", "_$s4main3fooyyF", true},
+		{"_$s4main3fooyyFyyXEfU_yyXEfU0_", "_$s4main3fooyyFyyXEfU_", true},
+		{"_$s4main3FooCACycfcyycfU_TA", "_$s4main3FooCACycfc", true},
+		{"_$s4main3fooyyF", "", false},
+		{"___main_block_invoke", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := swiftClosureParents(tt.name)
+			if ok != tt.wantOk {
+				t.Fatalf("swiftClosureParents() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (len(got) == 0 || got[0] != tt.want) {
+				t.Errorf("swiftClosureParents() = %v, want %q first", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_objcContext(t *testing.T) {
+	classes := []objc.Class{
+		{Name: "Bar", InstanceMethods: []objc.Method{{ImpVMAddr: 0x1000}}},
+		{
+			Name:            "Foo",
+			ClassMethods:    []objc.Method{{ImpVMAddr: 0x2000}},
+			Ivars:           []objc.Ivar{{Name: "_count", Type: "q", Offset: 8, IvarT: objc.IvarT{Size: 8}}},
+			ReadOnlyData:    objc.ClassRO64{InstanceSize: 16},
+			InstanceMethods: []objc.Method{{ImpVMAddr: 0x3000}},
+		},
+	}
+	tests := []struct {
+		name     string
+		fn       string
+		start    uint64
+		class    string
+		category string
+		ivars    int
+		wantNil  bool
+	}{
+		{name: "method", fn: "-[Foo count]", start: 0x4000, class: "Foo", ivars: 1},
+		{name: "class method", fn: "+[Foo(Extras) shared]", start: 0x4000, class: "Foo", category: "Extras", ivars: 1},
+		{name: "other image", fn: "-[NSString(Foo) foo]", start: 0x4000, class: "NSString", category: "Foo"},
+		{name: "stripped", fn: "", start: 0x2000, class: "Foo", ivars: 1},
+		{name: "not a method", fn: "_main", start: 0x5000, wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := objcContext(classes, tt.fn, tt.start)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("objcContext() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Class != tt.class || got.Category != tt.category || len(got.Ivars) != tt.ivars {
+				t.Fatalf("objcContext() = %+v, want class %s (category %q) with %d ivars", got, tt.class, tt.category, tt.ivars)
+			}
+		})
+	}
+}
+
+func Test_writeA2FScript(t *testing.T) {
+	fs := []dscFunc{
+		{Addr: 0x180001004, Start: 0x180001000, Name: "-[Foo bar]", Image: "Foundation", Source: "objc"},
+		{Addr: 0x180001008, Start: 0x180001000, Name: "-[Foo bar]", Image: "Foundation", Source: "objc"}, // same function
+		{Addr: 0x180002000, Start: 0x180002000, Name: "func_180002000", Image: "Foundation", Source: "synthesized"},
+		{Addr: 0x190000000, Error: "not in any image"},
+		{Addr: 0x1a0000000, Start: 0x1a0000000, Name: "objc selector table", Source: "region"},
+		{Addr: 0x1d0000010, Start: 0x1d0000000, Name: "_OBJC_CLASS_$_Foo", Image: "Foundation", Source: "data"},
+	}
+	for format, call := range map[string]string{"ghidra": "createFunction(", "ida": "ida_funcs.add_func("} {
+		t.Run(format, func(t *testing.T) {
+			var out strings.Builder
+			if err := writeA2FScript(&out, format, 0x180000000, map[string]uint64{"Foundation": 0x180000000}, fs); err != nil {
+				t.Fatalf("writeA2FScript() error = %v", err)
+			}
+			got := out.String()
+			for _, want := range []string{
+				"# dyld_shared_cache base: 0x180000000\n",
+				"#   0x180000000 Foundation\n",
+				"SLIDE = 0\n",
+				call,
+				"a2f(0x180001000, \"-[Foo bar]\")  # Foundation\n",
+				"a2f(0x180002000, None)  # Foundation\n",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("writeA2FScript() = \n%s\nwant it to contain %q", got, want)
+				}
+			}
+			if n := strings.Count(got, "\na2f("); n != 2 {
+				t.Errorf("writeA2FScript() wrote %d a2f() calls, want 2", n)
+			}
+		})
+	}
+	if err := writeA2FScript(&strings.Builder{}, "r2", 0, nil, fs); err == nil {
+		t.Errorf("writeA2FScript() error = nil for an unsupported format")
+	}
+}
+
+func Test_a2sCachePath(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		cache string
+		env   string
+		want  string
+	}{
+		{"default", "", "", "/tmp/dyld_shared_cache_arm64e.a2s"},
+		{"env file", "", "/shared/ios18.a2s", "/shared/ios18.a2s"},
+		{"env folder", "", dir, filepath.Join(dir, "UUID.a2s")},
+		{"flag wins", "/tmp/mine.a2s", dir, "/tmp/mine.a2s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(a2sCacheEnv, tt.env)
+			if got := a2sCachePath(tt.cache, "/tmp/dyld_shared_cache_arm64e", "UUID"); got != tt.want {
+				t.Errorf("a2sCachePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeNames(t *testing.T) {
+	fs := []dscFunc{
+		{Addr: 0x180001000, Name: "_main"},
+		{Addr: 0x180002000, Name: "-[Foo bar]"},
+		{Addr: 0x190000000, Error: "not in any image"},
+	}
+	var out strings.Builder
+	if err := writeNames(&out, []uint64{0x180002000, 0x190000000, 0, 0x180001000, 0x180002000}, fs); err != nil {
+		t.Fatalf("writeNames() error = %v", err)
+	}
+	if want := "-[Foo bar]\n?\n?\n_main\n-[Foo bar]\n"; out.String() != want {
+		t.Errorf("writeNames() = %q, want %q", out.String(), want)
+	}
+}
+
+func Test_writeMappings(t *testing.T) {
+	scs := []dscSubCache{
+		{UUID: "A", Start: 0x180000000, End: 0x180008000, Mappings: []dscMapping{
+			{Name: "__TEXT", Start: 0x180000000, End: 0x180004000, Size: 0x4000, InitProt: "r-x", MaxProt: "r-x"},
+			{Name: "__DATA_CONST", Start: 0x180004000, End: 0x180008000, FileOffset: 0x4000, Size: 0x4000, InitProt: "rw-", MaxProt: "rw-"},
+		}},
+		{UUID: "B", Extension: ".01", Start: 0x190000000, End: 0x190004000, Mappings: []dscMapping{
+			{Name: "__TEXT", Start: 0x190000000, End: 0x190004000, Size: 0x4000, InitProt: "r-x", MaxProt: "r-x"},
+		}},
+	}
+	var out strings.Builder
+	if err := writeMappings(&out, scs); err != nil {
+		t.Fatalf("writeMappings() error = %v", err)
+	}
+	want := "main cache (A): 0x180000000 -> 0x180008000\n" +
+		"  __TEXT        0x180000000 -> 0x180004000  offset: 0x0     size: 0x4000  r-x/r-x\n" +
+		"  __DATA_CONST  0x180004000 -> 0x180008000  offset: 0x4000  size: 0x4000  rw-/rw-\n" +
+		"\n" +
+		"subcache .01 (B): 0x190000000 -> 0x190004000\n" +
+		"  __TEXT  0x190000000 -> 0x190004000  offset: 0x0  size: 0x4000  r-x/r-x\n"
+	if out.String() != want {
+		t.Errorf("writeMappings() = %q, want %q", out.String(), want)
+	}
+}
+
+func Test_sourceLocation(t *testing.T) {
+	if got := sourceLocation("", 0); got != "" {
+		t.Errorf("sourceLocation() w/o line info = %q, want \"\"", got)
+	}
+	if got, want := sourceLocation("/src/Foo.m", 42), " at /src/Foo.m:42"; got != want {
+		t.Errorf("sourceLocation() = %q, want %q", got, want)
+	}
+	var ds dscDSYMs // no --dsym
+	fn := dscFunc{Addr: 0x180010000, Name: "_main"}
+	ds.addSourceLine(&fn, nil, nil)
+	if len(fn.File) > 0 || fn.Line != 0 {
+		t.Errorf("addSourceLine() w/o dSYMs set %s:%d", fn.File, fn.Line)
+	}
+}
+
+func Test_containingSymbol(t *testing.T) {
+	syms := map[uint64]string{
+		0x1d0000000: "_OBJC_CLASS_$_Foo",
+		0x1d0000028: "_OBJC_CLASS_$_Bar",
+		0x1d0000050: "_kFooKey",
+	}
+	tests := []struct {
+		addr       uint64
+		start, end uint64
+		name       string
+	}{
+		{0x1d0000000, 0x1d0000000, 0x1d0000028, "_OBJC_CLASS_$_Foo"},
+		{0x1d0000030, 0x1d0000028, 0x1d0000050, "_OBJC_CLASS_$_Bar"},
+		{0x1d0000058, 0x1d0000050, 0x1d0000060, "_kFooKey"}, // the last symbol ends at the section end
+	}
+	for _, tt := range tests {
+		start, end, name, ok := containingSymbol(syms, tt.addr, 0x1d0000060)
+		if !ok || start != tt.start || end != tt.end || name != tt.name {
+			t.Errorf("containingSymbol(%#x) = %#x, %#x, %s, %t, want %#x, %#x, %s", tt.addr, start, end, name, ok, tt.start, tt.end, tt.name)
+		}
+	}
+	if _, _, _, ok := containingSymbol(syms, 0x1cffffff0, 0x1d0000060); ok {
+		t.Errorf("containingSymbol() found a symbol before the first one")
+	}
+}
+
+func Test_sampleA2S(t *testing.T) {
+	a2s := make(map[uint64]string)
+	for i := uint64(0); i < 10; i++ {
+		a2s[0x180000000+i*0x10] = fmt.Sprintf("_func%d", i)
+	}
+	if got := sampleA2S(a2s, 0); len(got) != 10 || !slices.IsSorted(got) {
+		t.Errorf("sampleA2S(0) = %#x, want all 10 sorted addresses", got)
+	}
+	want := []uint64{0x180000000, 0x180000020, 0x180000050, 0x180000070}
+	if got := sampleA2S(a2s, 4); !slices.Equal(got, want) {
+		t.Errorf("sampleA2S(4) = %#x, want %#x", got, want)
+	}
+	if got := sampleA2S(a2s, 20); len(got) != 10 {
+		t.Errorf("sampleA2S(20) = %d addresses, want 10", len(got))
+	}
+}
+
+func Test_dscExports_loadedA2S(t *testing.T) {
+	a2s := filepath.Join(t.TempDir(), "dyld_shared_cache_arm64e.a2s")
+	saved := &dyld.File{AddressToSymbol: map[uint64]string{
+		0x180010000: "_exported",
+		0x180020000: "_local",
+		0x180030000: "__ZN10Foundation4Data5countEv",
+		0x180040000: "_alias", // a local alias of an export
+		0x180050000: "-[NSObject description]",
+	}}
+	if err := saved.SaveAddrToSymMap(a2s); err != nil {
+		t.Fatalf("SaveAddrToSymMap() error = %v", err)
+	}
+	// loading an existing .a2s doesn't parse the images' public symbols
+	f := &dyld.File{AddressToSymbol: make(map[uint64]string)}
+	if err := f.OpenOrCreateA2SCache(a2s); err != nil {
+		t.Fatalf("OpenOrCreateA2SCache() error = %v", err)
+	}
+	img := &dyld.CacheImage{Name: "/usr/lib/libfoo.dylib"}
+	exports := newDSCExports(f)
+	exports.sets[img] = map[uint64]string{ // the export trie of img
+		0x180010000: "_exported",
+		0x180030000: "__ZN10Foundation4Data5countEv",
+		0x180040000: "_real",
+	}
+	tests := []struct {
+		addr uint64
+		want string
+	}{
+		{0x180010000, "export"},
+		{0x180020000, "symtab"},
+		{0x180030000, "export"},
+		{0x180040000, "symtab"},
+		{0x180050000, "objc"},
+		{0x180060000, "synthesized"},
+	}
+	for _, tt := range tests {
+		name, _ := funcSymbol(f, tt.addr)
+		if got := exports.source(img, tt.addr, name); got != tt.want {
+			t.Errorf("source(%#x, %q) = %s, want %s", tt.addr, name, got, tt.want)
+		}
+	}
+	// the source is of the raw name, the demangled one isn't in the export trie
+	if got := exports.source(img, 0x180030000, demangle.Do("__ZN10Foundation4Data5countEv", false, false)); got != "symtab" {
+		t.Errorf("source() of the demangled name = %s, want symtab", got)
+	}
+}
+
+func Test_symbolAddrs(t *testing.T) {
+	local := func(name string, addr uint64) *dyld.CacheLocalSymbol64 {
+		sym := &dyld.CacheLocalSymbol64{Name: name}
+		sym.Value = addr
+		return sym
+	}
+	img := &dyld.CacheImage{
+		LocalSymbols: []*dyld.CacheLocalSymbol64{
+			local("_OUTLINED_FUNCTION_0", 0x180010000),
+			local("_OUTLINED_FUNCTION_0", 0x180020000),
+			local("_OUTLINED_FUNCTION_1", 0x180010020),
+			local("_helper", 0x180030000),
+		},
+		PublicSymbols: []*dyld.Symbol{
+			{Name: "_OUTLINED_FUNCTION_0", Address: 0x180040000},
+			{Name: "_public", Address: 0x180050000},
+		},
+	}
+	tests := []struct {
+		name string
+		want []uint64
+	}{
+		{"_OUTLINED_FUNCTION_0", []uint64{0x180010000, 0x180020000, 0x180040000}},
+		{"_OUTLINED_FUNCTION_1", []uint64{0x180010020}},
+		{"_public", []uint64{0x180050000}},
+		{"_missing", nil},
+	}
+	for _, tt := range tests {
+		if got := symbolAddrs(img, tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("symbolAddrs(%s) = %#x, want %#x", tt.name, got, tt.want)
+		}
+	}
+}
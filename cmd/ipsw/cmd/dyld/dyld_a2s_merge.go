@@ -0,0 +1,80 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AddrToSymCmd.AddCommand(a2sMergeCmd)
+	a2sMergeCmd.Flags().BoolP("force", "f", false, "Overwrite conflicting symbols (last wins)")
+	viper.BindPFlag("dyld.a2s.merge.force", a2sMergeCmd.Flags().Lookup("force"))
+}
+
+// a2sMergeCmd represents the a2s merge command
+var a2sMergeCmd = &cobra.Command{
+	Use:           "merge <OUT.a2s> <IN.a2s>...",
+	Short:         "Merge multiple .a2s addr to sym cache files",
+	Args:          cobra.MinimumNArgs(2),
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		force := viper.GetBool("dyld.a2s.merge.force")
+
+		outFile := filepath.Clean(args[0])
+
+		// TODO: validate that all inputs share the same cache UUID once .a2s files are UUID stamped
+		merged := make(map[uint64]string)
+		for _, inFile := range args[1:] {
+			a2s, err := dyld.ReadA2SCache(filepath.Clean(inFile))
+			if err != nil {
+				return err
+			}
+			log.Debugf("Merging %d symbols from %s", len(a2s), inFile)
+			for addr, sym := range a2s {
+				if prev, ok := merged[addr]; ok && prev != sym {
+					if !force {
+						return fmt.Errorf("conflicting symbols for address %#x: '%s' and '%s' (%s) (use --force to overwrite)", addr, prev, sym, inFile)
+					}
+					log.Debugf("overwriting %#x: '%s' with '%s'", addr, prev, sym)
+				}
+				merged[addr] = sym
+			}
+		}
+
+		log.Infof("Writing %d symbols to %s", len(merged), outFile)
+		return dyld.WriteA2SCache(outFile, merged)
+	},
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	AddrToSymCmd.AddCommand(a2sVerifyCmd)
+	a2sVerifyCmd.Flags().Bool("full", false, "Check every entry (instead of a sample)")
+	a2sVerifyCmd.Flags().IntP("sample", "n", 1000, "Number of entries to spot-check (evenly spaced by address)")
+	viper.BindPFlag("dyld.a2s.verify.full", a2sVerifyCmd.Flags().Lookup("full"))
+	viper.BindPFlag("dyld.a2s.verify.sample", a2sVerifyCmd.Flags().Lookup("sample"))
+}
+
+// a2sMismatch is a .a2s entry that doesn't match the cache
+type a2sMismatch struct {
+	Addr   uint64
+	Name   string
+	Reason string
+}
+
+func (m a2sMismatch) String() string {
+	return fmt.Sprintf("%#x: %s: %s", m.Addr, m.Name, m.Reason)
+}
+
+// a2sVerifyCmd represents the a2s verify command
+var a2sVerifyCmd = &cobra.Command{
+	Use:   "verify <DSC> <A2S>",
+	Short: "Verify that a .a2s addr to sym cache file matches the dyld_shared_cache",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return getDSCs(toComplete), cobra.ShellCompDirectiveDefault
+	},
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		full := viper.GetBool("dyld.a2s.verify.full")
+		sample := viper.GetInt("dyld.a2s.verify.sample")
+
+		if !full && sample <= 0 {
+			return fmt.Errorf("--sample must be greater than 0 (or use --full)")
+		}
+
+		f, err := dyld.Open(filepath.Clean(args[0]))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		a2s, err := dyld.ReadA2SCache(filepath.Clean(args[1]))
+		if err != nil {
+			return err
+		}
+
+		if full {
+			sample = 0
+		}
+		addrs := sampleA2S(a2s, sample)
+		log.Infof("Checking %d of %d entries in %s", len(addrs), len(a2s), args[1])
+
+		machos := make(map[*dyld.CacheImage]*macho.File)
+		defer func() {
+			for _, m := range machos {
+				m.Close()
+			}
+		}()
+		var skipped int
+		var mismatches []a2sMismatch
+		for _, addr := range addrs {
+			reason, skip, err := verifyA2SEntry(f, addr, a2s[addr], machos)
+			if err != nil {
+				return err
+			}
+			if skip {
+				skipped++
+			} else if len(reason) > 0 {
+				mismatches = append(mismatches, a2sMismatch{Addr: addr, Name: a2s[addr], Reason: reason})
+			}
+		}
+
+		for _, mm := range mismatches {
+			fmt.Println(mm)
+		}
+		log.Infof("Checked %d entries: %d OK, %d skipped, %d mismatched", len(addrs), len(addrs)-skipped-len(mismatches), skipped, len(mismatches))
+		if len(mismatches) > 0 {
+			return fmt.Errorf("%d of the checked .a2s entries do not match the cache", len(mismatches))
+		}
+		return nil
+	},
+}
+
+// sampleA2S returns the (sorted) addresses of n entries of a .a2s cache evenly spaced by address (or all of them if n <= 0)
+func sampleA2S(a2s map[uint64]string, n int) []uint64 {
+	addrs := make([]uint64, 0, len(a2s))
+	for addr := range a2s {
+		addrs = append(addrs, addr)
+	}
+	slices.Sort(addrs)
+	if n <= 0 || n >= len(addrs) {
+		return addrs
+	}
+	sampled := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		sampled = append(sampled, addrs[i*len(addrs)/n])
+	}
+	return sampled
+}
+
+// verifyA2SEntry returns why the .a2s entry for addr (name) doesn't match the cache ("" if it does) and whether it can't be checked
+// NOTE: only addresses in __text must be function starts (the cache also has data symbols) and ObjC method names
+// (which come from the ObjC metadata rather than the symbol tables) are only checked to be function starts
+func verifyA2SEntry(f *dyld.File, addr uint64, name string, machos map[*dyld.CacheImage]*macho.File) (string, bool, error) {
+	img, err := f.GetImageContainingVMAddr(addr)
+	if err != nil {
+		if strings.HasPrefix(name, "j_") {
+			return "", true, nil // stub islands are outside of the images
+		}
+		return "not in any image", false, nil
+	}
+	m, ok := machos[img]
+	if !ok {
+		if m, err = img.GetMacho(); err != nil {
+			return "", false, fmt.Errorf("failed to parse %s: %v", img.Name, err)
+		}
+		machos[img] = m
+	}
+	start := addr
+	if strings.HasPrefix(cacheArch(f), "armv7") {
+		start &^= 1 // thumb function symbols have the low bit set
+	}
+	if sec := m.FindSectionForVMAddr(start); sec != nil && sec.Name == "__text" {
+		fn, err := m.GetFunctionForVMAddr(start)
+		if err != nil {
+			return fmt.Sprintf("not in a function of %s", filepath.Base(img.Name)), false, nil
+		}
+		if fn.StartAddr != start {
+			return fmt.Sprintf("not a function start (the function starts at %#x)", fn.StartAddr), false, nil
+		}
+	}
+	if strings.HasPrefix(name, "-[") || strings.HasPrefix(name, "+[") {
+		return "", false, nil
+	}
+	img.ParseLocalSymbols(false)
+	img.ParsePublicSymbols(false)
+	addrs := symbolAddrs(img, name)
+	if len(addrs) == 0 {
+		return fmt.Sprintf("no such symbol in %s", filepath.Base(img.Name)), false, nil
+	}
+	if !slices.Contains(addrs, addr) {
+		if len(addrs) == 1 {
+			return fmt.Sprintf("the symbol is at %#x", addrs[0]), false, nil
+		}
+		return fmt.Sprintf("the symbols are at %#x", addrs), false, nil
+	}
+	return "", false, nil
+}
+
+// symbolAddrs returns the addresses of all the (parsed) local and public symbols of img named name,
+// as symbol names aren't unique within an image (e.g. _OUTLINED_FUNCTION_0 or the static functions of different files)
+func symbolAddrs(img *dyld.CacheImage, name string) []uint64 {
+	var addrs []uint64
+	// both are sorted by name
+	idx := sort.Search(len(img.LocalSymbols), func(i int) bool { return img.LocalSymbols[i].Name >= name })
+	for ; idx < len(img.LocalSymbols) && img.LocalSymbols[idx].Name == name; idx++ {
+		addrs = append(addrs, img.LocalSymbols[idx].Value)
+	}
+	idx = sort.Search(len(img.PublicSymbols), func(i int) bool { return img.PublicSymbols[i].Name >= name })
+	for ; idx < len(img.PublicSymbols) && img.PublicSymbols[idx].Name == name; idx++ {
+		addrs = append(addrs, img.PublicSymbols[idx].Address)
+	}
+	return addrs
+}
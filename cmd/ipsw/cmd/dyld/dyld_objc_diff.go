@@ -0,0 +1,131 @@
+/*
+Copyright © 2018-2024 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	mcmd "github.com/blacktop/ipsw/internal/commands/macho"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	ObjcCmd.AddCommand(objcDiffCmd)
+	objcDiffCmd.Flags().BoolP("json", "j", false, "Output as JSON")
+}
+
+// openDiffImage opens a DSC and returns an ObjC parser of its image (or nil if the DSC doesn't have the image)
+func openDiffImage(dscPath, image string) (*dyld.File, *mcmd.ObjC, error) {
+	fileInfo, err := os.Lstat(dscPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("file %s does not exist", dscPath)
+	}
+	// Check if file is a symlink
+	if fileInfo.Mode()&os.ModeSymlink != 0 {
+		symlinkPath, err := os.Readlink(dscPath)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read symlink %s", dscPath)
+		}
+		dscPath = filepath.Join(filepath.Dir(filepath.Dir(dscPath)), symlinkPath)
+	}
+	f, err := dyld.Open(dscPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := f.Image(image)
+	if err != nil {
+		log.Warnf("%s not found in %s", image, dscPath)
+		return f, nil, nil
+	}
+	m, err := img.GetMacho()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to get macho for image %s: %v", image, err)
+	}
+	o, err := mcmd.NewObjC(m, f, &mcmd.ObjcConfig{Name: filepath.Base(img.Name)})
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to parse ObjC of %s: %v", image, err)
+	}
+	return f, o, nil
+}
+
+// objcDiffCmd represents the objc diff command
+var objcDiffCmd = &cobra.Command{
+	Use:     "diff <OLD_DSC> <NEW_DSC> <IMAGE>",
+	Aliases: []string{"d"},
+	Short:   "Diff the ObjC classes, protocols and methods of an image in two DSCs",
+	Args:    cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return getDSCs(toComplete), cobra.ShellCompDirectiveDefault
+	},
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+		color.NoColor = viper.GetBool("no-color")
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		f1, o1, err := openDiffImage(filepath.Clean(args[0]), args[2])
+		if err != nil {
+			return err
+		}
+		defer f1.Close()
+		f2, o2, err := openDiffImage(filepath.Clean(args[1]), args[2])
+		if err != nil {
+			return err
+		}
+		defer f2.Close()
+		if o1 == nil && o2 == nil {
+			return fmt.Errorf("image %s not found in either DSC", args[2])
+		}
+
+		diff, err := mcmd.DiffObjC(args[2], o1, o2)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			dat, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal ObjC diff: %v", err)
+			}
+			fmt.Println(string(dat))
+			return nil
+		}
+		fmt.Print(diff)
+		return nil
+	},
+}
@@ -22,9 +22,12 @@ THE SOFTWARE.
 package dyld
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 
 	"github.com/apex/log"
 	"github.com/blacktop/ipsw/pkg/dyld"
@@ -37,6 +40,8 @@ import (
 func init() {
 	ObjcCmd.AddCommand(objcSelCmd)
 	objcSelCmd.Flags().StringP("image", "i", "", "dylib image to search")
+	objcSelCmd.Flags().BoolP("xrefs", "x", false, "Output JSON index of the images that reference each selector")
+	objcSelCmd.Flags().StringP("filter", "f", "", "Only index selectors matching regex (with --xrefs)")
 }
 
 // objcSelCmd represents the sel command
@@ -57,6 +62,12 @@ var objcSelCmd = &cobra.Command{
 		color.NoColor = viper.GetBool("no-color")
 
 		imageName, _ := cmd.Flags().GetString("image")
+		xrefs, _ := cmd.Flags().GetBool("xrefs")
+		filter, _ := cmd.Flags().GetString("filter")
+
+		if len(filter) > 0 && !xrefs {
+			return fmt.Errorf("--filter requires --xrefs")
+		}
 
 		dscPath := filepath.Clean(args[0])
 
@@ -84,6 +95,40 @@ var objcSelCmd = &cobra.Command{
 		}
 		defer f.Close()
 
+		if xrefs {
+			var re *regexp.Regexp
+			if len(filter) > 0 {
+				re, err = regexp.Compile(filter)
+				if err != nil {
+					return fmt.Errorf("invalid --filter regex: %v", err)
+				}
+			}
+			index := make(map[string][]string)
+			for _, image := range f.Images {
+				m, err := image.GetMacho()
+				if err != nil {
+					log.Errorf("failed to get macho for image %s: %v (skipping)", image.Name, err)
+					continue
+				}
+				sels, err := m.GetObjCSelectorReferences()
+				if err != nil {
+					log.Debugf("failed to get objc selector references for image %s: %v", image.Name, err)
+				}
+				for _, sel := range sels {
+					if re != nil && !re.MatchString(sel.Name) {
+						continue
+					}
+					if imgs := index[sel.Name]; !slices.Contains(imgs, image.Name) {
+						index[sel.Name] = append(imgs, image.Name)
+					}
+				}
+				image.Free() // process one image at a time to keep memory bounded
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(index)
+		}
+
 		if len(args) > 1 {
 			ptrs, err := f.GetSelectorAddresses(args[1])
 			if err != nil {
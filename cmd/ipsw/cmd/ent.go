@@ -234,7 +234,7 @@ var entCmd = &cobra.Command{
 										fmt.Fprintf(w, "%s\t%s\n", colorKey(k), colorBin(f))
 									}
 								case uint64:
-									fmt.Fprintf(w, "%s=%d\t%s\n", colorKey(k), colorValue(v), colorBin(f))
+									fmt.Fprintf(w, "%s=%s\t%s\n", colorKey(k), colorValue(v), colorBin(f))
 								case string:
 									fmt.Fprintf(w, "%s\t%s\n", colorKey(k), colorBin(f))
 									fmt.Fprintf(w, " - %s\n", colorValue(v))
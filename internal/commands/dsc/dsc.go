@@ -7,6 +7,7 @@ import (
 	"io"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/apex/log"
@@ -90,6 +91,20 @@ type SymbolLookup struct {
 	Segment string `json:"segment,omitempty"`
 }
 
+// Stub is a struct that contains information about a dyld_shared_cache symbol stub or ObjC selector stub
+type Stub struct {
+	// The address of the stub
+	Address uint64 `json:"address,omitempty"`
+	// The size of the stub
+	Size uint64 `json:"size,omitempty"`
+	// The address the stub branches to (or the selector for ObjC stubs)
+	Target uint64 `json:"target,omitempty"`
+	// The name of the stub target
+	Name string `json:"name,omitempty"`
+	// The stub section name
+	Section string `json:"section,omitempty"`
+}
+
 // String is a struct that contains information about a dyld_shared_cache string
 // swagger:model
 type String struct {
@@ -371,6 +386,87 @@ retry:
 	goto retry
 }
 
+// LookupStub returns the dyld_shared_cache symbol stub or ObjC selector stub containing an address
+func LookupStub(f *dyld.File, image *dyld.CacheImage, addr uint64) (*Stub, error) {
+	m, err := image.GetMacho()
+	if err != nil {
+		return nil, err
+	}
+
+	sec := m.FindSectionForVMAddr(addr)
+	if sec == nil {
+		return nil, fmt.Errorf("%#x is not in a section of %s", addr, filepath.Base(image.Name))
+	}
+
+	stub := &Stub{Section: sec.Name}
+
+	if sec.Name == "__objc_stubs" {
+		if image.ObjC.Stubs == nil {
+			if err := f.GetObjCStubsForImage(image.Name); err != nil {
+				return nil, fmt.Errorf("failed to parse objc stubs for %s: %v", filepath.Base(image.Name), err)
+			}
+		}
+		var starts []uint64
+		for start := range image.ObjC.Stubs {
+			starts = append(starts, start)
+		}
+		if err := stub.locate(starts, addr, sec.Addr, sec.Addr+sec.Size, 0); err != nil {
+			return nil, err
+		}
+		objcStub := image.ObjC.Stubs[stub.Address]
+		stub.Target = objcStub.SelectorRef
+		stub.Name = "_objc_msgSend$" + objcStub.Name
+		return stub, nil
+	} else if sec.Flags.IsSymbolStubs() {
+		if !image.Analysis.State.IsStubsDone() {
+			if err := image.ParseStubs(); err != nil {
+				return nil, err
+			}
+		}
+		var starts []uint64
+		for start := range image.Analysis.SymbolStubs {
+			starts = append(starts, start)
+		}
+		if err := stub.locate(starts, addr, sec.Addr, sec.Addr+sec.Size, uint64(sec.Reserved2)); err != nil {
+			return nil, err
+		}
+		stub.Target = image.Analysis.SymbolStubs[stub.Address]
+		if symName, ok := f.AddressToSymbol[stub.Target]; ok {
+			stub.Name = symName
+		} else {
+			stub.Name = fmt.Sprintf("func_%x", stub.Target)
+		}
+		return stub, nil
+	}
+
+	return nil, fmt.Errorf("%#x is not in a stubs section (%s.%s)", addr, sec.Seg, sec.Name)
+}
+
+// locate finds the stub (of the given starts) that contains addr
+func (s *Stub) locate(starts []uint64, addr, secStart, secEnd, size uint64) error {
+	slices.Sort(starts)
+	idx, found := slices.BinarySearch(starts, addr)
+	if !found {
+		if idx == 0 {
+			return fmt.Errorf("failed to find stub containing %#x", addr)
+		}
+		idx--
+	}
+	s.Address = starts[idx]
+	if size == 0 {
+		if idx+1 < len(starts) {
+			size = starts[idx+1] - s.Address
+		} else {
+			size = secEnd - s.Address
+		}
+	}
+	s.Size = size
+	if s.Address < secStart || addr >= s.Address+s.Size {
+		return fmt.Errorf("failed to find stub containing %#x", addr)
+	}
+	return nil
+}
+
 // GetDylibsThatImport returns a list of dylibs that import the given dylib
 func GetDylibsThatImport(f *dyld.File, name string) (*ImportedBy, error) {
 	var importedBy ImportedBy
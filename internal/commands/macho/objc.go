@@ -2,29 +2,48 @@
 package macho
 
 import (
+	"archive/tar"
 	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/alecthomas/chroma/v2/quick"
 	"github.com/apex/log"
 	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types"
 	"github.com/blacktop/go-macho/types/objc"
 	"github.com/blacktop/go-plist"
 	"github.com/blacktop/ipsw/internal/swift"
+	"github.com/blacktop/ipsw/pkg/disass"
 	"github.com/blacktop/ipsw/pkg/dyld"
 	"github.com/blacktop/ipsw/pkg/tbd"
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/sync/errgroup"
 )
 
 // ErrNoObjc is returned when a MachO does not contain objc info
 var ErrNoObjc = errors.New("macho does not contain objc info")
 
+// ErrEncrypted is returned when a MachO is (FairPlay) encrypted, i.e. its ObjC names and types can't be read
+var ErrEncrypted = errors.New("macho is encrypted (LC_ENCRYPTION_INFO cryptid is set): decrypt it before dumping its objc info")
+
 // ObjcConfig for MachO ObjC parser
 type ObjcConfig struct {
 	Name     string
@@ -35,6 +54,51 @@ type ObjcConfig struct {
 	Deps     bool
 	Demangle bool
 
+	CatsByClass        bool   // sort/group categories by their target class name
+	JSON               bool   // output queries as JSON
+	SwiftStyle         bool   // render method signatures in their (best-effort) Swift-imported form
+	KVCKeys            bool   // list the KVC keys derived from each class's properties and getters
+	SkipSwiftSynthetic bool   // omit compiler generated Swift classes from Dump and Headers (see swiftSyntheticPatterns)
+	Metaclass          bool   // also dump each class's metaclass (its ivars, root metaclass and superclass chain) in Dump and DumpClass
+	HideNSObject       bool   // omit the NSObject protocol's (inherited) methods and properties from non-root classes
+	ShowAdopters       bool   // list the classes (incl. those of Deps) that adopt each protocol after it in Dump and DumpProtocol
+	DedupProtocols     bool   // print protocols with identical methods/properties (but different pointers) once in Dump, noting the others' names
+	SortMethods        bool   // sort methods by selector instead of the (on-disk) method list order
+	SwiftAliases       bool   // emit @compatibility_alias <Name> <MangledName> in headers of Swift classes with mangled ObjC names
+	PropertyAccessors  bool   // annotate class and category properties with their getter/setter selectors
+	Literal            bool   // match name patterns as literal substrings instead of regexes (e.g. for Swift mangled names)
+	Threads            int    // max images processed concurrently by cache-wide operations (<= 0 is GOMAXPROCS, up to maxObjcThreads)
+	TableSort          string // DumpTable column to sort by: name (default), ivars, imethods, cmethods, props or protos
+	DepsDepth          int    // levels of imported libraries to load with Deps (<= 1 only loads the MachO's own imports)
+	MinMethods         int    // only include the classes with at least this many (class and instance) methods in Dump and DumpClass
+	SkipEmpty          bool   // omit the classes with no ivars, methods or properties (e.g. stubs) from Dump and Headers
+	OnlyEmpty          bool   // only include the classes with no ivars, methods or properties in Dump and Headers
+	ExportedOnly       bool   // only include the classes the MachO (or cache image) exports an _OBJC_CLASS_$_ symbol for (approximates the public API)
+	NamedParams        bool   // name method parameters after their whole selector keywords (initWithFoo:forKeyPath: -> foo, keyPath) instead of their last word
+	MarkOverrides      bool   // mark the methods that override a (MachO, deps or Foundation) superclass method with // override
+	Module             string // only include the classes of this Swift module (classes w/o a Swift module name are omitted)
+
+	// header generation options
+	UseModules         bool   // import Foundation with @import instead of #import <Foundation/Foundation.h>
+	ImportsNotForwards bool   // include the generated headers of @class/@protocol forward declared classes/protocols instead (for IDE navigation)
+	InlineFoundation   bool   // forward declare the referenced Foundation classes/protocols instead of importing Foundation (for standalone parsing)
+	FilenameTemplate   string // text/template for header file names w/o the extension (fields: .Name, .Kind, .Class, .Category; kinds: class, protocol, category, extension)
+	FoundationPath     string // on-disk Foundation binary (or folder containing it) to use when there is no DSC
+	LineEnding         string // line ending to use in headers: lf (default) or crlf
+	HeaderExt          string // header file name extension (default .h)
+	IndentStyle        string // normalize the headers' leading whitespace to tabs or spaces:N (default leaves it as-is)
+	NoBanner           bool   // omit the version-bearing "Generated by" banner (for byte-stable diffs across ipsw versions)
+	Baseline           string // folder of previously generated headers; only write headers that are new or changed and a changes.txt summary
+	BaselineAll        bool   // the run generates every image of Baseline, so the headers of the images it doesn't generate are removed
+	UmbrellaOnly       bool   // only write the umbrella header (importing the headers that would be generated) for a quick overview
+	Tags               bool   // also write a ctags tags file of the generated headers' declarations at the Output root (for jump-to-definition)
+	SplitPrivate       bool   // move the underscore-prefixed properties/methods of classes into their <Class>-Private.h extension header
+	BridgingHeader     bool   // also write a <Name>-Bridging-Header.h importing the umbrella header (for Swift consumers)
+	EmitImpl           bool   // also write a <Class>.m @implementation with stubbed (zero/nil returning) method bodies next to each class header
+	FoundationCache    string // on-disk cache of the DSC's Foundation class/protocol names (keyed by the DSC UUID)
+	// only emit the methods/properties with these selectors in Headers (classes, protocols and categories w/o any are skipped)
+	SelectorAllowlist []string
+
 	IpswVersion string
 
 	Color  bool
@@ -44,10 +108,10 @@ type ObjcConfig struct {
 
 // Imports represents the imported symbols, local symbols, classes, and protocols for a ObjC header
 type Imports struct {
-	Imports []string
-	Locals  []string
-	Classes []string
-	Protos  []string
+	Imports []string `json:"imports,omitempty"`
+	Locals  []string `json:"locals,omitempty"`
+	Classes []string `json:"classes,omitempty"`
+	Protos  []string `json:"protocols,omitempty"`
 }
 
 func (i *Imports) uniq(foundation map[string][]string) {
@@ -60,8 +124,8 @@ func (i *Imports) uniq(foundation map[string][]string) {
 	i.Classes = slices.Compact(i.Classes)
 	i.Protos = slices.Compact(i.Protos)
 	i.Locals = slices.DeleteFunc(i.Locals, func(l string) bool {
-		l = strings.TrimSuffix(l, "-Protocol.h")
-		l = strings.TrimSuffix(l, ".h")
+		l = strings.TrimSuffix(l, filepath.Ext(l))
+		l = strings.TrimSuffix(l, "-Protocol")
 		_, foundC := slices.BinarySearch(foundation["classes"], l)
 		_, foundP := slices.BinarySearch(foundation["protocols"], l)
 		return foundC || foundP
@@ -78,6 +142,70 @@ func (i *Imports) uniq(foundation map[string][]string) {
 	})
 }
 
+// ImageInfo represents the decoded __objc_imageinfo of a MachO
+type ImageInfo struct {
+	Image                string   `json:"image,omitempty"`
+	Version              uint32   `json:"version"`
+	RawFlags             uint32   `json:"raw_flags"`
+	Flags                []string `json:"flags,omitempty"`
+	Swift                string   `json:"swift,omitempty"`
+	SwiftUnstableVersion uint32   `json:"swift_unstable_version,omitempty"`
+	SwiftStableVersion   uint32   `json:"swift_stable_version,omitempty"`
+	SwiftMetadata        int      `json:"swift_metadata_version,omitempty"` // from the __swift<N>_* section names
+	SwiftReflection      bool     `json:"swift_reflection,omitempty"`       // has field/reflection string metadata (i.e. wasn't built w/ -disable-reflection-metadata)
+}
+
+func newImageInfo(m *macho.File, info *objc.ImageInfo) ImageInfo {
+	ii := ImageInfo{
+		Image:                machoName(m),
+		Version:              info.Version,
+		RawFlags:             uint32(info.Flags),
+		Flags:                info.Flags.List(),
+		SwiftUnstableVersion: uint32(info.Flags&objc.SwiftUnstableVersionMask) >> objc.SwiftUnstableVersionMaskShift,
+		SwiftStableVersion:   uint32(info.Flags&objc.SwiftStableVersionMask) >> objc.SwiftStableVersionMaskShift,
+	}
+	if info.HasSwift() {
+		ii.Swift = info.Flags.SwiftVersion()
+	}
+	for _, sec := range m.Sections {
+		// e.g. __swift5_types, __swift5_fieldmd, __swift5_reflstr
+		rest, ok := strings.CutPrefix(sec.Name, "__swift")
+		if !ok {
+			continue
+		}
+		ver, name, _ := strings.Cut(rest, "_")
+		if v, err := strconv.Atoi(ver); err == nil {
+			ii.SwiftMetadata = max(ii.SwiftMetadata, v)
+			if name == "fieldmd" || name == "reflstr" {
+				ii.SwiftReflection = true
+			}
+		}
+	}
+	return ii
+}
+
+func (i ImageInfo) String() string {
+	out := fmt.Sprintf(
+		"ObjC Image Info\n"+
+			"---------------\n"+
+			"  version = %d\n"+
+			"  flags   = %#x\n", i.Version, i.RawFlags)
+	for _, flag := range i.Flags {
+		out += fmt.Sprintf("    - %s\n", flag)
+	}
+	if len(i.Swift) > 0 {
+		out += fmt.Sprintf("  swift   = %s (unstable ABI: %d, stable ABI: %#x)\n", i.Swift, i.SwiftUnstableVersion, i.SwiftStableVersion)
+	}
+	if i.SwiftMetadata > 0 {
+		reflection := "no reflection metadata"
+		if i.SwiftReflection {
+			reflection = "with reflection metadata"
+		}
+		out += fmt.Sprintf("  swift metadata = %d (%s)\n", i.SwiftMetadata, reflection)
+	}
+	return out
+}
+
 type headerInfo struct {
 	FileName      string
 	IpswVersion   string
@@ -87,6 +215,7 @@ type headerInfo struct {
 	Name          string
 	Imports       Imports
 	Object        string
+	Aliases       []string // @compatibility_alias declarations (written after the demangled Object)
 }
 
 // ObjC represents a MachO ObjC parser
@@ -97,10 +226,53 @@ type ObjC struct {
 	deps  []*macho.File
 
 	foundation map[string][]string
+	stubs      map[*macho.File]map[uint64]uint64
+	stubsMu    sync.Mutex                      // guards stubs (resolved by the workers of forEachImage)
+	exported   map[*macho.File]map[string]bool // MachO -> the classes it exports (for ExportedOnly)
+	inCache    map[*macho.File]bool
+	inCacheMu  sync.Mutex             // guards inCache
+	supers     map[string]objcMembers // class name -> its superclass and selectors (for MarkOverrides)
+	cacheNames map[uint64]string
+	cacheSels  map[uint64]string
+	namesOnce  sync.Once         // lazily builds cacheNames (once for all the workers of forEachImage)
+	selsOnce   sync.Once         // lazily builds cacheSels
+	depProtos  map[string]string // protocol name -> name of the dep image that defines it
+	fnameTmpl  *template.Template
+	changes    *headerChanges
+	skipped    map[string][]string // image -> ObjC sections it doesn't have
+	adopters   map[string][]string // protocol name -> the classes (of the MachO and its deps) adopting it (for ShowAdopters)
+	archive    *tar.Writer
+	umbrellas  []string          // umbrella headers written by Headers (for VerifyHeaders)
+	hidden     map[string]bool   // "<class>.<ivar>" of the ivars Headers renders as @private
+	tags       []ctag            // the declarations of the written headers (for Tags)
+	outHeaders map[string]string // "class:<name>"/"protocol:<name>" -> image folder of its generated header (for ImportsNotForwards)
+	mu         sync.Mutex        // guards the state shared by the workers of forEachImage (skipped)
+}
+
+// maxObjcThreads caps the default number of concurrently processed images (each parsed image stays in memory until freed)
+const maxObjcThreads = 8
+
+const defaultFilenameTemplate = `{{if eq .Kind "protocol"}}{{.Name}}-Protocol{{else if eq .Kind "extension"}}{{.Class}}-Private{{else if eq .Kind "category"}}{{if .Class}}{{.Class}}+{{end}}{{.Category}}{{else}}{{.Name}}{{end}}`
+
+// headerFileInfo is the data passed to the header FilenameTemplate
+type headerFileInfo struct {
+	Name     string
+	Kind     string
+	Class    string
+	Category string
 }
 
 // NewObjC returns a new MachO ObjC parser instance
+//
+// Supported file/dsc combinations:
+//   - an on-disk MachO (dsc is nil)
+//   - one of the dsc's images (from CacheImage.GetMacho)
+//   - an on-disk MachO (e.g. a dylib extracted from the dsc) with its dsc, which is then only used
+//     for Foundation scanning, selector/class name resolution and Deps (and never for the MachO's own data)
 func NewObjC(file *macho.File, dsc *dyld.File, conf *ObjcConfig) (*ObjC, error) {
+	if isEncrypted(file) { // NOTE: only on-disk MachOs (the DSC's images are decrypted)
+		return nil, ErrEncrypted
+	}
 	if !file.HasObjC() {
 		return nil, ErrNoObjc
 	}
@@ -110,42 +282,114 @@ func NewObjC(file *macho.File, dsc *dyld.File, conf *ObjcConfig) (*ObjC, error)
 		file:       file,
 		cache:      dsc,
 		foundation: make(map[string][]string),
+		stubs:      make(map[*macho.File]map[uint64]uint64),
+		inCache:    make(map[*macho.File]bool),
+	}
+
+	if err := o.parseFilenameTemplate(); err != nil {
+		return nil, err
+	}
+
+	switch o.conf.LineEnding {
+	case "", "lf", "crlf":
+	default:
+		return nil, fmt.Errorf("invalid line ending '%s' (must be 'lf' or 'crlf')", o.conf.LineEnding)
+	}
+	if err := checkHeaderExt(o.conf.HeaderExt); err != nil {
+		return nil, err
+	}
+	if _, err := indentUnit(o.conf.IndentStyle); err != nil {
+		return nil, err
 	}
 
 	if o.conf.Deps {
 		if dsc == nil {
 			return nil, fmt.Errorf("dyld shared cache is required to dump imported private frameworks")
 		}
-		var deps []string
-		for _, imp := range file.ImportedLibraries() {
-			if o.conf.Headers {
-				// only dump private frameworks when generating headers
-				if strings.Contains(imp, "PrivateFrameworks") {
-					deps = append(deps, imp)
-				}
-			} else {
-				deps = append(deps, imp)
+		if err := o.loadDeps(); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// isEncrypted returns whether a MachO has an LC_ENCRYPTION_INFO(_64) with a nonzero cryptid
+func isEncrypted(m *macho.File) bool {
+	for _, l := range m.Loads {
+		switch l := l.(type) {
+		case *macho.EncryptionInfo:
+			if l.CryptID != types.NOT_ENCRYPTED_YET {
+				return true
+			}
+		case *macho.EncryptionInfo64:
+			if l.CryptID != types.NOT_ENCRYPTED_YET {
+				return true
 			}
 		}
-		for _, imageName := range deps {
-			img, err := o.cache.Image(imageName)
-			if err != nil {
-				return nil, err
+	}
+	return false
+}
+
+// loadDeps loads the MachO's imported libraries from the DSC (and theirs, up to DepsDepth levels deep)
+func (o *ObjC) loadDeps() error {
+	visited := make(map[string]bool)
+	if id := o.file.DylibID(); id != nil {
+		visited[id.Name] = true
+	}
+	parents := []*macho.File{o.file}
+	for level := 0; level < max(o.conf.DepsDepth, 1) && len(parents) > 0; level++ {
+		var next []*macho.File
+		for _, parent := range parents {
+			for _, imageName := range o.depImports(parent) {
+				if visited[imageName] { // prevent import cycles (and loading a dep twice)
+					continue
+				}
+				visited[imageName] = true
+				img, err := o.cache.Image(imageName)
+				if err != nil {
+					return err
+				}
+				m, err := img.GetMacho()
+				if err != nil {
+					return err
+				}
+				o.deps = append(o.deps, m)
+				next = append(next, m)
 			}
-			m, err := img.GetMacho()
-			if err != nil {
-				return nil, err
+		}
+		parents = next
+	}
+	return nil
+}
+
+// depImports returns the imported libraries of m to load as deps
+func (o *ObjC) depImports(m *macho.File) []string {
+	var deps []string
+	for _, imp := range m.ImportedLibraries() {
+		if o.conf.Headers {
+			// only dump private frameworks when generating headers
+			if strings.Contains(imp, "PrivateFrameworks") {
+				deps = append(deps, imp)
 			}
-			o.deps = append(o.deps, m)
+		} else {
+			deps = append(deps, imp)
 		}
 	}
+	return deps
+}
 
-	return o, nil
+// compilePattern compiles a user supplied class/protocol/category pattern (quoted as a literal if Literal is set)
+func (o *ObjC) compilePattern(pattern string) (*regexp.Regexp, error) {
+	if o.conf.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	return regexp.Compile(pattern)
 }
 
 // DumpClass returns a ObjC classes matching a given pattern from a MachO
 func (o *ObjC) DumpClass(pattern string) error {
-	re, err := regexp.Compile(pattern)
+	re, err := o.compilePattern(pattern)
 	if err != nil {
 		return fmt.Errorf("failed to compile regex: %v", err)
 	}
@@ -167,21 +411,27 @@ func (o *ObjC) DumpClass(pattern string) error {
 		})
 
 		for _, class := range classes {
-			if re.MatchString(class.Name) {
+			if re.MatchString(class.Name) && !o.tooFewMethods(&class) && !o.skipUnexported(m, class.Name) {
+				if o.conf.Addrs {
+					o.resolveClassImps(m, &class)
+				}
 				if o.conf.Color {
 					if o.conf.Addrs {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(class.WithAddrs()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpClass(&class, true, true)), "objc", "terminal256", o.conf.Theme)
 					} else {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(class.Verbose()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpClass(&class, true, false)), "objc", "terminal256", o.conf.Theme)
 					}
 					quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
 				} else {
 					if o.conf.Addrs {
-						fmt.Println(swift.DemangleBlob(class.WithAddrs()))
+						fmt.Println(swift.DemangleBlob(o.dumpClass(&class, true, true)))
 					} else {
-						fmt.Println(swift.DemangleBlob(class.Verbose()))
+						fmt.Println(swift.DemangleBlob(o.dumpClass(&class, true, false)))
 					}
 				}
+				if o.conf.Metaclass {
+					o.printMetaclass(m, &class, true, o.conf.Addrs)
+				}
 			}
 		}
 	}
@@ -190,7 +440,7 @@ func (o *ObjC) DumpClass(pattern string) error {
 
 // DumpProtocol returns a ObjC protocols matching a given pattern from a MachO
 func (o *ObjC) DumpProtocol(pattern string) error {
-	re, err := regexp.Compile(pattern)
+	re, err := o.compilePattern(pattern)
 	if err != nil {
 		return fmt.Errorf("failed to compile regex: %v", err)
 	}
@@ -216,18 +466,21 @@ func (o *ObjC) DumpProtocol(pattern string) error {
 			if re.MatchString(proto.Name) {
 				if o.conf.Color {
 					if o.conf.Addrs {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(proto.WithAddrs()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpProtocol(&proto, true, true)), "objc", "terminal256", o.conf.Theme)
 					} else {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(proto.Verbose()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpProtocol(&proto, true, false)), "objc", "terminal256", o.conf.Theme)
 					}
 					quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
 				} else {
 					if o.conf.Addrs {
-						fmt.Println(swift.DemangleBlob(proto.WithAddrs()))
+						fmt.Println(swift.DemangleBlob(o.dumpProtocol(&proto, true, true)))
 					} else {
-						fmt.Println(swift.DemangleBlob(proto.Verbose()))
+						fmt.Println(swift.DemangleBlob(o.dumpProtocol(&proto, true, false)))
 					}
 				}
+				if o.conf.ShowAdopters {
+					o.printAdopters(proto.Name)
+				}
 				seen[proto.Ptr] = true
 			}
 		}
@@ -237,7 +490,7 @@ func (o *ObjC) DumpProtocol(pattern string) error {
 
 // DumpCategory returns a ObjC categories matching a given pattern from a MachO
 func (o *ObjC) DumpCategory(pattern string) error {
-	re, err := regexp.Compile(pattern)
+	re, err := o.compilePattern(pattern)
 	if err != nil {
 		return fmt.Errorf("failed to compile regex: %v", err)
 	}
@@ -253,25 +506,48 @@ func (o *ObjC) DumpCategory(pattern string) error {
 			}
 			return err
 		}
+		o.resolveCategoryClasses(m, cats)
 
-		slices.SortStableFunc(cats, func(a, b objc.Category) int {
-			return cmp.Compare(a.Name, b.Name)
-		})
+		if o.conf.CatsByClass {
+			slices.SortStableFunc(cats, func(a, b objc.Category) int {
+				if c := cmp.Compare(categoryClassName(a), categoryClassName(b)); c != 0 {
+					return c
+				}
+				return cmp.Compare(a.Name, b.Name)
+			})
+		} else {
+			slices.SortStableFunc(cats, func(a, b objc.Category) int {
+				return cmp.Compare(a.Name, b.Name)
+			})
+		}
 
+		var group *string
 		for _, cat := range cats {
 			if re.MatchString(cat.Name) {
+				if cname := categoryClassName(cat); o.conf.CatsByClass && (group == nil || cname != *group) {
+					group = &cname
+					header := fmt.Sprintf("/* %s */\n", cname)
+					if o.conf.Color {
+						quick.Highlight(os.Stdout, header+"\n", "objc", "terminal256", o.conf.Theme)
+					} else {
+						fmt.Println(header)
+					}
+				}
+				if o.conf.Addrs {
+					o.resolveCategoryImps(m, &cat)
+				}
 				if o.conf.Color {
 					if o.conf.Addrs {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(cat.WithAddrs()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpCategory(&cat, true, true)), "objc", "terminal256", o.conf.Theme)
 					} else {
-						quick.Highlight(os.Stdout, swift.DemangleBlob(cat.Verbose()), "objc", "terminal256", o.conf.Theme)
+						quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpCategory(&cat, true, false)), "objc", "terminal256", o.conf.Theme)
 					}
 					quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
 				} else {
 					if o.conf.Addrs {
-						fmt.Println(swift.DemangleBlob(cat.WithAddrs()))
+						fmt.Println(swift.DemangleBlob(o.dumpCategory(&cat, true, true)))
 					} else {
-						fmt.Println(swift.DemangleBlob(cat.Verbose()))
+						fmt.Println(swift.DemangleBlob(o.dumpCategory(&cat, true, false)))
 					}
 				}
 			}
@@ -280,385 +556,1371 @@ func (o *ObjC) DumpCategory(pattern string) error {
 	return nil
 }
 
-// Dump outputs ObjC info from a MachO
-func (o *ObjC) Dump() error {
+// DumpImports outputs the computed header imports/forward declarations for ObjC classes matching a given pattern as JSON
+func (o *ObjC) DumpImports(pattern string) error {
+	re, err := o.compilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+
+	// scan DSC for Foundation/CoreFoundation classes and protocols
+	if err := o.scanFoundation(); err != nil {
+		return err
+	}
+
 	ms := []*macho.File{o.file}
 	if o.conf.Deps {
 		ms = append(ms, o.deps...)
 	}
+	out := make(map[string]Imports)
 	for _, m := range ms {
-		if o.conf.Verbose {
-			if info, err := m.GetObjCImageInfo(); err == nil {
-				fmt.Println(info.Flags)
-			} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
-			fmt.Println(m.GetObjCToc())
-		}
-		/* ObjC Protocols */
-		if protos, err := m.GetObjCProtocols(); err == nil {
-			slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			seen := make(map[uint64]bool)
-			for _, proto := range protos {
-				if _, ok := seen[proto.Ptr]; !ok { // prevent displaying duplicates
-					if o.conf.Verbose {
-						if o.conf.Color {
-							if o.conf.Addrs {
-								quick.Highlight(os.Stdout, swift.DemangleBlob(proto.WithAddrs()), "objc", "terminal256", o.conf.Theme)
-							} else {
-								quick.Highlight(os.Stdout, swift.DemangleBlob(proto.Verbose()), "objc", "terminal256", o.conf.Theme)
-							}
-							quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
-						} else {
-							if o.conf.Addrs {
-								fmt.Println(swift.DemangleBlob(proto.WithAddrs()))
-							} else {
-								fmt.Println(swift.DemangleBlob(proto.Verbose()))
-							}
-						}
-					} else {
-						if o.conf.Color {
-							quick.Highlight(os.Stdout, proto.String()+"\n", "objc", "terminal256", o.conf.Theme)
-						} else {
-							fmt.Println(proto.String())
-						}
-					}
-					seen[proto.Ptr] = true
-				}
-			}
-		} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		imps, err := o.processForwardDeclarations(m)
+		if err != nil {
 			return err
 		}
-		/* ObjC Classes */
-		if classes, err := m.GetObjCClasses(); err == nil {
-			slices.SortStableFunc(classes, func(a, b objc.Class) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			for _, class := range classes {
-				if o.conf.Verbose {
-					if o.conf.Color {
-						if o.conf.Addrs {
-							quick.Highlight(os.Stdout, swift.DemangleBlob(class.WithAddrs()), "objc", "terminal256", o.conf.Theme)
-						} else {
-							quick.Highlight(os.Stdout, swift.DemangleBlob(class.Verbose()), "objc", "terminal256", o.conf.Theme)
-						}
-						quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
-					} else {
-						if o.conf.Addrs {
-							fmt.Println(swift.DemangleBlob(class.WithAddrs()))
-						} else {
-							fmt.Println(swift.DemangleBlob(class.Verbose()))
-						}
-					}
-				} else {
-					if o.conf.Color {
-						quick.Highlight(os.Stdout, class.String()+"\n", "objc", "terminal256", o.conf.Theme)
-					} else {
-						fmt.Println(class.String())
-					}
-				}
+		for name, imp := range imps {
+			if re.MatchString(name) {
+				out[name] = imp
 			}
-		} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-			return err
 		}
-		/* ObjC Categories */
-		if cats, err := m.GetObjCCategories(); err == nil {
-			slices.SortStableFunc(cats, func(a, b objc.Category) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			for _, cat := range cats {
-				if o.conf.Verbose {
-					if o.conf.Color {
-						if o.conf.Addrs {
-							quick.Highlight(os.Stdout, swift.DemangleBlob(cat.WithAddrs()), "objc", "terminal256", o.conf.Theme)
-						} else {
-							quick.Highlight(os.Stdout, swift.DemangleBlob(cat.Verbose()), "objc", "terminal256", o.conf.Theme)
-						}
-						quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
-					} else {
-						if o.conf.Addrs {
-							fmt.Println(swift.DemangleBlob(cat.WithAddrs()))
-						} else {
-							fmt.Println(swift.DemangleBlob(cat.Verbose()))
-						}
-					}
-				} else {
-					if o.conf.Color {
-						quick.Highlight(os.Stdout, cat.String()+"\n", "objc", "terminal256", o.conf.Theme)
-					} else {
-						fmt.Println(cat.String())
-					}
-				}
+	}
+
+	dat, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal imports: %v", err)
+	}
+	if o.conf.Color {
+		quick.Highlight(os.Stdout, string(dat)+"\n", "json", "terminal256", o.conf.Theme)
+	} else {
+		fmt.Println(string(dat))
+	}
+	return nil
+}
+
+// Conformer represents an ObjC class that conforms to a protocol
+type Conformer struct {
+	Class    string `json:"class"`
+	Protocol string `json:"protocol"`
+	Image    string `json:"image,omitempty"`
+}
+
+// DumpConformers outputs the ObjC classes that conform to the protocol(s) matching a given name or pattern
+func (o *ObjC) DumpConformers(protocol string) error {
+	re, err := o.compilePattern(protocol)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	results := make([][]Conformer, len(ms)) // per image (so the output keeps the image order)
+	if err := o.forEachImage(len(ms), func(i int) error {
+		m := ms[i]
+		classes, err := m.GetObjCClasses()
+		if err != nil {
+			if o.noObjC(m, "classes", err) {
+				return nil
 			}
-		} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
 			return err
 		}
-		if o.conf.ObjcRefs {
-			if protRefs, err := m.GetObjCProtoReferences(); err == nil {
-				fmt.Printf("\n@protocol refs\n")
-				for off, prot := range protRefs {
-					fmt.Printf("0x%011x => 0x%011x: %s\n", off, prot.Ptr, prot.Name)
-				}
-			} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
-			if clsRefs, err := m.GetObjCClassReferences(); err == nil {
-				fmt.Printf("\n@class refs\n")
-				for off, cls := range clsRefs {
-					fmt.Printf("0x%011x => 0x%011x: %s\n", off, cls.ClassPtr, cls.Name)
-				}
-			} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
-			if supRefs, err := m.GetObjCSuperReferences(); err == nil {
-				fmt.Printf("\n@super refs\n")
-				for off, sup := range supRefs {
-					fmt.Printf("0x%011x => 0x%011x: %s\n", off, sup.ClassPtr, sup.Name)
-				}
-			} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
-			if selRefs, err := m.GetObjCSelectorReferences(); err == nil {
-				fmt.Printf("\n@selectors refs\n")
-				for off, sel := range selRefs {
-					fmt.Printf("0x%011x => 0x%011x: %s\n", off, sel.VMAddr, sel.Name)
-				}
-			} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
-			if o.conf.Verbose {
-				if classes, err := m.GetObjCClassNames(); err == nil {
-					fmt.Printf("\n@objc_classname\n")
-					for vmaddr, className := range classes {
-						fmt.Printf("0x%011x: %s\n", vmaddr, className)
-					}
-				} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-					return err
-				}
-				if methods, err := m.GetObjCMethodNames(); err == nil {
-					fmt.Printf("\n@objc_methname\n")
-					for vmaddr, method := range methods {
-						fmt.Printf("0x%011x: %s\n", vmaddr, method)
-					}
-				} else if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-					return err
+		slices.SortStableFunc(classes, func(a, b objc.Class) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		for _, class := range classes {
+			for _, prot := range class.Protocols {
+				if prot.Name == protocol || re.MatchString(prot.Name) {
+					results[i] = append(results[i], Conformer{
+						Class:    class.Name,
+						Protocol: prot.Name,
+						Image:    machoName(m),
+					})
 				}
 			}
 		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var conformers []Conformer
+	for _, res := range results {
+		conformers = append(conformers, res...)
+	}
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(conformers, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal conformers: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, c := range conformers {
+		if len(c.Image) > 0 {
+			fmt.Printf("%s <%s>\t(%s)\n", c.Class, c.Protocol, c.Image)
+		} else {
+			fmt.Printf("%s <%s>\n", c.Class, c.Protocol)
+		}
 	}
 	return nil
 }
 
-// Headers outputs ObjC class-dump headers from a MachO
-func (o *ObjC) Headers() error {
+// ClassCounts represents the number of ivars, methods, properties and protocols of an ObjC class
+type ClassCounts struct {
+	Class           string `json:"class"`
+	Ivars           int    `json:"ivars"`
+	InstanceMethods int    `json:"instance_methods"`
+	ClassMethods    int    `json:"class_methods"`
+	Properties      int    `json:"properties"`
+	Protocols       int    `json:"protocols"`
+	Image           string `json:"image,omitempty"`
+}
 
-	// scan DSC for Foundation/CoreFoundation classes and protocols
-	if err := o.scanFoundation(); err != nil {
-		return err
-	}
+var classCountsSorters = map[string]func(a, b ClassCounts) int{
+	"name":     func(a, b ClassCounts) int { return cmp.Compare(a.Class, b.Class) },
+	"ivars":    func(a, b ClassCounts) int { return cmp.Compare(b.Ivars, a.Ivars) },
+	"imethods": func(a, b ClassCounts) int { return cmp.Compare(b.InstanceMethods, a.InstanceMethods) },
+	"cmethods": func(a, b ClassCounts) int { return cmp.Compare(b.ClassMethods, a.ClassMethods) },
+	"props":    func(a, b ClassCounts) int { return cmp.Compare(b.Properties, a.Properties) },
+	"protos":   func(a, b ClassCounts) int { return cmp.Compare(b.Protocols, a.Protocols) },
+}
 
-	writeHeaders := func(m *macho.File) error {
-		var headers []string
-
-		if !m.HasObjC() {
-			return nil
-		}
-
-		if id := m.DylibID(); id != nil {
-			o.conf.Name = filepath.Base(id.Name)
+// DumpTable outputs a table of the ivar, method, property and protocol counts of the ObjC classes matching a given name or pattern
+func (o *ObjC) DumpTable(pattern string) error {
+	sortBy := o.conf.TableSort
+	if len(sortBy) == 0 {
+		sortBy = "name"
+	}
+	sorter, ok := classCountsSorters[sortBy]
+	if !ok {
+		return fmt.Errorf("invalid table sort column '%s' (must be one of: name, ivars, imethods, cmethods, props, protos)", sortBy)
+	}
+	re, err := o.compilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	var counts []ClassCounts
+	for _, m := range ms {
+		classes, err := m.GetObjCClasses()
+		if err != nil {
+			if o.noObjC(m, "classes", err) {
+				continue
+			}
+			return err
 		}
-		var buildVersions []string
-		if bvers := m.GetLoadsByName("LC_BUILD_VERSION"); len(bvers) > 0 {
-			for _, bv := range bvers {
-				buildVersions = append(buildVersions, bv.String())
+		for _, class := range classes {
+			if class.Name == pattern || re.MatchString(class.Name) {
+				counts = append(counts, ClassCounts{
+					Class:           class.Name,
+					Ivars:           len(class.Ivars),
+					InstanceMethods: len(class.InstanceMethods),
+					ClassMethods:    len(class.ClassMethods),
+					Properties:      len(class.Props),
+					Protocols:       len(class.Protocols),
+					Image:           machoName(m),
+				})
 			}
 		}
-		var sourceVersion string
-		if svers := m.GetLoadsByName("LC_SOURCE_VERSION"); len(svers) > 0 {
-			sourceVersion = svers[0].String()
+	}
+	slices.SortStableFunc(counts, func(a, b ClassCounts) int {
+		if c := sorter(a, b); c != 0 {
+			return c
 		}
-
-		imps, err := o.processForwardDeclarations(m)
+		return cmp.Compare(a.Class, b.Class)
+	})
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(counts, "", "  ")
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to marshal class counts: %v", err)
 		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	var tdata [][]string
+	for _, c := range counts {
+		tdata = append(tdata, []string{
+			c.Class,
+			strconv.Itoa(c.Ivars),
+			strconv.Itoa(c.InstanceMethods),
+			strconv.Itoa(c.ClassMethods),
+			strconv.Itoa(c.Properties),
+			strconv.Itoa(c.Protocols),
+		})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Class", "Ivars", "Instance Methods", "Class Methods", "Properties", "Protocols"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.AppendBulk(tdata)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+	return nil
+}
 
-		/* generate ObjC class headers */
+// IvarMatch represents an ObjC class ivar that matched an ivar query
+type IvarMatch struct {
+	Class string `json:"class"`
+	Ivar  string `json:"ivar"`
+	Type  string `json:"type"`
+	Image string `json:"image,omitempty"`
+}
+
+// ivarType returns the decoded type of an ivar
+func ivarType(ivar *objc.Ivar) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(ivar.Verbose(), ";"), ivar.Name))
+}
+
+// DumpClassesWithIvar outputs the ObjC classes with an ivar whose name or decoded type matches a given name or pattern
+func (o *ObjC) DumpClassesWithIvar(typeOrName string) error {
+	re, err := o.compilePattern(typeOrName)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	var matches []IvarMatch
+	for _, m := range ms {
 		classes, err := m.GetObjCClasses()
 		if err != nil {
-			if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
+			if o.noObjC(m, "classes", err) {
+				continue
 			}
+			return err
 		}
 		slices.SortStableFunc(classes, func(a, b objc.Class) int {
 			return cmp.Compare(a.Name, b.Name)
 		})
 		for _, class := range classes {
-			var props []string
-			var setters []string
-			for _, prop := range class.Props {
-				props = append(props, prop.Name)
-				setters = append(setters, "set"+strings.ToUpper(prop.Name[:1])+prop.Name[1:]+":")
-			}
-			slices.Sort(props)
-			slices.Sort(setters)
-			// remove ivars that are properties
-			class.Ivars = slices.DeleteFunc(class.Ivars, func(i objc.Ivar) bool {
-				// return slices.Contains(props, i.Name) || slices.Contains(props, strings.TrimPrefix(i.Name, "_")) TODO: use this instead
-				return slices.Contains(props, strings.TrimPrefix(i.Name, "_"))
-			})
-			// remove methods that are property getter/setter
-			class.InstanceMethods = slices.DeleteFunc(class.InstanceMethods, func(m objc.Method) bool {
-				return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
-			})
-			fname := filepath.Join(o.conf.Output, o.conf.Name, class.Name+".h")
-			if err := writeHeader(&headerInfo{
-				FileName:      fname,
-				IpswVersion:   o.conf.IpswVersion,
-				BuildVersions: buildVersions,
-				SourceVersion: sourceVersion,
-				Name:          class.Name,
-				Imports:       imps[class.Name],
-				Object:        swift.DemangleBlob(class.Verbose()),
-			}); err != nil {
-				return err
+			for _, ivar := range class.Ivars {
+				typ := ivarType(&ivar)
+				if ivar.Name == typeOrName || typ == typeOrName || re.MatchString(ivar.Name) || re.MatchString(typ) {
+					matches = append(matches, IvarMatch{
+						Class: class.Name,
+						Ivar:  ivar.Name,
+						Type:  typ,
+						Image: machoName(m),
+					})
+				}
 			}
-			headers = append(headers, filepath.Base(fname))
 		}
-
-		/* generate ObjC protocol headers */
-		protos, err := m.GetObjCProtocols()
+	}
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(matches, "", "  ")
 		if err != nil {
-			if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
+			return fmt.Errorf("failed to marshal ivar matches: %v", err)
 		}
-		slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
-			return cmp.Compare(a.Name, b.Name)
-		})
-		seen := make(map[uint64]bool)
-		for _, proto := range protos {
-			if _, found := slices.BinarySearch(o.foundation["protocols"], proto.Name); found {
-				continue // skip Foundation protocols
-			}
-			if _, ok := seen[proto.Ptr]; !ok { // prevent displaying duplicates
-				var props []string
-				var setters []string
-				for _, prop := range proto.InstanceProperties {
-					props = append(props, prop.Name)
-					setters = append(setters, "set"+strings.ToUpper(prop.Name[:1])+prop.Name[1:]+":")
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, m := range matches {
+		if len(m.Image) > 0 {
+			fmt.Printf("%s { %s %s; }\t(%s)\n", m.Class, m.Type, m.Ivar, m.Image)
+		} else {
+			fmt.Printf("%s { %s %s; }\n", m.Class, m.Type, m.Ivar)
+		}
+	}
+	return nil
+}
+
+// SelectorMatch represents an ObjC class, category or protocol method that matched a selector query
+type SelectorMatch struct {
+	Owner     string `json:"owner"` // e.g. Class, Class(Category) or Protocol
+	Kind      string `json:"kind"`  // class, category or protocol
+	Selector  string `json:"selector"`
+	IsClass   bool   `json:"is_class_method,omitempty"`
+	Optional  bool   `json:"optional,omitempty"` // an @optional protocol method
+	Signature string `json:"signature"`
+	Image     string `json:"image,omitempty"`
+}
+
+func (m SelectorMatch) String() string {
+	var opt string
+	if m.Optional {
+		opt = " @optional"
+	}
+	owner := m.Owner
+	if m.Kind == "protocol" {
+		owner = "<" + owner + ">"
+	}
+	if len(m.Image) > 0 {
+		return fmt.Sprintf("%s%s %s\t(%s)", owner, opt, m.Signature, m.Image)
+	}
+	return fmt.Sprintf("%s%s %s", owner, opt, m.Signature)
+}
+
+// FindSelector returns the ObjC class, category and protocol methods whose selector matches a given name or pattern
+func (o *ObjC) FindSelector(pattern string) ([]SelectorMatch, error) {
+	re, err := o.compilePattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	results := make([][]SelectorMatch, len(ms)) // per image (so the matches keep the image order)
+	if err := o.forEachImage(len(ms), func(i int) error {
+		m := ms[i]
+		add := func(owner, kind string, methods []objc.Method, isClass, optional bool) {
+			for _, meth := range methods {
+				meth.Name = o.methodName(&meth)
+				if meth.Name != pattern && !re.MatchString(meth.Name) {
+					continue
 				}
-				slices.Sort(props)
-				slices.Sort(setters)
-				// remove methods that are property getter/setter
-				proto.InstanceMethods = slices.DeleteFunc(proto.InstanceMethods, func(m objc.Method) bool {
-					return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
-				})
-				proto.OptionalInstanceMethods = slices.DeleteFunc(proto.OptionalInstanceMethods, func(m objc.Method) bool {
-					return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
-				})
-				fname := filepath.Join(o.conf.Output, o.conf.Name, proto.Name+"-Protocol.h")
-				if err := writeHeader(&headerInfo{
-					FileName:      fname,
-					IpswVersion:   o.conf.IpswVersion,
-					BuildVersions: buildVersions,
-					SourceVersion: sourceVersion,
-					Name:          proto.Name + "_Protocol",
-					Imports:       imps[proto.Name],
-					Object:        swift.DemangleBlob(proto.Verbose()),
-				}); err != nil {
-					return err
+				prefix := "-"
+				if isClass {
+					prefix = "+"
 				}
-				headers = append(headers, filepath.Base(fname))
-				seen[proto.Ptr] = true
+				results[i] = append(results[i], SelectorMatch{
+					Owner:     owner,
+					Kind:      kind,
+					Selector:  meth.Name,
+					IsClass:   isClass,
+					Optional:  optional,
+					Signature: prefix + " " + o.methodDecl(&meth, isClass),
+					Image:     machoName(m),
+				})
 			}
 		}
-
-		/* generate ObjC category headers */
+		classes, err := m.GetObjCClasses()
+		if err != nil && !o.noObjC(m, "classes", err) {
+			return err
+		}
+		slices.SortStableFunc(classes, func(a, b objc.Class) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		for _, class := range classes {
+			add(class.Name, "class", class.ClassMethods, true, false)
+			add(class.Name, "class", class.InstanceMethods, false, false)
+		}
 		cats, err := m.GetObjCCategories()
-		if err != nil {
-			if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-				return err
-			}
+		if err != nil && !o.noObjC(m, "categories", err) {
+			return err
 		}
+		o.resolveCategoryClasses(m, cats)
 		slices.SortStableFunc(cats, func(a, b objc.Category) int {
 			return cmp.Compare(a.Name, b.Name)
 		})
 		for _, cat := range cats {
-			fname := filepath.Join(o.conf.Output, o.conf.Name, cat.Name+".h")
-			if cat.Class != nil && cat.Class.Name != "" {
-				fname = filepath.Join(o.conf.Output, o.conf.Name, cat.Class.Name+"+"+cat.Name+".h")
+			owner := cat.Name
+			if cat.Class != nil && len(cat.Class.Name) > 0 {
+				owner = cat.Class.Name + "(" + cat.Name + ")"
 			}
-			if err := writeHeader(&headerInfo{
-				FileName:      fname,
-				IpswVersion:   o.conf.IpswVersion,
-				BuildVersions: buildVersions,
-				SourceVersion: sourceVersion,
-				Name:          cat.Class.Name + "_" + cat.Name,
-				Imports:       imps[cat.Name],
-				Object:        swift.DemangleBlob(cat.Verbose()),
-			}); err != nil {
-				return err
+			add(owner, "category", cat.ClassMethods, true, false)
+			add(owner, "category", cat.InstanceMethods, false, false)
+		}
+		protos, err := m.GetObjCProtocols()
+		if err != nil && !o.noObjC(m, "protocols", err) {
+			return err
+		}
+		slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		seen := make(map[uint64]bool)
+		for _, proto := range protos {
+			if seen[proto.Ptr] { // prevent displaying duplicates
+				continue
 			}
-			headers = append(headers, filepath.Base(fname))
+			seen[proto.Ptr] = true
+			add(proto.Name, "protocol", proto.ClassMethods, true, false)
+			add(proto.Name, "protocol", proto.InstanceMethods, false, false)
+			add(proto.Name, "protocol", proto.OptionalClassMethods, true, true)
+			add(proto.Name, "protocol", proto.OptionalInstanceMethods, false, true)
 		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	var matches []SelectorMatch
+	for _, res := range results {
+		matches = append(matches, res...)
+	}
+	return matches, nil
+}
 
-		/* generate umbrella header */
-		if len(headers) > 0 {
-			var umbrella string
-			if slices.Contains(headers, o.conf.Name+".h") {
-				umbrella = o.conf.Name + "-Umbrella"
-			} else {
-				umbrella = o.conf.Name
-			}
+// DumpSelector outputs the ObjC classes, categories and protocols that declare a method matching a given selector or pattern
+func (o *ObjC) DumpSelector(pattern string) error {
+	matches, err := o.FindSelector(pattern)
+	if err != nil {
+		return err
+	}
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal selector matches: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, m := range matches {
+		fmt.Println(m)
+	}
+	return nil
+}
 
-			for i, header := range headers {
-				headers[i] = "#import \"" + header + "\""
-			}
+// MethodOwner represents the ObjC method whose IMP contains an address
+type MethodOwner struct {
+	Name     string `json:"name"` // e.g. -[Class selector]
+	Class    string `json:"class"`
+	Selector string `json:"selector"`
+	IsClass  bool   `json:"is_class_method,omitempty"`
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"`
+	Image    string `json:"image,omitempty"`
+}
 
-			fname := filepath.Join(o.conf.Output, o.conf.Name, umbrella+".h")
-			if err := writeHeader(&headerInfo{
-				FileName:      fname,
-				IpswVersion:   o.conf.IpswVersion,
-				BuildVersions: buildVersions,
-				SourceVersion: sourceVersion,
-				IsUmbrella:    true,
-				Name:          strings.ReplaceAll(umbrella, "-", "_"),
-				Object:        strings.Join(headers, "\n") + "\n",
-			}); err != nil {
-				return err
-			}
+// ClassForAddress returns the ObjC class (or category) method whose IMP contains the given address
+func (o *ObjC) ClassForAddress(addr uint64) (*MethodOwner, error) {
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	// find the image with the function containing addr (parsing each image's function starts concurrently)
+	found := -1
+	var fn types.Function
+	if err := o.forEachImage(len(ms), func(i int) error {
+		f, err := ms[i].GetFunctionForVMAddr(addr)
+		if err != nil {
+			return nil // not in this image
+		}
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if found < 0 || i < found { // prefer the first image (i.e. the MachO over its deps)
+			found, fn = i, f
 		}
-
 		return nil
+	}); err != nil {
+		return nil, err
 	}
-
-	if len(o.deps) > 0 {
-		for _, m := range o.deps {
-			if err := writeHeaders(m); err != nil {
-				return err
+	if found < 0 {
+		return nil, fmt.Errorf("%#x is not in any known function", addr)
+	}
+	m := ms[found]
+	owner := func(class string, methods []objc.Method, isClass bool) *MethodOwner {
+		for _, meth := range methods {
+			if o.resolveImp(m, meth.ImpVMAddr) != fn.StartAddr {
+				continue
+			}
+			prefix := "-"
+			if isClass {
+				prefix = "+"
 			}
+			return &MethodOwner{
+				Name:     fmt.Sprintf("%s[%s %s]", prefix, class, meth.Name),
+				Class:    class,
+				Selector: meth.Name,
+				IsClass:  isClass,
+				Start:    fn.StartAddr,
+				End:      fn.EndAddr,
+				Image:    machoName(m),
+			}
+		}
+		return nil
+	}
+	classes, err := m.GetObjCClasses()
+	if err != nil && !o.noObjC(m, "classes", err) {
+		return nil, err
+	}
+	for _, class := range classes {
+		if mo := owner(class.Name, class.InstanceMethods, false); mo != nil {
+			return mo, nil
+		}
+		if mo := owner(class.Name, class.ClassMethods, true); mo != nil {
+			return mo, nil
 		}
 	}
-
-	return writeHeaders(o.file)
+	cats, err := m.GetObjCCategories()
+	if err != nil && !o.noObjC(m, "categories", err) {
+		return nil, err
+	}
+	for _, cat := range cats {
+		name := cat.Name
+		if cat.Class != nil {
+			name = cat.Class.Name + "(" + cat.Name + ")"
+		}
+		if mo := owner(name, cat.InstanceMethods, false); mo != nil {
+			return mo, nil
+		}
+		if mo := owner(name, cat.ClassMethods, true); mo != nil {
+			return mo, nil
+		}
+	}
+	return nil, fmt.Errorf("%#x is in function %#x-%#x which is not an ObjC method IMP", addr, fn.StartAddr, fn.EndAddr)
 }
 
-type XCFrameworkAvailableLibrary struct {
-	BinaryPath               string   `plist:"BinaryPath"`
-	LibraryIdentifier        string   `plist:"LibraryIdentifier"`
-	LibraryPath              string   `plist:"libraryPath"`
-	SupportedArchitectures   []string `plist:"SupportedArchitectures"`
-	SupportedPlatform        string   `plist:"SupportedPlatform"`
-	SupportedPlatformVariant string   `plist:"SupportedPlatformVariant,omitempty"`
+// DumpMethodForAddress outputs the ObjC method whose IMP contains the given address
+func (o *ObjC) DumpMethodForAddress(addr uint64) error {
+	mo, err := o.ClassForAddress(addr)
+	if err != nil {
+		return err
+	}
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(mo, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal method owner: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	if off := addr - mo.Start; off > 0 {
+		fmt.Printf("%#x: %s + %d (start: %#x, end: %#x)\n", addr, mo.Name, off, mo.Start, mo.End)
+	} else {
+		fmt.Printf("%#x: %s (start: %#x, end: %#x)\n", addr, mo.Name, mo.Start, mo.End)
+	}
+	return nil
 }
 
-type XCFrameworkInfoPlist struct {
-	AvailableLibraries       []XCFrameworkAvailableLibrary `plist:"AvailableLibraries"`
-	CFBundlePackageType      string                        `plist:"CFBundlePackageType"`
-	XCFrameworkFormatVersion string                        `plist:"XCFrameworkFormatVersion"`
+// InventoryItem represents an ObjC class, protocol or category in an image
+type InventoryItem struct {
+	Image string `json:"image"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// DumpInventory streams every ObjC class, protocol and category as JSON Lines (every image in the DSC if there is one)
+func (o *ObjC) DumpInventory() error {
+	var mu sync.Mutex // keep each image's lines together
+	enc := json.NewEncoder(os.Stdout)
+
+	dump := func(image string, m *macho.File) error {
+		if !m.HasObjC() {
+			return nil
+		}
+		var items []InventoryItem
+		classes, err := m.GetObjCClasses()
+		if err != nil && !o.noObjC(m, "classes", err) {
+			return fmt.Errorf("failed to get classes for %s: %v", image, err)
+		}
+		for _, class := range classes {
+			items = append(items, InventoryItem{Image: image, Kind: "class", Name: class.Name})
+		}
+		protos, err := m.GetObjCProtocols()
+		if err != nil && !o.noObjC(m, "protocols", err) {
+			return fmt.Errorf("failed to get protocols for %s: %v", image, err)
+		}
+		for _, proto := range protos {
+			items = append(items, InventoryItem{Image: image, Kind: "protocol", Name: proto.Name})
+		}
+		cats, err := m.GetObjCCategories()
+		if err != nil && !o.noObjC(m, "categories", err) {
+			return fmt.Errorf("failed to get categories for %s: %v", image, err)
+		}
+		for _, cat := range cats {
+			name := cat.Name
+			if cat.Class != nil {
+				name = cat.Class.Name + "(" + cat.Name + ")"
+			}
+			items = append(items, InventoryItem{Image: image, Kind: "category", Name: name})
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if o.cache == nil {
+		image := machoName(o.file)
+		if len(image) == 0 {
+			image = o.conf.Name
+		}
+		return dump(image, o.file)
+	}
+
+	return o.forEachImage(len(o.cache.Images), func(i int) error {
+		img := o.cache.Images[i]
+		m, err := img.GetMacho()
+		if err != nil {
+			return fmt.Errorf("failed to parse image %s: %v", img.Name, err)
+		}
+		if err := dump(img.Name, m); err != nil {
+			return err
+		}
+		img.Free() // keep memory bounded for cache-wide inventories
+		return nil
+	})
+}
+
+// RuntimeInfo returns the ObjC runtime metadata (image info) and Swift ABI/metadata versions of the MachO (and its deps)
+func (o *ObjC) RuntimeInfo() ([]ImageInfo, error) {
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	var infos []ImageInfo
+	for _, m := range ms {
+		info, err := m.GetObjCImageInfo()
+		if err != nil {
+			if o.noObjC(m, "image info", err) {
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, newImageInfo(m, info))
+	}
+	return infos, nil
+}
+
+// DumpImageInfo outputs the decoded ObjC image info from a MachO
+func (o *ObjC) DumpImageInfo() error {
+	infos, err := o.RuntimeInfo()
+	if err != nil {
+		return err
+	}
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal image info: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, info := range infos {
+		fmt.Println(info)
+	}
+	return nil
+}
+
+// Dump outputs ObjC info from a MachO
+func (o *ObjC) Dump() error {
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	for _, m := range ms {
+		if o.conf.Verbose {
+			if info, err := m.GetObjCImageInfo(); err == nil {
+				fmt.Println(newImageInfo(m, info))
+			} else if !o.noObjC(m, "image info", err) {
+				return err
+			}
+			fmt.Println(m.GetObjCToc())
+		}
+		/* ObjC Protocols */
+		if protos, err := m.GetObjCProtocols(); err == nil {
+			slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
+				return cmp.Compare(a.Name, b.Name)
+			})
+			var aliases map[uint64][]string
+			if o.conf.DedupProtocols {
+				protos, aliases = o.dedupProtocols(protos)
+			}
+			seen := make(map[uint64]bool)
+			for _, proto := range protos {
+				if _, ok := seen[proto.Ptr]; !ok { // prevent displaying duplicates
+					if names := aliases[proto.Ptr]; len(names) > 0 {
+						note := fmt.Sprintf("// identical to %s: %s", proto.Name, strings.Join(names, ", "))
+						if o.conf.Color {
+							quick.Highlight(os.Stdout, note+"\n", "objc", "terminal256", o.conf.Theme)
+						} else {
+							fmt.Println(note)
+						}
+					}
+					if o.conf.Verbose {
+						if o.conf.Color {
+							if o.conf.Addrs {
+								quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpProtocol(&proto, true, true)), "objc", "terminal256", o.conf.Theme)
+							} else {
+								quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpProtocol(&proto, true, false)), "objc", "terminal256", o.conf.Theme)
+							}
+							quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
+						} else {
+							if o.conf.Addrs {
+								fmt.Println(swift.DemangleBlob(o.dumpProtocol(&proto, true, true)))
+							} else {
+								fmt.Println(swift.DemangleBlob(o.dumpProtocol(&proto, true, false)))
+							}
+						}
+					} else {
+						if o.conf.Color {
+							quick.Highlight(os.Stdout, o.dumpProtocol(&proto, false, false)+"\n", "objc", "terminal256", o.conf.Theme)
+						} else {
+							fmt.Println(o.dumpProtocol(&proto, false, false))
+						}
+					}
+					if o.conf.ShowAdopters {
+						o.printAdopters(proto.Name)
+					}
+					seen[proto.Ptr] = true
+				}
+			}
+		} else if !o.noObjC(m, "protocols", err) {
+			return err
+		}
+		/* ObjC Classes */
+		if classes, err := m.GetObjCClasses(); err == nil {
+			slices.SortStableFunc(classes, func(a, b objc.Class) int {
+				return cmp.Compare(a.Name, b.Name)
+			})
+			for _, class := range classes {
+				if o.skipClass(class.Name) || o.tooFewMethods(&class) || o.skipEmpty(&class) || o.skipUnexported(m, class.Name) {
+					continue
+				}
+				if o.conf.Verbose {
+					if o.conf.Addrs {
+						o.resolveClassImps(m, &class)
+					}
+					if o.conf.Color {
+						if o.conf.Addrs {
+							quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpClass(&class, true, true)), "objc", "terminal256", o.conf.Theme)
+						} else {
+							quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpClass(&class, true, false)), "objc", "terminal256", o.conf.Theme)
+						}
+						quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
+					} else {
+						if o.conf.Addrs {
+							fmt.Println(swift.DemangleBlob(o.dumpClass(&class, true, true)))
+						} else {
+							fmt.Println(swift.DemangleBlob(o.dumpClass(&class, true, false)))
+						}
+					}
+				} else {
+					if o.conf.Color {
+						quick.Highlight(os.Stdout, o.dumpClass(&class, false, false)+"\n", "objc", "terminal256", o.conf.Theme)
+					} else {
+						fmt.Println(o.dumpClass(&class, false, false))
+					}
+				}
+				if o.conf.Metaclass {
+					o.printMetaclass(m, &class, o.conf.Verbose, o.conf.Verbose && o.conf.Addrs)
+				}
+			}
+		} else if !o.noObjC(m, "classes", err) {
+			return err
+		}
+		/* ObjC Categories */
+		if cats, err := m.GetObjCCategories(); err == nil {
+			o.resolveCategoryClasses(m, cats)
+			slices.SortStableFunc(cats, func(a, b objc.Category) int {
+				return cmp.Compare(a.Name, b.Name)
+			})
+			for _, cat := range cats {
+				if cat.Class != nil && o.skipSwiftSynthetic(cat.Class.Name) {
+					continue
+				}
+				if o.conf.Verbose {
+					if o.conf.Addrs {
+						o.resolveCategoryImps(m, &cat)
+					}
+					if o.conf.Color {
+						if o.conf.Addrs {
+							quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpCategory(&cat, true, true)), "objc", "terminal256", o.conf.Theme)
+						} else {
+							quick.Highlight(os.Stdout, swift.DemangleBlob(o.dumpCategory(&cat, true, false)), "objc", "terminal256", o.conf.Theme)
+						}
+						quick.Highlight(os.Stdout, "\n/****************************************/\n\n", "objc", "terminal256", o.conf.Theme)
+					} else {
+						if o.conf.Addrs {
+							fmt.Println(swift.DemangleBlob(o.dumpCategory(&cat, true, true)))
+						} else {
+							fmt.Println(swift.DemangleBlob(o.dumpCategory(&cat, true, false)))
+						}
+					}
+				} else {
+					if o.conf.Color {
+						quick.Highlight(os.Stdout, o.dumpCategory(&cat, false, false)+"\n", "objc", "terminal256", o.conf.Theme)
+					} else {
+						fmt.Println(o.dumpCategory(&cat, false, false))
+					}
+				}
+			}
+		} else if !o.noObjC(m, "categories", err) {
+			return err
+		}
+		if o.conf.ObjcRefs {
+			names := o.refNames(m)
+			if protRefs, err := m.GetObjCProtoReferences(); err == nil {
+				fmt.Printf("\n@protocol refs\n")
+				for off, prot := range protRefs {
+					printRef(off, prot.Ptr, refName(names, prot.Ptr, prot.Name))
+				}
+			} else if !o.noObjC(m, "protocol refs", err) {
+				return err
+			}
+			if clsRefs, err := m.GetObjCClassReferences(); err == nil {
+				fmt.Printf("\n@class refs\n")
+				for off, cls := range clsRefs {
+					printRef(off, cls.ClassPtr, refName(names, cls.ClassPtr, cls.Name))
+				}
+			} else if !o.noObjC(m, "class refs", err) {
+				return err
+			}
+			if supRefs, err := m.GetObjCSuperReferences(); err == nil {
+				fmt.Printf("\n@super refs\n")
+				for off, sup := range supRefs {
+					printRef(off, sup.ClassPtr, refName(names, sup.ClassPtr, sup.Name))
+				}
+			} else if !o.noObjC(m, "superclass refs", err) {
+				return err
+			}
+			if selRefs, err := m.GetObjCSelectorReferences(); err == nil {
+				fmt.Printf("\n@selectors refs\n")
+				for off, sel := range selRefs {
+					printRef(off, sel.VMAddr, sel.Name)
+				}
+			} else if !o.noObjC(m, "selector refs", err) {
+				return err
+			}
+			if o.conf.Verbose {
+				if classes, err := m.GetObjCClassNames(); err == nil {
+					fmt.Printf("\n@objc_classname\n")
+					for vmaddr, className := range classes {
+						fmt.Printf("0x%011x: %s\n", vmaddr, className)
+					}
+				} else if !o.noObjC(m, "class names", err) {
+					return err
+				}
+				if methods, err := m.GetObjCMethodNames(); err == nil {
+					fmt.Printf("\n@objc_methname\n")
+					for vmaddr, method := range methods {
+						fmt.Printf("0x%011x: %s\n", vmaddr, method)
+					}
+				} else if !o.noObjC(m, "method names", err) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Headers outputs ObjC class-dump headers from a MachO
+func (o *ObjC) Headers() error {
+
+	if IsTarGz(o.conf.Output) && o.archive == nil {
+		return o.headersArchive()
+	}
+
+	// scan DSC for Foundation/CoreFoundation classes and protocols
+	if err := o.scanFoundation(); err != nil {
+		return err
+	}
+
+	if o.conf.ImportsNotForwards && o.outHeaders == nil {
+		o.outHeaders = o.outputHeaders()
+	}
+
+	writeHeaders := func(m *macho.File) error {
+		var headers []string
+
+		if !m.HasObjC() {
+			return nil
+		}
+
+		if id := m.DylibID(); id != nil {
+			o.conf.Name = frameworkName(id.Name)
+		}
+		if m.Symtab != nil {
+			o.hidden = hiddenIvars(m.Symtab.Syms)
+		}
+		defer func() { o.hidden = nil }()
+		var buildVersions []string
+		if bvers := m.GetLoadsByName("LC_BUILD_VERSION"); len(bvers) > 0 {
+			for _, bv := range bvers {
+				buildVersions = append(buildVersions, bv.String())
+			}
+		}
+		var sourceVersion string
+		if svers := m.GetLoadsByName("LC_SOURCE_VERSION"); len(svers) > 0 {
+			sourceVersion = svers[0].String()
+		}
+
+		var imps map[string]Imports
+		if !o.conf.UmbrellaOnly { // the umbrella header only imports the headers
+			var err error
+			if imps, err = o.processForwardDeclarations(m); err != nil {
+				return err
+			}
+		}
+
+		/* generate ObjC class headers */
+		classes, err := m.GetObjCClasses()
+		if err != nil {
+			if !o.noObjC(m, "classes", err) {
+				return err
+			}
+		}
+		slices.SortStableFunc(classes, func(a, b objc.Class) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		classes = o.allowedClasses(classes)
+		private := make(map[string]*objc.Category) // class -> its split private members (for SplitPrivate)
+		for _, class := range classes {
+			if o.skipClass(class.Name) || o.skipEmpty(&class) || o.skipUnexported(m, class.Name) {
+				continue
+			}
+			var props []string
+			var setters []string
+			for _, prop := range class.Props {
+				props = append(props, prop.Name)
+				setters = append(setters, "set"+strings.ToUpper(prop.Name[:1])+prop.Name[1:]+":")
+			}
+			slices.Sort(props)
+			slices.Sort(setters)
+			// remove ivars that are properties
+			class.Ivars = slices.DeleteFunc(class.Ivars, func(i objc.Ivar) bool {
+				// return slices.Contains(props, i.Name) || slices.Contains(props, strings.TrimPrefix(i.Name, "_")) TODO: use this instead
+				return slices.Contains(props, strings.TrimPrefix(i.Name, "_"))
+			})
+			// remove methods that are property getter/setter
+			class.InstanceMethods = slices.DeleteFunc(class.InstanceMethods, func(m objc.Method) bool {
+				return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
+			})
+			impl := class // the stubs implement the split private members too
+			impl.ClassMethods, impl.InstanceMethods = slices.Clone(class.ClassMethods), slices.Clone(class.InstanceMethods)
+			if o.conf.SplitPrivate {
+				if ext := splitPrivateMembers(&class); ext != nil {
+					private[class.Name] = ext
+				}
+			}
+			fname, err := o.headerFileName(headerFileInfo{Name: class.Name, Kind: "class", Class: class.Name})
+			if err != nil {
+				return err
+			}
+			fname = filepath.Join(o.conf.Output, o.conf.Name, fname)
+			if o.conf.UmbrellaOnly {
+				headers = append(headers, filepath.Base(fname))
+				continue
+			}
+			if err := o.writeHeader(&headerInfo{
+				FileName:      fname,
+				IpswVersion:   o.conf.IpswVersion,
+				BuildVersions: buildVersions,
+				SourceVersion: sourceVersion,
+				Name:          class.Name,
+				Imports:       imps[class.Name],
+				Object:        swift.DemangleBlob(o.dumpClass(&class, true, false)),
+				Aliases:       o.swiftAliases(class.Name),
+			}); err != nil {
+				return err
+			}
+			if o.conf.EmitImpl {
+				if err := o.writeImplementation(fname, &impl); err != nil {
+					return err
+				}
+			}
+			headers = append(headers, filepath.Base(fname))
+		}
+
+		/* generate ObjC protocol headers */
+		protos, err := m.GetObjCProtocols()
+		if err != nil {
+			if !o.noObjC(m, "protocols", err) {
+				return err
+			}
+		}
+		slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		protos = o.allowedProtocols(protos, classes)
+		seen := make(map[uint64]bool)
+		for _, proto := range protos {
+			if _, found := slices.BinarySearch(o.foundation["protocols"], proto.Name); found {
+				continue // skip Foundation protocols
+			}
+			if _, ok := seen[proto.Ptr]; !ok { // prevent displaying duplicates
+				var props []string
+				var setters []string
+				for _, prop := range proto.InstanceProperties {
+					props = append(props, prop.Name)
+					setters = append(setters, "set"+strings.ToUpper(prop.Name[:1])+prop.Name[1:]+":")
+				}
+				slices.Sort(props)
+				slices.Sort(setters)
+				// remove methods that are property getter/setter
+				proto.InstanceMethods = slices.DeleteFunc(proto.InstanceMethods, func(m objc.Method) bool {
+					return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
+				})
+				proto.OptionalInstanceMethods = slices.DeleteFunc(proto.OptionalInstanceMethods, func(m objc.Method) bool {
+					return slices.Contains(props, m.Name) || slices.Contains(setters, m.Name)
+				})
+				fname, err := o.headerFileName(headerFileInfo{Name: proto.Name, Kind: "protocol"})
+				if err != nil {
+					return err
+				}
+				fname = filepath.Join(o.conf.Output, o.conf.Name, fname)
+				seen[proto.Ptr] = true
+				headers = append(headers, filepath.Base(fname))
+				if o.conf.UmbrellaOnly {
+					continue
+				}
+				if err := o.writeHeader(&headerInfo{
+					FileName:      fname,
+					IpswVersion:   o.conf.IpswVersion,
+					BuildVersions: buildVersions,
+					SourceVersion: sourceVersion,
+					Name:          proto.Name + "_Protocol",
+					Imports:       imps[proto.Name+"-Protocol"],
+					Object:        swift.DemangleBlob(o.dumpProtocol(&proto, true, false)),
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		/* generate ObjC category headers */
+		cats, err := m.GetObjCCategories()
+		if err != nil {
+			if !o.noObjC(m, "categories", err) {
+				return err
+			}
+		}
+		o.resolveCategoryClasses(m, cats)
+		cats = mergePrivateExtensions(o.allowedCategories(cats), private)
+		slices.SortStableFunc(cats, func(a, b objc.Category) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		for _, cat := range cats {
+			if cat.Class != nil && o.skipSwiftSynthetic(cat.Class.Name) {
+				continue
+			}
+			info := categoryHeaderFileInfo(cat)
+			if info.Kind == "extension" && len(info.Class) == 0 {
+				log.Warnf("skipping class extension with unknown class")
+				continue
+			}
+			fname, err := o.headerFileName(info)
+			if err != nil {
+				return err
+			}
+			fname = filepath.Join(o.conf.Output, o.conf.Name, fname)
+			if o.conf.UmbrellaOnly {
+				headers = append(headers, filepath.Base(fname))
+				continue
+			}
+			guard := info.Class + "_" + info.Category
+			imports := imps[cat.Name]
+			if info.Kind == "extension" {
+				guard = info.Class + "_Private"
+				imports = Imports{}
+				if slices.ContainsFunc(classes, func(c objc.Class) bool { return c.Name == info.Class }) {
+					imports.Locals = []string{o.localHeader("class", info.Class)} // an extension needs the class's @interface
+				}
+			}
+			if err := o.writeHeader(&headerInfo{
+				FileName:      fname,
+				IpswVersion:   o.conf.IpswVersion,
+				BuildVersions: buildVersions,
+				SourceVersion: sourceVersion,
+				Name:          guard,
+				Imports:       imports,
+				Object:        swift.DemangleBlob(o.dumpCategory(&cat, true, false)),
+			}); err != nil {
+				return err
+			}
+			headers = append(headers, filepath.Base(fname))
+		}
+
+		/* generate umbrella header */
+		if len(headers) > 0 {
+			var umbrella string
+			if slices.Contains(headers, o.conf.Name+o.headerExt()) {
+				umbrella = o.conf.Name + "-Umbrella"
+			} else {
+				umbrella = o.conf.Name
+			}
+
+			fname := filepath.Join(o.conf.Output, o.conf.Name, umbrella+o.headerExt())
+			if err := o.writeHeader(&headerInfo{
+				FileName:      fname,
+				IpswVersion:   o.conf.IpswVersion,
+				BuildVersions: buildVersions,
+				SourceVersion: sourceVersion,
+				IsUmbrella:    true,
+				Name:          strings.ReplaceAll(umbrella, "-", "_"),
+				Object:        umbrellaImports(headers),
+			}); err != nil {
+				return err
+			}
+			o.umbrellas = append(o.umbrellas, fname)
+
+			if o.conf.BridgingHeader {
+				bridging := o.conf.Name + "-Bridging-Header"
+				if err := o.writeHeader(&headerInfo{
+					FileName:      filepath.Join(o.conf.Output, o.conf.Name, bridging+o.headerExt()),
+					IpswVersion:   o.conf.IpswVersion,
+					BuildVersions: buildVersions,
+					SourceVersion: sourceVersion,
+					IsUmbrella:    true,
+					Name:          strings.ReplaceAll(bridging, "-", "_"),
+					Object:        "#import \"" + umbrella + o.headerExt() + "\"\n",
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if len(o.conf.Baseline) > 0 {
+		o.changes = &headerChanges{written: make(map[string]bool), folders: make(map[string]bool)}
+	}
+
+	if len(o.deps) > 0 {
+		for _, m := range o.deps {
+			if err := writeHeaders(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeHeaders(o.file); err != nil {
+		return err
+	}
+
+	if o.conf.Tags {
+		if err := o.writeTags(); err != nil {
+			return err
+		}
+	}
+
+	if o.changes != nil {
+		return o.writeChanges()
+	}
+
+	return nil
+}
+
+// hiddenIvars returns the "<class>.<ivar>" names of the ivars with a hidden (local) offset symbol, i.e. @private or @package
+// NOTE: ObjC ivar metadata has no access control, but clang gives the offset symbols of @private and @package
+// ivars (of non-hidden classes) hidden visibility, so they are the only ones that can be told apart from the
+// default @protected (and only if the local symbols weren't stripped), @public ones are external like @protected ones
+func hiddenIvars(syms []macho.Symbol) map[string]bool {
+	hiddenClasses := make(map[string]bool)
+	for _, sym := range syms {
+		if class, ok := strings.CutPrefix(sym.Name, "_OBJC_CLASS_$_"); ok && !sym.Type.IsDebugSym() {
+			hiddenClasses[class] = !sym.Type.IsExternalSym()
+		}
+	}
+	hidden := make(map[string]bool)
+	for _, sym := range syms {
+		ivar, ok := strings.CutPrefix(sym.Name, "_OBJC_IVAR_$_")
+		if !ok || sym.Type.IsDebugSym() || sym.Type.IsUndefinedSym() || sym.Type.IsExternalSym() {
+			continue
+		}
+		if class, _, ok := strings.Cut(ivar, "."); ok && !hiddenClasses[class] {
+			hidden[ivar] = true
+		}
+	}
+	return hidden
+}
+
+// umbrellaImports returns the umbrella header's #import lines (once per header, in generation order)
+// NOTE: protocols with different pointers can share a name (e.g. weak/merged protocols) and generate the same header
+func umbrellaImports(headers []string) string {
+	var out strings.Builder
+	seen := make(map[string]bool)
+	for _, header := range headers {
+		if seen[header] {
+			continue
+		}
+		seen[header] = true
+		out.WriteString("#import \"" + header + "\"\n")
+	}
+	return out.String()
+}
+
+// headerChanges tracks the generated headers that differ from a baseline header folder
+type headerChanges struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+
+	written map[string]bool // all generated headers (relative to the output folder)
+	folders map[string]bool // all generated header folders (relative to the output folder)
+}
+
+// hashHeader returns the hash of a header's contents ignoring the version-bearing banner and line endings
+func hashHeader(data string) [sha256.Size]byte {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for strings.HasPrefix(data, "//") {
+		if _, rest, ok := strings.Cut(data, "\n"); ok {
+			data = rest
+		} else {
+			data = ""
+		}
+	}
+	return sha256.Sum256([]byte(data))
+}
+
+// diffBaseline records how a header compares to its baseline and returns whether it is new or changed
+func (o *ObjC) diffBaseline(fname, out string) (bool, error) {
+	rel, err := filepath.Rel(o.conf.Output, fname)
+	if err != nil {
+		return false, fmt.Errorf("failed to get header path relative to output folder: %v", err)
+	}
+	o.changes.written[rel] = true
+	o.changes.folders[filepath.Dir(rel)] = true
+	base, err := os.ReadFile(filepath.Join(o.conf.Baseline, rel))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			o.changes.Added = append(o.changes.Added, rel)
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to read baseline header: %v", err)
+	}
+	if hashHeader(string(base)) == hashHeader(out) {
+		return false, nil
+	}
+	o.changes.Modified = append(o.changes.Modified, rel)
+	return true, nil
+}
+
+// writeChanges writes the changes.txt summary of the headers added, modified and removed since the baseline
+func (o *ObjC) writeChanges() error {
+	for folder := range o.changes.folders {
+		baseHeaders, err := filepath.Glob(filepath.Join(o.conf.Baseline, folder, "*"+o.headerExt()))
+		if err != nil {
+			return fmt.Errorf("failed to list baseline headers: %v", err)
+		}
+		for _, header := range baseHeaders {
+			rel, err := filepath.Rel(o.conf.Baseline, header)
+			if err != nil {
+				return fmt.Errorf("failed to get baseline header path relative to baseline folder: %v", err)
+			}
+			if !o.changes.written[rel] {
+				o.changes.Removed = append(o.changes.Removed, rel)
+			}
+		}
+	}
+	if o.conf.BaselineAll {
+		if err := o.removedImages(); err != nil {
+			return err
+		}
+	}
+	var out strings.Builder
+	for _, section := range []struct {
+		name    string
+		headers []string
+	}{
+		{"Added", o.changes.Added},
+		{"Modified", o.changes.Modified},
+		{"Removed", o.changes.Removed},
+	} {
+		slices.Sort(section.headers)
+		out.WriteString(fmt.Sprintf("%s (%d):\n", section.name, len(section.headers)))
+		for _, header := range section.headers {
+			out.WriteString(fmt.Sprintf("  %s\n", header))
+		}
+	}
+	fname := filepath.Join(o.conf.Output, "changes.txt")
+	log.Infof("Creating %s", fname)
+	if err := o.writeOutput(fname, []byte(out.String()), 0o660); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fname, err)
+	}
+	return nil
+}
+
+// removedImages records the headers of the baseline's images that weren't regenerated at all (i.e. the images that are gone)
+// as removed, which is only known if the run generates every image the baseline has (see BaselineAll)
+func (o *ObjC) removedImages() error {
+	regenerated := make(map[string]bool)
+	for folder := range o.changes.folders {
+		top, _, _ := strings.Cut(filepath.ToSlash(folder), "/")
+		regenerated[top] = true
+	}
+	entries, err := os.ReadDir(o.conf.Baseline)
+	if err != nil && !errors.Is(err, os.ErrNotExist) { // a missing baseline has no headers
+		return fmt.Errorf("failed to list baseline folder: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || regenerated[entry.Name()] {
+			continue
+		}
+		if err := filepath.WalkDir(filepath.Join(o.conf.Baseline, entry.Name()), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, o.headerExt()) {
+				return nil
+			}
+			rel, err := filepath.Rel(o.conf.Baseline, path)
+			if err != nil {
+				return fmt.Errorf("failed to get baseline header path relative to baseline folder: %v", err)
+			}
+			o.changes.Removed = append(o.changes.Removed, rel)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to list baseline headers of %s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// IsTarGz returns whether the output path is a gzip-compressed tar archive
+func IsTarGz(output string) bool {
+	return strings.HasSuffix(output, ".tar.gz") || strings.HasSuffix(output, ".tgz")
+}
+
+// headersArchive generates the headers into the gzip-compressed tar archive at conf.Output (with the same layout as on disk)
+func (o *ObjC) headersArchive() error {
+	archive := o.conf.Output
+	f, err := os.Create(archive)
+	if err != nil {
+		return fmt.Errorf("failed to create archive %s: %v", archive, err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	o.archive = tar.NewWriter(gw)
+	o.conf.Output = "" // archive paths are relative to the archive root
+	defer func() {
+		o.archive = nil
+		o.conf.Output = archive
+	}()
+	if err := o.Headers(); err != nil {
+		return err
+	}
+	if err := o.archive.Close(); err != nil {
+		return fmt.Errorf("failed to close tar archive %s: %v", archive, err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to close gzip archive %s: %v", archive, err)
+	}
+	return f.Close()
+}
+
+// writeOutput writes a generated file to disk (or to the output archive)
+func (o *ObjC) writeOutput(fname string, data []byte, perm os.FileMode) error {
+	if o.archive != nil {
+		if err := o.archive.WriteHeader(&tar.Header{
+			Name:    filepath.ToSlash(fname),
+			Mode:    int64(perm),
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		_, err := o.archive.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(fname, data, perm)
+}
+
+type XCFrameworkAvailableLibrary struct {
+	BinaryPath               string   `plist:"BinaryPath"`
+	LibraryIdentifier        string   `plist:"LibraryIdentifier"`
+	LibraryPath              string   `plist:"libraryPath"`
+	SupportedArchitectures   []string `plist:"SupportedArchitectures"`
+	SupportedPlatform        string   `plist:"SupportedPlatform"`
+	SupportedPlatformVariant string   `plist:"SupportedPlatformVariant,omitempty"`
+}
+
+type XCFrameworkInfoPlist struct {
+	AvailableLibraries       []XCFrameworkAvailableLibrary `plist:"AvailableLibraries"`
+	CFBundlePackageType      string                        `plist:"CFBundlePackageType"`
+	XCFrameworkFormatVersion string                        `plist:"XCFrameworkFormatVersion"`
 }
 type XCFrameworkLibraryInfoPlist struct {
 	BuildMachineOSBuild           string   `plist:"BuildMachineOSBuild"`
@@ -683,174 +1945,940 @@ type XCFrameworkLibraryInfoPlist struct {
 	LSMinimumSystemVersion        string   `plist:"LSMinimumSystemVersion"`
 }
 
-// XCFramework outputs and XCFramework for a DSC dylib
-func (o *ObjC) XCFramework() error {
-	xcfolder := filepath.Join(o.conf.Output, o.conf.Name+".xcframework")
-	if err := os.MkdirAll(xcfolder, 0o750); err != nil {
-		return err
+// XCFramework outputs and XCFramework for a DSC dylib
+func (o *ObjC) XCFramework() error {
+	xcfolder := filepath.Join(o.conf.Output, o.conf.Name+".xcframework")
+	if err := os.MkdirAll(xcfolder, 0o750); err != nil {
+		return err
+	}
+	supported := "ios-arm64_x86_64-simulator"
+	/* generate XCFramework Info.plist */
+	f, err := os.Create(filepath.Join(xcfolder, "Info.plist"))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Join(xcfolder, "Info.plist"), err)
+	}
+	defer f.Close()
+	if err := plist.NewEncoder(f).Encode(XCFrameworkInfoPlist{
+		AvailableLibraries: []XCFrameworkAvailableLibrary{
+			{
+				BinaryPath:               o.conf.Name + ".framework/" + o.conf.Name + ".tbd",
+				LibraryIdentifier:        supported,
+				LibraryPath:              o.conf.Name + ".framework",
+				SupportedArchitectures:   []string{"arm64", "x86_64"},
+				SupportedPlatform:        "ios",
+				SupportedPlatformVariant: "simulator",
+			},
+		},
+		CFBundlePackageType:      "XFWK",
+		XCFrameworkFormatVersion: "1.0",
+	}); err != nil {
+		return fmt.Errorf("failed to create XCFramework Info.plist")
+	}
+	/* create folder structure */
+	fwfolder := filepath.Join(xcfolder, supported, o.conf.Name+".framework")
+	if err := os.MkdirAll(filepath.Join(fwfolder, "Headers"), 0o750); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(fwfolder, "Modules"), 0o750); err != nil {
+		return err
+	}
+	/* generate framework stub */
+	image, err := o.cache.Image(o.conf.Name)
+	if err != nil {
+		return err
+	}
+	t, err := tbd.NewTBD(image, nil, false)
+	if err != nil {
+		return err
+	}
+	outTBD, err := t.Generate()
+	if err != nil {
+		return err
+	}
+	tbdFile := filepath.Join(fwfolder, o.conf.Name+".tbd")
+	if err = os.WriteFile(tbdFile, []byte(outTBD), 0o660); err != nil {
+		return fmt.Errorf("failed to write tbd file %s: %v", tbdFile, err)
+	}
+	/* generate modulemap */
+	if err := os.WriteFile(filepath.Join(fwfolder, "Modules", "module.modulemap"), []byte(fmt.Sprintf(
+		"module %s [system] {\n"+
+			"header \"Headers/%s%s\"\n"+ // NOTE: this SHOULD be the umbrella header
+			"export *\n"+
+			"}\n", o.conf.Name, o.conf.Name, o.headerExt(),
+	)), 0o660); err != nil {
+		return fmt.Errorf("failed to write module.modulemap file: %v", err)
+	}
+	/* generate XCFramework Library Info.plist */
+	f2, err := os.Create(filepath.Join(fwfolder, "Info.plist"))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Join(fwfolder, "Info.plist"), err)
+	}
+	defer f2.Close()
+	if err := plist.NewEncoder(f2).Encode(XCFrameworkLibraryInfoPlist{
+		BuildMachineOSBuild:           "23C52",
+		CFBundleDevelopmentRegion:     "English",
+		CFBundleExecutable:            o.conf.Name + ".tbd",
+		CFBundleIdentifier:            "com.apple." + strings.ToLower(o.conf.Name),
+		CFBundleInfoDictionaryVersion: "6.0",
+		CFBundleName:                  o.conf.Name + ".tbd",
+		CFBundlePackageType:           "FMWK",
+		CFBundleShortVersionString:    "2.1",
+		CFBundleSignature:             "????",
+		CFBundleSupportedPlatforms:    []string{"MacOSX"},
+		CFBundleVersion:               "866.4",
+		DTCompiler:                    "com.apple.compilers.llvm.clang.1_0",
+		DTPlatformBuild:               "",
+		DTPlatformName:                "macosx",
+		DTPlatformVersion:             "14.2",
+		DTSDKBuild:                    "23C52",
+		DTSDKName:                     "macosx14.2.internal",
+		DTXcode:                       "1500",
+		DTXcodeBuild:                  "15A6160m",
+		LSMinimumSystemVersion:        "14.2",
+	}); err != nil {
+		return fmt.Errorf("failed to create XCFramework Info.plist")
+	}
+	/* generate Headers */
+	o.conf.Headers = true
+	o.conf.Output = filepath.Join(fwfolder, "Headers")
+	return o.Headers()
+}
+
+/* utils */
+
+// machoName returns the install name of a dylib (or an empty string for other MachOs)
+func machoName(m *macho.File) string {
+	if id := m.DylibID(); id != nil {
+		return id.Name
 	}
-	supported := "ios-arm64_x86_64-simulator"
-	/* generate XCFramework Info.plist */
-	f, err := os.Create(filepath.Join(xcfolder, "Info.plist"))
+	return ""
+}
+
+// frameworkName returns the clean framework/library name of an install name for the header folder (and umbrella header),
+// i.e. w/o the .dylib/.framework extension and any version suffix (e.g. /usr/lib/libz.1.2.12.dylib -> libz)
+func frameworkName(installName string) string {
+	name := filepath.Base(installName)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".dylib"), ".framework")
+	for {
+		base, version, ok := cutLast(name, ".")
+		if !ok || len(base) == 0 || !isVersionComponent(version) {
+			return name
+		}
+		name = base
+	}
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// isVersionComponent returns true for a dylib version component, i.e. a number (libz.1) or a framework version letter (libobjc.A)
+func isVersionComponent(s string) bool {
+	if len(s) == 1 && 'A' <= s[0] && s[0] <= 'Z' {
+		return true
+	}
+	return len(s) > 0 && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// noObjC returns whether err is due to a missing ObjC section and if so records (and debug logs) the missing section for the image
+func (o *ObjC) noObjC(m *macho.File, kind string, err error) bool {
+	if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		return false
+	}
+	image := machoName(m)
+	if len(image) == 0 {
+		image = o.conf.Name
+	}
+	log.Debugf("%s: no ObjC %s", image, kind)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.skipped == nil {
+		o.skipped = make(map[string][]string)
+	}
+	if !slices.Contains(o.skipped[image], kind) {
+		o.skipped[image] = append(o.skipped[image], kind)
+	}
+	return true
+}
+
+// threads returns the number of images to process concurrently
+func (o *ObjC) threads() int {
+	if o.conf.Threads > 0 {
+		return o.conf.Threads
+	}
+	return min(runtime.GOMAXPROCS(0), maxObjcThreads)
+}
+
+// forEachImage calls fn for each of the n images with at most threads() running at a time and returns the first error
+func (o *ObjC) forEachImage(n int, fn func(i int) error) error {
+	if o.cache != nil {
+		// parse the DSC's shared ObjC optimizations (used by every image's GetMacho) before fanning out
+		if _, err := o.cache.Image("/usr/lib/libobjc.A.dylib"); err == nil {
+			if _, err := o.cache.GetOptimizations(); err != nil {
+				return fmt.Errorf("failed to get ObjC optimizations: %v", err)
+			}
+		}
+	}
+	var g errgroup.Group
+	g.SetLimit(o.threads())
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error { return fn(i) })
+	}
+	return g.Wait()
+}
+
+// Skipped returns the images (and the kinds of ObjC data) that were skipped because they had no ObjC section for them
+func (o *ObjC) Skipped() map[string][]string {
+	return o.skipped
+}
+
+// parseFilenameTemplate parses and validates the header FilenameTemplate
+func (o *ObjC) parseFilenameTemplate() (err error) {
+	tmpl := defaultFilenameTemplate
+	if len(o.conf.FilenameTemplate) > 0 {
+		tmpl = o.conf.FilenameTemplate
+	}
+	o.fnameTmpl, err = template.New("filename").Option("missingkey=error").Parse(tmpl)
 	if err != nil {
-		return fmt.Errorf("failed to create %s: %w", filepath.Join(xcfolder, "Info.plist"), err)
+		return fmt.Errorf("failed to parse header filename template: %v", err)
 	}
-	defer f.Close()
-	if err := plist.NewEncoder(f).Encode(XCFrameworkInfoPlist{
-		AvailableLibraries: []XCFrameworkAvailableLibrary{
-			{
-				BinaryPath:               o.conf.Name + ".framework/" + o.conf.Name + ".tbd",
-				LibraryIdentifier:        supported,
-				LibraryPath:              o.conf.Name + ".framework",
-				SupportedArchitectures:   []string{"arm64", "x86_64"},
-				SupportedPlatform:        "ios",
-				SupportedPlatformVariant: "simulator",
-			},
-		},
-		CFBundlePackageType:      "XFWK",
-		XCFrameworkFormatVersion: "1.0",
-	}); err != nil {
-		return fmt.Errorf("failed to create XCFramework Info.plist")
+	for _, kind := range []string{"class", "protocol", "category"} {
+		if _, err := o.headerFileName(headerFileInfo{Name: "Foo", Kind: kind, Class: "Foo", Category: "Bar"}); err != nil {
+			return fmt.Errorf("invalid header filename template: %v", err)
+		}
 	}
-	/* create folder structure */
-	fwfolder := filepath.Join(xcfolder, supported, o.conf.Name+".framework")
-	if err := os.MkdirAll(filepath.Join(fwfolder, "Headers"), 0o750); err != nil {
-		return err
+	return nil
+}
+
+// headerFileName returns the filesystem-safe header file name for an ObjC class, protocol or category
+func (o *ObjC) headerFileName(info headerFileInfo) (string, error) {
+	var buf strings.Builder
+	if err := o.fnameTmpl.Execute(&buf, info); err != nil {
+		return "", fmt.Errorf("failed to execute header filename template: %v", err)
 	}
-	if err := os.MkdirAll(filepath.Join(fwfolder, "Modules"), 0o750); err != nil {
-		return err
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(buf.String()))
+	if len(name) == 0 || name == "." || name == ".." {
+		return "", fmt.Errorf("header filename template produced an invalid file name '%s' for %s %s", name, info.Kind, info.Name)
 	}
-	/* generate framework stub */
-	image, err := o.cache.Image(o.conf.Name)
+	return name + o.headerExt(), nil
+}
+
+// checkHeaderExt returns an error if ext isn't a valid HeaderExt (a dot followed by a file name safe suffix)
+func checkHeaderExt(ext string) error {
+	if len(ext) > 0 && (len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], `./\:*?"<>| `)) {
+		return fmt.Errorf("invalid header extension '%s' (must start with a dot, e.g. .hpp)", ext)
+	}
+	return nil
+}
+
+// headerExt returns the header file name extension (see HeaderExt)
+func (o *ObjC) headerExt() string {
+	if len(o.conf.HeaderExt) > 0 {
+		return o.conf.HeaderExt
+	}
+	return ".h"
+}
+
+// localHeader returns the header file name used to #include a local class or protocol
+func (o *ObjC) localHeader(kind, name string) string {
+	info := headerFileInfo{Name: name, Kind: kind}
+	if kind == "class" {
+		info.Class = name
+	}
+	fname, err := o.headerFileName(info)
 	if err != nil {
-		return err
+		log.Errorf("failed to get header file name for %s %s: %v", kind, name, err)
+		return name + o.headerExt()
+	}
+	return fname
+}
+
+// resolveCategoryClasses recovers the target class of categories the parser couldn't resolve from their class pointer
+func (o *ObjC) resolveCategoryClasses(m *macho.File, cats []objc.Category) {
+	resolveCategoryClassNames(cats, func() map[uint64]string {
+		return o.refNames(m) // local and cache class names by pointer
+	})
+}
+
+// resolveCategoryClassNames sets the Class of categories with an unnamed class to the name its ClsVMAddr resolves to (names is only called if needed)
+func resolveCategoryClassNames(cats []objc.Category, names func() map[uint64]string) {
+	var ptrs map[uint64]string
+	for i := range cats {
+		if (cats[i].Class != nil && len(cats[i].Class.Name) > 0) || cats[i].ClsVMAddr == 0 {
+			continue
+		}
+		if ptrs == nil {
+			ptrs = names()
+		}
+		if name, ok := ptrs[cats[i].ClsVMAddr]; ok {
+			cats[i].Class = &objc.Class{Name: name, ClassPtr: cats[i].ClsVMAddr}
+		} else {
+			log.Debugf("failed to resolve class %#x of category %s", cats[i].ClsVMAddr, cats[i].Name)
+		}
+	}
+}
+
+// categoryHeaderFileInfo returns the header file name data for a category (anonymous categories are class extensions)
+func categoryHeaderFileInfo(cat objc.Category) headerFileInfo {
+	var className string
+	if cat.Class != nil {
+		className = cat.Class.Name
+	}
+	if len(cat.Name) == 0 {
+		return headerFileInfo{Name: className, Kind: "extension", Class: className}
+	}
+	return headerFileInfo{Name: cat.Name, Kind: "category", Class: className, Category: cat.Name}
+}
+
+// isPrivateName returns true if a property/selector name has the underscore prefix of (Apple's) private API
+func isPrivateName(name string) bool {
+	return strings.HasPrefix(name, "_")
+}
+
+// splitPrivateMembers removes the private (see isPrivateName) properties and methods of a class and
+// returns them as a class extension (or nil if the class has none)
+func splitPrivateMembers(class *objc.Class) *objc.Category {
+	ext := objc.Category{Class: &objc.Class{
+		Name:          class.Name,
+		ClassPtr:      class.ClassPtr,
+		IsSwiftLegacy: class.IsSwiftLegacy,
+		IsSwiftStable: class.IsSwiftStable,
+	}}
+	class.Props = slices.DeleteFunc(class.Props, func(p objc.Property) bool {
+		if isPrivateName(p.Name) {
+			ext.Properties = append(ext.Properties, p)
+			return true
+		}
+		return false
+	})
+	class.ClassMethods = slices.DeleteFunc(class.ClassMethods, func(m objc.Method) bool {
+		if isPrivateName(m.Name) {
+			ext.ClassMethods = append(ext.ClassMethods, m)
+			return true
+		}
+		return false
+	})
+	class.InstanceMethods = slices.DeleteFunc(class.InstanceMethods, func(m objc.Method) bool {
+		if isPrivateName(m.Name) {
+			ext.InstanceMethods = append(ext.InstanceMethods, m)
+			return true
+		}
+		return false
+	})
+	if len(ext.Properties) == 0 && len(ext.ClassMethods) == 0 && len(ext.InstanceMethods) == 0 {
+		return nil
+	}
+	return &ext
+}
+
+// mergePrivateExtensions prepends the split private members of classes to their (anonymous category) class
+// extensions, adding an extension for the classes that don't have one
+func mergePrivateExtensions(cats []objc.Category, private map[string]*objc.Category) []objc.Category {
+	if len(private) == 0 {
+		return cats
+	}
+	merged := make(map[string]bool)
+	for i, cat := range cats {
+		if len(cat.Name) > 0 || cat.Class == nil {
+			continue
+		}
+		ext, ok := private[cat.Class.Name]
+		if !ok || merged[cat.Class.Name] {
+			continue
+		}
+		cats[i].Properties = append(slices.Clone(ext.Properties), cat.Properties...)
+		cats[i].ClassMethods = append(slices.Clone(ext.ClassMethods), cat.ClassMethods...)
+		cats[i].InstanceMethods = append(slices.Clone(ext.InstanceMethods), cat.InstanceMethods...)
+		merged[cat.Class.Name] = true
+	}
+	names := make([]string, 0, len(private))
+	for name := range private {
+		if !merged[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		cats = append(cats, *private[name])
+	}
+	return cats
+}
+
+// categoryClassName returns the name of a category's target class (falling back to the category name)
+func categoryClassName(cat objc.Category) string {
+	if cat.Class != nil && cat.Class.Name != "" {
+		return cat.Class.Name
+	}
+	return cat.Name
+}
+
+func (o *ObjC) writeHeader(hdr *headerInfo) error {
+	var out string
+	if !o.conf.NoBanner {
+		out = fmt.Sprintf(
+			"//\n"+
+				"//   Generated by https://github.com/blacktop/ipsw (%s)\n"+
+				"//\n"+
+				"//    - LC_BUILD_VERSION:  %s\n"+
+				"//    - LC_SOURCE_VERSION: %s\n"+
+				"//\n",
+			hdr.IpswVersion,
+			strings.Join(hdr.BuildVersions, "\n//    - LC_BUILD_VERSION:  "),
+			hdr.SourceVersion)
+	}
+	out += fmt.Sprintf(
+		"#ifndef %s_h\n"+
+			"#define %s_h\n",
+		hdr.Name,
+		hdr.Name)
+	if !hdr.IsUmbrella && !o.conf.InlineFoundation {
+		if o.conf.UseModules {
+			out += fmt.Sprintf("@import Foundation;\n")
+		} else {
+			out += fmt.Sprintf("#import <Foundation/Foundation.h>\n")
+		}
+	}
+	out += fmt.Sprintf("\n")
+	if o.conf.ImportsNotForwards {
+		hdr.Imports = o.forwardImports(hdr.Imports)
+	}
+	if len(hdr.Imports.Imports) > 0 {
+		for _, imp := range hdr.Imports.Imports {
+			out += fmt.Sprintf("#include \"%s\"\n", imp)
+		}
+	}
+	if len(hdr.Imports.Locals) > 0 {
+		for _, local := range hdr.Imports.Locals {
+			out += fmt.Sprintf("#include \"%s\"\n", local)
+		}
+	}
+	if len(hdr.Imports.Imports) > 0 || len(hdr.Imports.Locals) > 0 {
+		out += fmt.Sprintf("\n")
+	}
+	if len(hdr.Imports.Classes) > 0 {
+		out += fmt.Sprintf("@class %s;\n", strings.Join(hdr.Imports.Classes, ", "))
+	}
+	if len(hdr.Imports.Protos) > 0 {
+		out += fmt.Sprintf("@protocol %s;\n", strings.Join(hdr.Imports.Protos, ", "))
+	}
+	if len(hdr.Imports.Classes) > 0 || len(hdr.Imports.Protos) > 0 {
+		out += fmt.Sprintf("\n")
+	}
+	out += fmt.Sprintf("%s\n", hdr.Object)
+	if len(hdr.Aliases) > 0 {
+		out += strings.Join(hdr.Aliases, "\n") + "\n\n"
+	}
+	out += fmt.Sprintf("#endif /* %s_h */\n", hdr.Name)
+
+	out = reindent(out, o.conf.IndentStyle)
+	if o.conf.LineEnding == "crlf" {
+		out = strings.ReplaceAll(strings.ReplaceAll(out, "\r\n", "\n"), "\n", "\r\n")
+	}
+
+	if o.changes != nil {
+		if changed, err := o.diffBaseline(hdr.FileName, out); err != nil {
+			return err
+		} else if !changed {
+			return nil
+		}
+	}
+
+	log.Infof("Creating %s", hdr.FileName)
+	if err := o.writeOutput(hdr.FileName, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write header %s: %v", hdr.FileName, err)
+	}
+	if o.conf.Tags && !hdr.IsUmbrella {
+		o.addTags(hdr.FileName, out)
+	}
+
+	return nil
+}
+
+func printRef(off, ptr uint64, name string) {
+	if len(name) == 0 {
+		fmt.Printf("0x%011x => 0x%011x\n", off, ptr)
+		return
+	}
+	fmt.Printf("0x%011x => 0x%011x: %s\n", off, ptr, name)
+}
+
+// refNames returns the names of the image's (and the DSC's) ObjC classes and protocols by address
+// so that refs whose names weren't parsed can be resolved from their pointers
+func (o *ObjC) refNames(m *macho.File) map[uint64]string {
+	names := make(map[uint64]string)
+	if o.cache != nil {
+		o.namesOnce.Do(func() {
+			o.cacheNames = make(map[uint64]string)
+			if classes, err := o.cache.GetAllObjCClasses(false); err == nil {
+				for addr, class := range classes {
+					o.cacheNames[addr] = class.Name
+				}
+			}
+			if protos, err := o.cache.GetAllObjCProtocols(false); err == nil {
+				for addr, proto := range protos {
+					o.cacheNames[addr] = proto.Name
+				}
+			}
+		})
+		maps.Copy(names, o.cacheNames)
+	}
+	if classes, err := m.GetObjCClasses(); err == nil {
+		for _, class := range classes {
+			names[class.ClassPtr] = class.Name
+		}
+	}
+	if protos, err := m.GetObjCProtocols(); err == nil {
+		for _, proto := range protos {
+			names[proto.Ptr] = proto.Name
+		}
+	}
+	return names
+}
+
+// selectorName resolves a selector's name through the DSC's selector optimization hash table (for selectors
+// that point into the shared cache's selector strings instead of the image's own __objc_methname section)
+func (o *ObjC) selectorName(addr uint64) string {
+	if o.cache == nil || addr == 0 {
+		return ""
+	}
+	o.selsOnce.Do(func() {
+		o.cacheSels = make(map[uint64]string)
+		sels, err := o.cache.GetAllObjCSelectors(false)
+		if err != nil {
+			log.Debugf("failed to get DSC selectors: %v", err)
+		}
+		for addr, sel := range sels {
+			o.cacheSels[addr] = sel.Name
+		}
+	})
+	if name, ok := o.cacheSels[addr]; ok {
+		return name
+	}
+	// the address may be a selref (pointer to the selector string)
+	if ptr, err := o.cache.ReadPointerAtAddress(addr); err == nil {
+		if name, ok := o.cacheSels[o.cache.SlideInfo.SlidePointer(ptr)]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// refName returns the name of an ObjC ref (falling back to the name of the object it points to)
+func refName(names map[uint64]string, ptr uint64, name string) string {
+	if len(name) > 0 {
+		return name
+	}
+	return names[ptr]
+}
+
+// swiftSyntheticPatterns match the ObjC runtime names of compiler generated Swift classes:
+//
+//	_TtCs  - Swift stdlib/runtime classes (e.g. _TtCs19__EmptyArrayStorage)
+//	_TtG   - generic class instantiations (e.g. _TtGC7SwiftUI14_UIHostingView...)
+//	P33_   - fileprivate/private types mangled with a file discriminator
+//	_TtCF  - classes local to a function or closure
+var swiftSyntheticPatterns = regexp.MustCompile(`^_TtCs|^_TtG|P33_[0-9A-F]{32}|^_TtCF`)
+
+func isSwiftSynthetic(name string) bool {
+	return swiftSyntheticPatterns.MatchString(name)
+}
+
+func (o *ObjC) skipSwiftSynthetic(name string) bool {
+	return o.conf.SkipSwiftSynthetic && isSwiftSynthetic(name)
+}
+
+// skipModule returns true if the Module filter is set and the class isn't from that Swift module
+func (o *ObjC) skipModule(name string) bool {
+	if len(o.conf.Module) == 0 {
+		return false
+	}
+	module, ok := swiftModuleName(name)
+	return !ok || module != o.conf.Module
+}
+
+// tooFewMethods returns true if the class has fewer (class and instance) methods than MinMethods
+func (o *ObjC) tooFewMethods(c *objc.Class) bool {
+	return len(c.ClassMethods)+len(c.InstanceMethods) < o.conf.MinMethods
+}
+
+// skipEmpty returns true if the class is filtered out by SkipEmpty or OnlyEmpty
+func (o *ObjC) skipEmpty(c *objc.Class) bool {
+	empty := len(c.Ivars) == 0 && len(c.Props) == 0 && len(c.ClassMethods) == 0 && len(c.InstanceMethods) == 0
+	return o.conf.SkipEmpty && empty || o.conf.OnlyEmpty && !empty
+}
+
+// skipClass returns true if the class is filtered out (see SkipSwiftSynthetic and Module)
+func (o *ObjC) skipClass(name string) bool {
+	return o.skipSwiftSynthetic(name) || o.skipModule(name)
+}
+
+// swiftNameParts returns the module and (outer to inner) class names of a mangled ObjC runtime name
+// (e.g. _TtCC7Example5Outer5Inner -> [Example Outer Inner])
+func swiftNameParts(name string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(name, "_TtC")
+	if !ok {
+		return nil, false // not mangled (e.g. an @objc(Name) renamed class)
+	}
+	rest = strings.TrimLeft(rest, "C") // nested classes
+	var parts []string
+	for len(rest) > 0 {
+		i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+		if i <= 0 {
+			return nil, false // stdlib (s) or other special manglings
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || n == 0 || i+n > len(rest) {
+			return nil, false
+		}
+		parts = append(parts, rest[i:i+n])
+		rest = rest[i+n:]
+	}
+	if len(parts) < 2 { // module + class
+		return nil, false
+	}
+	return parts, true
+}
+
+// swiftClassName returns the Swift class name of a mangled ObjC runtime name (e.g. _TtC7Example3Foo -> Foo)
+func swiftClassName(name string) (string, bool) {
+	parts, ok := swiftNameParts(name)
+	if !ok {
+		return "", false
+	}
+	return parts[len(parts)-1], true
+}
+
+// swiftModuleName returns the Swift module of an ObjC runtime class name,
+// either mangled (e.g. _TtC7Example3Foo) or demangled (e.g. Example.Foo)
+func swiftModuleName(name string) (string, bool) {
+	if parts, ok := swiftNameParts(name); ok {
+		return parts[0], true
+	}
+	if module, class, ok := strings.Cut(name, "."); ok && len(module) > 0 && len(class) > 0 && !strings.ContainsAny(module, "<>()") {
+		return module, true
+	}
+	return "", false
+}
+
+// swiftAliases returns the @compatibility_alias declarations for a class whose ObjC name is a mangled Swift name
+func (o *ObjC) swiftAliases(name string) []string {
+	if !o.conf.SwiftAliases {
+		return nil
+	}
+	if alias, ok := swiftClassName(name); ok {
+		return []string{fmt.Sprintf("@compatibility_alias %s %s;", alias, name)}
+	}
+	return nil
+}
+
+// resolveClassImps resolves the IMP addresses of a class's methods to their implementation vmaddrs
+// (and applies any outstanding pointer fixups to the class and metaclass addresses printed with them)
+func (o *ObjC) resolveClassImps(m *macho.File, class *objc.Class) {
+	class.ClassPtr = o.resolvePtr(m, class.ClassPtr)
+	class.IsaVMAddr = o.resolvePtr(m, class.IsaVMAddr)
+	for i := range class.ClassMethods {
+		class.ClassMethods[i].ImpVMAddr = o.resolveImp(m, class.ClassMethods[i].ImpVMAddr)
+	}
+	for i := range class.InstanceMethods {
+		class.InstanceMethods[i].ImpVMAddr = o.resolveImp(m, class.InstanceMethods[i].ImpVMAddr)
+	}
+}
+
+// resolveCategoryImps resolves the IMP addresses of a category's methods to their implementation vmaddrs
+func (o *ObjC) resolveCategoryImps(m *macho.File, cat *objc.Category) {
+	cat.VMAddr = o.resolvePtr(m, cat.VMAddr)
+	for i := range cat.ClassMethods {
+		cat.ClassMethods[i].ImpVMAddr = o.resolveImp(m, cat.ClassMethods[i].ImpVMAddr)
+	}
+	for i := range cat.InstanceMethods {
+		cat.InstanceMethods[i].ImpVMAddr = o.resolveImp(m, cat.InstanceMethods[i].ImpVMAddr)
+	}
+}
+
+// resolveImp applies any outstanding pointer fixups to a method's IMP and follows it through a symbol stub
+func (o *ObjC) resolveImp(m *macho.File, imp uint64) uint64 {
+	if imp == 0 {
+		return 0
+	}
+	imp = o.resolvePtr(m, imp)
+	if target, ok := o.getStubs(m)[imp]; ok && target != 0 {
+		log.Debugf("IMP %#x is a stub for %#x", imp, target)
+		return target
+	}
+	return imp
+}
+
+// resolvePtr applies any outstanding pointer fixups to an address read from m
+// (the cache's slide info for cache images, the MachO's chained fixups or threaded rebases otherwise)
+func (o *ObjC) resolvePtr(m *macho.File, ptr uint64) uint64 {
+	if o.isCacheImage(m) {
+		return fixupPtr(ptr, func(addr uint64) bool {
+			_, _, err := o.cache.GetMappingForVMAddress(addr)
+			return err == nil
+		}, o.cache.SlideInfo.SlidePointer)
+	}
+	return fixupPtr(ptr, func(addr uint64) bool {
+		return m.FindSegmentForVMAddr(addr) != nil
+	}, m.SlidePointer)
+}
+
+// fixupPtr returns ptr if it is already a mapped vmaddr and the vmaddr slide rebases it to otherwise
+// (i.e. ptr is still the raw value of an unapplied chained fixup/rebase)
+func fixupPtr(ptr uint64, mapped func(uint64) bool, slide func(uint64) uint64) uint64 {
+	if ptr == 0 || mapped(ptr) {
+		return ptr
+	}
+	return slide(ptr)
+}
+
+// isCacheImage returns whether a MachO was parsed from one of the cache's images (rather than read from disk)
+func (o *ObjC) isCacheImage(m *macho.File) bool {
+	if o.cache == nil {
+		return false
 	}
-	t, err := tbd.NewTBD(image, nil, false)
-	if err != nil {
-		return err
+	o.inCacheMu.Lock()
+	defer o.inCacheMu.Unlock()
+	if in, ok := o.inCache[m]; ok {
+		return in
 	}
-	outTBD, err := t.Generate()
-	if err != nil {
-		return err
+	in := false
+	if id := m.DylibID(); id != nil {
+		if img, err := o.cache.Image(id.Name); err == nil {
+			cm, err := img.GetMacho()
+			in = err == nil && cm == m // an extracted dylib has the same install name but is a different MachO
+		}
 	}
-	tbdFile := filepath.Join(fwfolder, o.conf.Name+".tbd")
-	if err = os.WriteFile(tbdFile, []byte(outTBD), 0o660); err != nil {
-		return fmt.Errorf("failed to write tbd file %s: %v", tbdFile, err)
+	o.inCache[m] = in
+	return in
+}
+
+// getStubs returns the (cached) symbol stub to target map for a MachO
+func (o *ObjC) getStubs(m *macho.File) map[uint64]uint64 {
+	o.stubsMu.Lock()
+	defer o.stubsMu.Unlock()
+	if stubs, ok := o.stubs[m]; ok {
+		return stubs
 	}
-	/* generate modulemap */
-	if err := os.WriteFile(filepath.Join(fwfolder, "Modules", "module.modulemap"), []byte(fmt.Sprintf(
-		"module %s [system] {\n"+
-			"header \"Headers/%s.h\"\n"+ // NOTE: this SHOULD be the umbrella header
-			"export *\n"+
-			"}\n", o.conf.Name, o.conf.Name,
-	)), 0o660); err != nil {
-		return fmt.Errorf("failed to write module.modulemap file: %v", err)
+	stubs := make(map[uint64]uint64)
+	if o.isCacheImage(m) {
+		id := m.DylibID()
+		if img, err := o.cache.Image(id.Name); err == nil {
+			if err := img.ParseStubs(); err != nil {
+				log.Debugf("failed to parse stubs for %s: %v", id.Name, err)
+			} else {
+				stubs = img.Analysis.SymbolStubs
+			}
+		}
+	} else {
+		if sb, err := disass.ParseStubsForMachO(m); err != nil {
+			log.Debugf("failed to parse stubs: %v", err)
+		} else {
+			stubs = sb
+		}
 	}
-	/* generate XCFramework Library Info.plist */
-	f2, err := os.Create(filepath.Join(fwfolder, "Info.plist"))
+	o.stubs[m] = stubs
+	return stubs
+}
+
+func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, error) {
+	classes, err := m.GetObjCClasses()
 	if err != nil {
-		return fmt.Errorf("failed to create %s: %w", filepath.Join(fwfolder, "Info.plist"), err)
+		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return nil, err
+		}
 	}
-	defer f2.Close()
-	if err := plist.NewEncoder(f2).Encode(XCFrameworkLibraryInfoPlist{
-		BuildMachineOSBuild:           "23C52",
-		CFBundleDevelopmentRegion:     "English",
-		CFBundleExecutable:            o.conf.Name + ".tbd",
-		CFBundleIdentifier:            "com.apple." + strings.ToLower(o.conf.Name),
-		CFBundleInfoDictionaryVersion: "6.0",
-		CFBundleName:                  o.conf.Name + ".tbd",
-		CFBundlePackageType:           "FMWK",
-		CFBundleShortVersionString:    "2.1",
-		CFBundleSignature:             "????",
-		CFBundleSupportedPlatforms:    []string{"MacOSX"},
-		CFBundleVersion:               "866.4",
-		DTCompiler:                    "com.apple.compilers.llvm.clang.1_0",
-		DTPlatformBuild:               "",
-		DTPlatformName:                "macosx",
-		DTPlatformVersion:             "14.2",
-		DTSDKBuild:                    "23C52",
-		DTSDKName:                     "macosx14.2.internal",
-		DTXcode:                       "1500",
-		DTXcodeBuild:                  "15A6160m",
-		LSMinimumSystemVersion:        "14.2",
-	}); err != nil {
-		return fmt.Errorf("failed to create XCFramework Info.plist")
+	protos, err := m.GetObjCProtocols()
+	if err != nil {
+		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return nil, err
+		}
 	}
-	/* generate Headers */
-	o.conf.Headers = true
-	o.conf.Output = filepath.Join(fwfolder, "Headers")
-	return o.Headers()
+	classes = o.allowedClasses(classes)
+	return o.forwardDeclarations(classes, o.allowedProtocols(protos, classes)), nil
 }
 
-/* utils */
+// protocolImport adds a protocol reference to the imports as a local header, a dep image header or a forward declaration
+func (o *ObjC) protocolImport(imp *Imports, name string, protoNames []string) {
+	if slices.Contains(protoNames, name) {
+		imp.Locals = append(imp.Locals, o.localHeader("protocol", name))
+	} else if dep, ok := o.dependencyProtocols()[name]; ok {
+		imp.Imports = append(imp.Imports, "../"+dep+"/"+o.localHeader("protocol", name))
+	} else {
+		imp.Protos = append(imp.Protos, name)
+	}
+}
 
-func writeHeader(hdr *headerInfo) error {
-	out := fmt.Sprintf(
-		"//\n"+
-			"//   Generated by https://github.com/blacktop/ipsw (%s)\n"+
-			"//\n"+
-			"//    - LC_BUILD_VERSION:  %s\n"+
-			"//    - LC_SOURCE_VERSION: %s\n"+
-			"//\n"+
-			"#ifndef %s_h\n"+
-			"#define %s_h\n",
-		hdr.IpswVersion,
-		strings.Join(hdr.BuildVersions, "\n//    - LC_BUILD_VERSION:  "),
-		hdr.SourceVersion,
-		hdr.Name,
-		hdr.Name)
-	if !hdr.IsUmbrella {
-		out += fmt.Sprintf("@import Foundation;\n")
+// dependencyProtocols returns the (non-Foundation) protocols defined in the dep images and the image that defines each
+func (o *ObjC) dependencyProtocols() map[string]string {
+	if o.depProtos != nil {
+		return o.depProtos
 	}
-	out += fmt.Sprintf("\n")
-	if len(hdr.Imports.Imports) > 0 {
-		for _, imp := range hdr.Imports.Imports {
-			out += fmt.Sprintf("#include \"%s\"\n", imp)
+	o.depProtos = make(map[string]string)
+	for _, m := range o.deps {
+		id := m.DylibID()
+		if id == nil {
+			continue
 		}
-	}
-	if len(hdr.Imports.Locals) > 0 {
-		for _, local := range hdr.Imports.Locals {
-			out += fmt.Sprintf("#include \"%s\"\n", local)
+		protos, err := m.GetObjCProtocols()
+		if err != nil {
+			if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+				log.Errorf("failed to get protocols for dep %s: %v", id.Name, err)
+			}
+			continue
+		}
+		for _, proto := range protos {
+			if _, found := slices.BinarySearch(o.foundation["protocols"], proto.Name); found {
+				continue // Foundation protocol headers are never generated
+			}
+			if _, ok := o.depProtos[proto.Name]; !ok {
+				o.depProtos[proto.Name] = frameworkName(id.Name)
+			}
 		}
 	}
-	if len(hdr.Imports.Imports) > 0 || len(hdr.Imports.Locals) > 0 {
-		out += fmt.Sprintf("\n")
+	return o.depProtos
+}
+
+// outputHeaders returns the image folder of each class and protocol header Headers generates (keyed by "<kind>:<name>")
+func (o *ObjC) outputHeaders() map[string]string {
+	out := make(map[string]string)
+	for _, m := range append([]*macho.File{o.file}, o.deps...) {
+		dir := o.conf.Name
+		if id := m.DylibID(); id != nil {
+			dir = frameworkName(id.Name)
+		}
+		if classes, err := m.GetObjCClasses(); err == nil {
+			for _, class := range classes {
+				if _, ok := out["class:"+class.Name]; !ok && !o.skipClass(class.Name) && !o.skipEmpty(&class) && !o.skipUnexported(m, class.Name) {
+					out["class:"+class.Name] = dir
+				}
+			}
+		}
+		if protos, err := m.GetObjCProtocols(); err == nil {
+			for _, proto := range protos {
+				if _, found := slices.BinarySearch(o.foundation["protocols"], proto.Name); found {
+					continue // Foundation protocol headers are never generated
+				}
+				if _, ok := out["protocol:"+proto.Name]; !ok {
+					out["protocol:"+proto.Name] = dir
+				}
+			}
+		}
 	}
-	if len(hdr.Imports.Classes) > 0 {
-		out += fmt.Sprintf("@class %s;\n", strings.Join(hdr.Imports.Classes, ", "))
+	return out
+}
+
+// forwardImports replaces the forward declarations of classes/protocols with a generated header with imports of those headers
+func (o *ObjC) forwardImports(imp Imports) Imports {
+	out := Imports{Imports: slices.Clone(imp.Imports), Locals: slices.Clone(imp.Locals)}
+	include := func(kind, name string) bool {
+		dir, ok := o.outHeaders[kind+":"+name]
+		if !ok {
+			return false
+		}
+		if dir == o.conf.Name { // the header being written is in the same image
+			out.Locals = append(out.Locals, o.localHeader(kind, name))
+		} else {
+			out.Imports = append(out.Imports, "../"+dir+"/"+o.localHeader(kind, name))
+		}
+		return true
 	}
-	if len(hdr.Imports.Protos) > 0 {
-		out += fmt.Sprintf("@protocol %s;\n", strings.Join(hdr.Imports.Protos, ", "))
+	for _, class := range imp.Classes {
+		if !include("class", class) {
+			out.Classes = append(out.Classes, class)
+		}
 	}
-	if len(hdr.Imports.Classes) > 0 || len(hdr.Imports.Protos) > 0 {
-		out += fmt.Sprintf("\n")
+	for _, proto := range imp.Protos {
+		if !include("protocol", proto) {
+			out.Protos = append(out.Protos, proto)
+		}
 	}
-	out += fmt.Sprintf("%s\n", hdr.Object)
-	out += fmt.Sprintf("#endif /* %s_h */\n", hdr.Name)
+	out.uniq(nil)
+	return out
+}
 
-	if err := os.MkdirAll(filepath.Dir(hdr.FileName), 0o750); err != nil {
-		return err
+// typeImports adds the imports for the classes and protocols referenced by a protocol qualified or generic type
+func (o *ObjC) typeImports(imp *Imports, typ string, classNames, protoNames []string) {
+	classes, protos := typeRefs(typ)
+	for _, class := range classes {
+		if slices.Contains(classNames, class) {
+			imp.Locals = append(imp.Locals, o.localHeader("class", class))
+		} else {
+			imp.Classes = append(imp.Classes, class) // Foundation classes are removed by uniq
+		}
 	}
-	log.Infof("Creating %s", hdr.FileName)
-	if err := os.WriteFile(hdr.FileName, []byte(out), 0644); err != nil {
-		return fmt.Errorf("failed to write header %s: %v", hdr.FileName, err)
+	for _, proto := range protos {
+		o.protocolImport(imp, proto, protoNames)
 	}
+}
 
-	return nil
+// typeRefs returns the class and protocol names referenced by an ObjC type
+// (e.g. `NSObject<Foo>`, `id<Foo, Bar>` or `NSDictionary<NSString *, MyValue *> *`)
+func typeRefs(typ string) (classes, protos []string) {
+	typ = strings.Trim(strings.TrimSpace(typ), "@\" *")
+	base, rest, generic := strings.Cut(typ, "<")
+	base = strings.TrimSpace(base)
+	if !generic {
+		if len(base) > 0 && base != "id" {
+			classes = append(classes, base)
+		}
+		return classes, protos
+	}
+	inner := rest[:max(strings.LastIndex(rest, ">"), 0)]
+	// split the params on top-level commas
+	var params []string
+	depth, start := 0, 0
+	for i, c := range inner {
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	params = append(params, inner[start:])
+	if len(base) == 0 || base == "id" || base == "NSObject" { // protocol qualified
+		for _, param := range params {
+			if param = strings.TrimSpace(param); len(param) > 0 {
+				protos = append(protos, param)
+			}
+		}
+		return classes, protos
+	}
+	classes = append(classes, base)
+	for _, param := range params { // lightweight generics
+		c, p := typeRefs(param)
+		classes = append(classes, c...)
+		protos = append(protos, p...)
+	}
+	return classes, protos
 }
 
-func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, error) {
+func (o *ObjC) forwardDeclarations(classes []objc.Class, protos []objc.Protocol) map[string]Imports {
 	var classNames []string
 	var protoNames []string
 
 	imps := make(map[string]Imports)
 
-	classes, err := m.GetObjCClasses()
-	if err != nil {
-		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-			return nil, err
-		}
+	known := o.foundation // Foundation symbols are declared by the Foundation import
+	if o.conf.InlineFoundation {
+		known = nil
 	}
+
 	slices.SortStableFunc(classes, func(a, b objc.Class) int {
 		return cmp.Compare(a.Name, b.Name)
 	})
@@ -858,56 +2886,47 @@ func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, er
 		classNames = append(classNames, class.Name)
 	}
 
-	protos, err := m.GetObjCProtocols()
-	if err != nil {
-		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-			return nil, err
-		}
-	}
 	slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
 		return cmp.Compare(a.Name, b.Name)
 	})
 	for _, proto := range protos {
 		protoNames = append(protoNames, proto.Name)
+	}
+
+	for _, proto := range protos {
+		imp := Imports{}
+		for _, prot := range proto.Prots {
+			if prot.Name == proto.Name {
+				continue
+			}
+			o.protocolImport(&imp, prot.Name, protoNames)
+		}
 		//TODO: parse protocol properties and methods and add to imports etc
+		imp.uniq(known)
+		imps[proto.Name+"-Protocol"] = imp
 	}
 
 	for _, class := range classes {
 		imp := Imports{}
-		if class.SuperClass != "NSObject" { // skip NSObject since we'll import Foundation by default
-			imp.Imports = append(imp.Imports, class.SuperClass+".h")
-		}
-		for _, prot := range class.Protocols {
-			if slices.Contains(protoNames, prot.Name) {
-				imp.Locals = append(imp.Locals, prot.Name+"-Protocol.h")
+		if len(class.SuperClass) > 0 && (class.SuperClass != "NSObject" || o.conf.InlineFoundation) { // skip NSObject since we'll import Foundation by default
+			if slices.Contains(classNames, class.SuperClass) {
+				imp.Locals = append(imp.Locals, o.localHeader("class", class.SuperClass))
 			} else {
-				imp.Protos = append(imp.Protos, prot.Name)
+				imp.Classes = append(imp.Classes, class.SuperClass) // Foundation classes are removed by uniq
 			}
 		}
+		for _, prot := range class.Protocols {
+			o.protocolImport(&imp, prot.Name, protoNames)
+		}
 		for _, ivar := range class.Ivars {
 			typ := ivar.Type
 			if strings.ContainsAny(typ, "<>") {
-				typ = strings.Trim(typ, "@\"")
-				if rest, ok := strings.CutPrefix(typ, "NSObject<"); ok {
-					typ = strings.TrimSuffix(rest, ">")
-					if slices.Contains(protoNames, typ) {
-						imp.Locals = append(imp.Locals, typ+"-Protocol.h")
-					} else {
-						imp.Protos = append(imp.Protos, typ)
-					}
-				}
-				typ = strings.Trim(typ, "<>")
-				if slices.Contains(protoNames, typ) {
-					imp.Locals = append(imp.Locals, typ+"-Protocol.h")
-				} else {
-					imp.Protos = append(imp.Protos, typ)
-				}
-
+				o.typeImports(&imp, typ, classNames, protoNames)
 			} else {
 				if rest, ok := strings.CutPrefix(typ, "@\""); ok {
 					typ = strings.TrimSuffix(rest, "\"")
 					if slices.Contains(classNames, typ) {
-						imp.Locals = append(imp.Locals, typ+".h")
+						imp.Locals = append(imp.Locals, o.localHeader("class", typ))
 					} else {
 						imp.Classes = append(imp.Classes, typ)
 					}
@@ -917,27 +2936,12 @@ func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, er
 		for _, prop := range class.Props {
 			typ := prop.Type()
 			if strings.ContainsAny(typ, "<>") {
-				typ = strings.Trim(typ, "@\"")
-				typ = strings.Trim(typ, " *")
-				if rest, ok := strings.CutPrefix(typ, "NSObject<"); ok {
-					typ = strings.TrimSuffix(rest, ">")
-					if slices.Contains(protoNames, typ) {
-						imp.Locals = append(imp.Locals, typ+"-Protocol.h")
-					} else {
-						imp.Protos = append(imp.Protos, typ)
-					}
-				}
-				typ = strings.Trim(typ, "<>")
-				if slices.Contains(protoNames, typ) {
-					imp.Locals = append(imp.Locals, typ+"-Protocol.h")
-				} else {
-					imp.Protos = append(imp.Protos, typ)
-				}
+				o.typeImports(&imp, typ, classNames, protoNames)
 			} else {
 				if (len(typ) > 0 && unicode.IsUpper(rune(typ[0])) && unicode.IsLetter(rune(typ[0]))) && strings.HasSuffix(typ, "*") {
 					typ = strings.Trim(typ, " *")
 					if slices.Contains(classNames, typ) {
-						imp.Locals = append(imp.Locals, typ+".h")
+						imp.Locals = append(imp.Locals, o.localHeader("class", typ))
 					} else {
 						imp.Classes = append(imp.Classes, typ)
 					}
@@ -949,9 +2953,9 @@ func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, er
 				typ := method.ArgumentType(i)
 				if (len(typ) > 0 && unicode.IsUpper(rune(typ[0])) && unicode.IsLetter(rune(typ[0]))) && strings.HasSuffix(typ, "*") { // or < >
 					if slices.Contains(classNames, typ) {
-						imp.Locals = append(imp.Locals, typ+".h")
+						imp.Locals = append(imp.Locals, o.localHeader("class", typ))
 					} else if slices.Contains(protoNames, strings.Trim(typ, "NSObject<>")) {
-						imp.Locals = append(imp.Locals, strings.Trim(typ, "NSObject<>")+"-Protocol.h")
+						imp.Locals = append(imp.Locals, o.localHeader("protocol", strings.Trim(typ, "NSObject<>")))
 					} else {
 						imp.Classes = append(imp.Classes, typ)
 					}
@@ -966,7 +2970,7 @@ func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, er
 				typ := method.ArgumentType(i)
 				if (len(typ) > 0 && unicode.IsUpper(rune(typ[0])) && unicode.IsLetter(rune(typ[0]))) && strings.HasSuffix(typ, "*") { // or < >
 					if slices.Contains(classNames, typ) {
-						imp.Locals = append(imp.Locals, typ+".h")
+						imp.Locals = append(imp.Locals, o.localHeader("class", typ))
 					} else {
 						imp.Classes = append(imp.Classes, typ)
 					}
@@ -976,16 +2980,25 @@ func (o *ObjC) processForwardDeclarations(m *macho.File) (map[string]Imports, er
 				}
 			}
 		}
-		imp.uniq(o.foundation)
+		imp.uniq(known)
 		imps[class.Name] = imp
 	}
 
-	return imps, nil
+	return imps
 }
 
 func (o *ObjC) scanFoundation() error {
+	if o.cache != nil && len(o.conf.FoundationCache) > 0 {
+		foundation, err := readFoundationCache(o.conf.FoundationCache, o.cache.UUID.String())
+		if err == nil {
+			o.foundation = foundation
+			return nil
+		}
+		log.Debugf("rescanning Foundation: %v", err)
+	}
 	o.foundation["classes"] = []string{}
 	o.foundation["protocols"] = []string{}
+	o.foundation["nsobject"] = []string{}
 	if o.cache != nil {
 		for _, name := range []string{"Foundation", "CoreFoundation"} {
 			img, err := o.cache.Image(name)
@@ -996,35 +3009,164 @@ func (o *ObjC) scanFoundation() error {
 			if err != nil {
 				return err
 			}
-
-			classes, err := m.GetObjCClasses()
+			if err := o.addFoundation(m); err != nil {
+				return err
+			}
+		}
+	} else if len(o.conf.FoundationPath) > 0 {
+		paths, err := foundationBinaries(o.conf.FoundationPath)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			m, closer, err := o.openFoundation(path)
 			if err != nil {
-				if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-					return err
-				}
+				return err
 			}
-			slices.SortStableFunc(classes, func(a, b objc.Class) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			for _, class := range classes {
-				o.foundation["classes"] = append(o.foundation["classes"], class.Name)
+			err = o.addFoundation(m)
+			closer.Close()
+			if err != nil {
+				return err
 			}
+		}
+	} else {
+		log.Warn("no DSC or Foundation path supplied (Foundation classes/protocols will be forward declared)")
+	}
+	slices.Sort(o.foundation["classes"])
+	slices.Sort(o.foundation["protocols"])
+	slices.Sort(o.foundation["nsobject"])
+	o.foundation["nsobject"] = slices.Compact(o.foundation["nsobject"])
+	if o.cache != nil && len(o.conf.FoundationCache) > 0 {
+		return writeFoundationCache(o.conf.FoundationCache, o.cache.UUID.String(), o.foundation)
+	}
+	return nil
+}
+
+type foundationCache struct {
+	UUID      string   `json:"uuid"`
+	Classes   []string `json:"classes"`
+	Protocols []string `json:"protocols"`
+	NSObject  []string `json:"nsobject,omitempty"` // the NSObject protocol's methods and properties (see nsobjectMembers)
+}
+
+// readFoundationCache reads the cached Foundation class/protocol names if they were generated from the DSC with the given UUID
+func readFoundationCache(path, uuid string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Foundation cache %s: %v", path, err)
+	}
+	var fc foundationCache
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("failed to parse Foundation cache %s: %v", path, err)
+	}
+	if fc.UUID != uuid {
+		return nil, fmt.Errorf("Foundation cache %s is for DSC %s (not %s)", path, fc.UUID, uuid)
+	}
+	foundation := map[string][]string{
+		"classes":   fc.Classes,
+		"protocols": fc.Protocols,
+	}
+	if len(fc.NSObject) > 0 { // not in the caches of older versions
+		foundation["nsobject"] = fc.NSObject
+	}
+	return foundation, nil
+}
+
+// writeFoundationCache writes the Foundation class/protocol names for the DSC with the given UUID
+func writeFoundationCache(path, uuid string, foundation map[string][]string) error {
+	data, err := json.Marshal(foundationCache{
+		UUID:      uuid,
+		Classes:   foundation["classes"],
+		Protocols: foundation["protocols"],
+		NSObject:  foundation["nsobject"],
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Foundation cache: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create Foundation cache folder: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o660); err != nil {
+		return fmt.Errorf("failed to write Foundation cache %s: %v", path, err)
+	}
+	return nil
+}
+
+// addFoundation adds a Foundation MachO's classes and protocols to the known Foundation symbols
+func (o *ObjC) addFoundation(m *macho.File) error {
+	classes, err := m.GetObjCClasses()
+	if err != nil {
+		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return err
+		}
+	}
+	for _, class := range classes {
+		o.foundation["classes"] = append(o.foundation["classes"], class.Name)
+	}
+	protos, err := m.GetObjCProtocols()
+	if err != nil {
+		if !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			return err
+		}
+	}
+	for _, proto := range protos {
+		o.foundation["protocols"] = append(o.foundation["protocols"], proto.Name)
+		if proto.Name == "NSObject" {
+			o.foundation["nsobject"] = append(o.foundation["nsobject"], o.nsobjectMembers(&proto)...)
+		}
+	}
+	return nil
+}
 
-			protos, err := m.GetObjCProtocols()
+// openFoundation opens an on-disk Foundation MachO (picking the same arch as the target MachO if it is universal)
+// and returns the closer of the opened file (the universal file of an arch slice)
+func (o *ObjC) openFoundation(path string) (*macho.File, io.Closer, error) {
+	fat, err := macho.OpenFat(path)
+	if err != nil {
+		if err == macho.ErrNotFat {
+			m, err := macho.Open(path)
 			if err != nil {
-				if !errors.Is(err, macho.ErrObjcSectionNotFound) {
-					return err
-				}
+				return nil, nil, err
 			}
-			slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
-				return cmp.Compare(a.Name, b.Name)
-			})
-			for _, proto := range protos {
-				o.foundation["protocols"] = append(o.foundation["protocols"], proto.Name)
+			return m, m, nil
+		}
+		return nil, nil, fmt.Errorf("failed to open Foundation binary %s: %v", path, err)
+	}
+	for _, arch := range fat.Arches {
+		if arch.CPU == o.file.CPU {
+			return arch.File, fat, nil
+		}
+	}
+	fat.Close()
+	return nil, nil, fmt.Errorf("Foundation binary %s does not contain arch %s", path, o.file.CPU)
+}
+
+// foundationBinaries returns the Foundation/CoreFoundation binaries for a binary, framework, SDK or root filesystem path
+func foundationBinaries(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Foundation path %s: %v", path, err)
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	var paths []string
+	for _, name := range []string{"Foundation", "CoreFoundation"} {
+		for _, candidate := range []string{
+			filepath.Join(path, name),
+			filepath.Join(path, name+".framework", name),
+			filepath.Join(path, name+".framework", "Versions", "Current", name),
+			filepath.Join(path, "System", "Library", "Frameworks", name+".framework", name),
+			filepath.Join(path, "System", "Library", "Frameworks", name+".framework", "Versions", "Current", name),
+		} {
+			if fi, err := os.Stat(candidate); err == nil && !fi.IsDir() {
+				paths = append(paths, candidate)
+				break
 			}
-			slices.Sort(o.foundation["classes"])
-			slices.Sort(o.foundation["protocols"])
 		}
 	}
-	return nil
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("failed to find Foundation or CoreFoundation binaries in %s", path)
+	}
+	return paths, nil
 }
@@ -0,0 +1,64 @@
+package macho
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// protocolAdopters returns the (cached) classes of the MachO (and its deps with Deps) adopting each protocol
+func (o *ObjC) protocolAdopters() map[string][]string {
+	if o.adopters != nil {
+		return o.adopters
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	var classes []objc.Class
+	for _, m := range ms {
+		cs, err := m.GetObjCClasses()
+		if err != nil && !o.noObjC(m, "classes", err) {
+			log.Errorf("failed to get classes of %s: %v", machoName(m), err)
+			continue
+		}
+		classes = append(classes, cs...)
+	}
+	o.adopters = adoptersByProtocol(classes)
+	return o.adopters
+}
+
+// adoptersByProtocol returns the (sorted and unique) names of the classes adopting each protocol
+func adoptersByProtocol(classes []objc.Class) map[string][]string {
+	adopters := make(map[string][]string)
+	for _, class := range classes {
+		for _, prot := range class.Protocols {
+			adopters[prot.Name] = append(adopters[prot.Name], class.Name)
+		}
+	}
+	for name, classes := range adopters {
+		slices.Sort(classes)
+		adopters[name] = slices.Compact(classes)
+	}
+	return adopters
+}
+
+// printAdopters prints the classes adopting a protocol after it (see ShowAdopters)
+func (o *ObjC) printAdopters(protocol string) {
+	classes := o.protocolAdopters()[protocol]
+	if len(classes) == 0 {
+		return
+	}
+	note := fmt.Sprintf("// adopted by: %s\n", strings.Join(classes, ", "))
+	if o.conf.Color {
+		quick.Highlight(os.Stdout, note+"\n", "objc", "terminal256", o.conf.Theme)
+	} else {
+		fmt.Println(note)
+	}
+}
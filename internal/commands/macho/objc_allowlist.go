@@ -0,0 +1,119 @@
+package macho
+
+import (
+	"slices"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// allowsSelector returns whether a method or property accessor selector is in the SelectorAllowlist (or there is none)
+func (o *ObjC) allowsSelector(sel string) bool {
+	return len(o.conf.SelectorAllowlist) == 0 || slices.Contains(o.conf.SelectorAllowlist, sel)
+}
+
+// allowedMethods returns a copy of methods with only the SelectorAllowlist's selectors
+func (o *ObjC) allowedMethods(methods []objc.Method) []objc.Method {
+	return slices.DeleteFunc(slices.Clone(methods), func(m objc.Method) bool {
+		return !o.allowsSelector(o.methodName(&m))
+	})
+}
+
+// allowedProperties returns a copy of props with only the properties with a SelectorAllowlist getter or setter
+func (o *ObjC) allowedProperties(props []objc.Property) []objc.Property {
+	return slices.DeleteFunc(slices.Clone(props), func(p objc.Property) bool {
+		getter, setter := propertyAccessors(&p)
+		return !o.allowsSelector(getter) && (len(setter) == 0 || !o.allowsSelector(setter))
+	})
+}
+
+// allowedClasses filters the classes' methods and properties to the SelectorAllowlist (for Headers)
+// and drops their ivars; classes with none are dropped unless they are the (local) superclass of a
+// retained class, whose header needs their @interface (kept as a shell)
+func (o *ObjC) allowedClasses(classes []objc.Class) []objc.Class {
+	if len(o.conf.SelectorAllowlist) == 0 {
+		return classes
+	}
+	supers := make(map[string]string, len(classes))
+	for _, class := range classes {
+		supers[class.Name] = class.SuperClass
+	}
+	keep := make(map[string]bool)
+	var allowed []objc.Class
+	for _, class := range classes {
+		class.Ivars = nil
+		class.ClassMethods = o.allowedMethods(class.ClassMethods)
+		class.InstanceMethods = o.allowedMethods(class.InstanceMethods)
+		class.Props = o.allowedProperties(class.Props)
+		if len(class.ClassMethods) > 0 || len(class.InstanceMethods) > 0 || len(class.Props) > 0 {
+			for name := class.Name; len(name) > 0 && !keep[name]; name = supers[name] {
+				keep[name] = true
+			}
+		}
+		allowed = append(allowed, class)
+	}
+	return slices.DeleteFunc(allowed, func(c objc.Class) bool {
+		return !keep[c.Name]
+	})
+}
+
+// allowedProtocols filters the protocols' methods and properties to the SelectorAllowlist (for Headers);
+// protocols with none are dropped unless a retained class or protocol adopts them (kept as a shell)
+func (o *ObjC) allowedProtocols(protos []objc.Protocol, classes []objc.Class) []objc.Protocol {
+	if len(o.conf.SelectorAllowlist) == 0 {
+		return protos
+	}
+	adopts := make(map[string][]objc.Protocol, len(protos))
+	for _, proto := range protos {
+		adopts[proto.Name] = proto.Prots
+	}
+	keep := make(map[string]bool)
+	var mark func(name string)
+	mark = func(name string) {
+		if keep[name] {
+			return
+		}
+		keep[name] = true
+		for _, prot := range adopts[name] {
+			mark(prot.Name)
+		}
+	}
+	for _, class := range classes {
+		for _, prot := range class.Protocols {
+			mark(prot.Name)
+		}
+	}
+	var allowed []objc.Protocol
+	for _, proto := range protos {
+		proto.InstanceMethods = o.allowedMethods(proto.InstanceMethods)
+		proto.ClassMethods = o.allowedMethods(proto.ClassMethods)
+		proto.OptionalInstanceMethods = o.allowedMethods(proto.OptionalInstanceMethods)
+		proto.OptionalClassMethods = o.allowedMethods(proto.OptionalClassMethods)
+		proto.InstanceProperties = o.allowedProperties(proto.InstanceProperties)
+		if len(proto.InstanceMethods) > 0 || len(proto.ClassMethods) > 0 || len(proto.OptionalInstanceMethods) > 0 ||
+			len(proto.OptionalClassMethods) > 0 || len(proto.InstanceProperties) > 0 {
+			mark(proto.Name)
+		}
+		allowed = append(allowed, proto)
+	}
+	return slices.DeleteFunc(allowed, func(p objc.Protocol) bool {
+		return !keep[p.Name]
+	})
+}
+
+// allowedCategories filters the categories' methods and properties to the SelectorAllowlist (for Headers)
+// and drops the categories with none
+func (o *ObjC) allowedCategories(cats []objc.Category) []objc.Category {
+	if len(o.conf.SelectorAllowlist) == 0 {
+		return cats
+	}
+	var allowed []objc.Category
+	for _, cat := range cats {
+		cat.ClassMethods = o.allowedMethods(cat.ClassMethods)
+		cat.InstanceMethods = o.allowedMethods(cat.InstanceMethods)
+		cat.Properties = o.allowedProperties(cat.Properties)
+		if len(cat.ClassMethods) > 0 || len(cat.InstanceMethods) > 0 || len(cat.Properties) > 0 {
+			allowed = append(allowed, cat)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,161 @@
+package macho
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/swift"
+)
+
+// CategoryMethod is a method a category adds to its class
+type CategoryMethod struct {
+	Category string `json:"category"` // empty for class extensions
+	Selector string `json:"selector"` // +/- prefixed
+	Imp      uint64 `json:"imp,omitempty"`
+}
+
+// categoryMethod is a category-added method with its provenance
+type categoryMethod struct {
+	objc.Method
+	Category string
+	IsClass  bool
+}
+
+// from returns the provenance comment of a category-added method
+func (m categoryMethod) from() string {
+	if len(m.Category) == 0 {
+		return "// from class extension"
+	}
+	return "// from category " + m.Category
+}
+
+// DumpCategoryMethods outputs only the methods the categories (incl. those of Deps) add to the ObjC classes
+// matching a given name or pattern, each tagged with the category it comes from
+func (o *ObjC) DumpCategoryMethods(pattern string) error {
+	re, err := o.compilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	added := make(map[string][]categoryMethod)
+	for _, m := range ms {
+		cats, err := m.GetObjCCategories()
+		if err != nil && !o.noObjC(m, "categories", err) {
+			return err
+		}
+		o.resolveCategoryClasses(m, cats)
+		for _, cat := range cats {
+			cname := categoryClassName(cat)
+			if cname != pattern && !re.MatchString(cname) || o.skipClass(cname) {
+				continue
+			}
+			if o.conf.Addrs {
+				o.resolveCategoryImps(m, &cat)
+			}
+			added[cname] = append(added[cname], o.categoryMethods(&cat)...)
+		}
+	}
+	classes := make([]string, 0, len(added))
+	for class, meths := range added {
+		o.sortCategoryMethods(meths)
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+
+	if o.conf.JSON {
+		out := make(map[string][]CategoryMethod, len(added))
+		for class, meths := range added {
+			for _, meth := range meths {
+				sel := "-" + meth.Name
+				if meth.IsClass {
+					sel = "+" + meth.Name
+				}
+				out[class] = append(out[class], CategoryMethod{Category: meth.Category, Selector: sel, Imp: meth.ImpVMAddr})
+			}
+		}
+		dat, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal category methods: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, class := range classes {
+		out := swift.DemangleBlob(o.dumpCategoryMethods(class, added[class], o.conf.Addrs))
+		if o.conf.Color {
+			quick.Highlight(os.Stdout, out+"\n", "objc", "terminal256", o.conf.Theme)
+		} else {
+			fmt.Println(out)
+		}
+	}
+	return nil
+}
+
+// categoryMethods returns the class and instance methods a category adds to its class
+func (o *ObjC) categoryMethods(cat *objc.Category) []categoryMethod {
+	meths := make([]categoryMethod, 0, len(cat.ClassMethods)+len(cat.InstanceMethods))
+	for _, meth := range cat.ClassMethods {
+		meths = append(meths, categoryMethod{Method: meth, Category: cat.Name, IsClass: true})
+	}
+	for _, meth := range cat.InstanceMethods {
+		meths = append(meths, categoryMethod{Method: meth, Category: cat.Name})
+	}
+	for i := range meths {
+		if len(meths[i].Name) == 0 {
+			meths[i].Name = o.selectorName(meths[i].NameVMAddr)
+		}
+	}
+	return meths
+}
+
+// sortCategoryMethods sorts a class's category-added methods: class methods first, by category
+// (and by selector with SortMethods, otherwise in their method list order)
+func (o *ObjC) sortCategoryMethods(meths []categoryMethod) {
+	slices.SortStableFunc(meths, func(a, b categoryMethod) int {
+		if a.IsClass != b.IsClass {
+			if a.IsClass {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(a.Category, b.Category); c != 0 || !o.conf.SortMethods {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// dumpCategoryMethods returns the (sorted) methods categories add to a class as an interface of the class
+func (o *ObjC) dumpCategoryMethods(class string, meths []categoryMethod, addrs bool) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "@interface %s (category methods)\n", class)
+	for i, meth := range meths {
+		if i == 0 || meth.IsClass != meths[i-1].IsClass {
+			if meth.IsClass {
+				out.WriteString("/* class methods */\n")
+			} else {
+				out.WriteString("/* instance methods */\n")
+			}
+		}
+		if addrs && meth.ImpVMAddr != 0 {
+			fmt.Fprintf(&out, "// %#x\n", meth.ImpVMAddr)
+		}
+		prefix := "-"
+		if meth.IsClass {
+			prefix = "+"
+		}
+		fmt.Fprintf(&out, "%s %s %s\n", prefix, o.methodDecl(&meth.Method, meth.IsClass), meth.from())
+	}
+	out.WriteString("@end\n")
+	return out.String()
+}
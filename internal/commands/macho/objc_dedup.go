@@ -0,0 +1,71 @@
+package macho
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// dedupProtocols returns the protocols w/o the ones whose content is identical to an earlier one (see protocolSignature),
+// and the names of the dropped protocols keyed by the Ptr of the protocol they are identical to
+// NOTE: marker protocols (w/o methods or properties) are never collapsed as they only differ by name
+func (o *ObjC) dedupProtocols(protos []objc.Protocol) ([]objc.Protocol, map[uint64][]string) {
+	var unique []objc.Protocol
+	aliases := make(map[uint64][]string)
+	first := make(map[string]uint64) // signature -> Ptr of the protocol printed for it
+	for _, proto := range protos {
+		if isMarkerProtocol(&proto) { // only dropped if it's the same protocol
+			if !slices.ContainsFunc(unique, func(p objc.Protocol) bool { return p.Ptr == proto.Ptr }) {
+				unique = append(unique, proto)
+			}
+			continue
+		}
+		sig := o.protocolSignature(&proto)
+		if ptr, ok := first[sig]; ok {
+			if ptr != proto.Ptr && !slices.Contains(aliases[ptr], proto.Name) {
+				aliases[ptr] = append(aliases[ptr], proto.Name)
+			}
+			continue
+		}
+		first[sig] = proto.Ptr
+		unique = append(unique, proto)
+	}
+	return unique, aliases
+}
+
+// isMarkerProtocol returns whether p has no methods or properties (it may still adopt other protocols)
+func isMarkerProtocol(p *objc.Protocol) bool {
+	return len(p.ClassMethods) == 0 && len(p.InstanceMethods) == 0 && len(p.OptionalClassMethods) == 0 &&
+		len(p.OptionalInstanceMethods) == 0 && len(p.InstanceProperties) == 0
+}
+
+// protocolSignature returns a protocol's content, i.e. its (order independent) adopted protocols, methods and properties
+func (o *ObjC) protocolSignature(p *objc.Protocol) string {
+	var parts []string
+	for _, prot := range p.Prots {
+		parts = append(parts, "<"+prot.Name)
+	}
+	for _, list := range []struct {
+		kind    string
+		methods []objc.Method
+	}{
+		{"+", p.ClassMethods},
+		{"-", p.InstanceMethods},
+		{"?+", p.OptionalClassMethods},
+		{"?-", p.OptionalInstanceMethods},
+	} {
+		for _, meth := range list.methods {
+			name := meth.Name
+			if len(name) == 0 {
+				name = o.selectorName(meth.NameVMAddr)
+			}
+			parts = append(parts, list.kind+name+" "+meth.Types)
+		}
+	}
+	for _, prop := range p.InstanceProperties {
+		parts = append(parts, "@"+prop.Name+" "+prop.EncodedAttributes)
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "\n")
+}
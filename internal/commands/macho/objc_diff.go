@@ -0,0 +1,181 @@
+package macho
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// ObjcDiff is the ObjC class and protocol changes of an image between two versions (e.g. of a DSC)
+type ObjcDiff struct {
+	Image     string      `json:"image"`
+	Status    string      `json:"status,omitempty"` // added or removed (if the image is only in one version)
+	Classes   ObjcSetDiff `json:"classes"`
+	Protocols ObjcSetDiff `json:"protocols"`
+}
+
+// ObjcSetDiff is the added, removed and changed classes (or protocols) of an ObjcDiff
+type ObjcSetDiff struct {
+	Added   []string         `json:"added,omitempty"`
+	Removed []string         `json:"removed,omitempty"`
+	Changed []ObjcMemberDiff `json:"changed,omitempty"`
+}
+
+// ObjcMemberDiff is the superclass and method changes of a class (or protocol) in both versions
+type ObjcMemberDiff struct {
+	Name          string   `json:"name"`
+	OldSuperclass string   `json:"old_superclass,omitempty"`
+	NewSuperclass string   `json:"new_superclass,omitempty"`
+	Added         []string `json:"added,omitempty"`   // +/- prefixed selectors
+	Removed       []string `json:"removed,omitempty"` // +/- prefixed selectors
+}
+
+func (d ObjcDiff) String() string {
+	var out strings.Builder
+	out.WriteString(d.Image)
+	if len(d.Status) > 0 {
+		fmt.Fprintf(&out, " (%s)", d.Status)
+	}
+	out.WriteString("\n")
+	for _, set := range []struct {
+		title string
+		diff  ObjcSetDiff
+	}{{"Classes", d.Classes}, {"Protocols", d.Protocols}} {
+		if len(set.diff.Added) == 0 && len(set.diff.Removed) == 0 && len(set.diff.Changed) == 0 {
+			continue
+		}
+		fmt.Fprintf(&out, "\n%s:\n", set.title)
+		for _, name := range set.diff.Added {
+			fmt.Fprintf(&out, "  + %s\n", name)
+		}
+		for _, name := range set.diff.Removed {
+			fmt.Fprintf(&out, "  - %s\n", name)
+		}
+		for _, c := range set.diff.Changed {
+			fmt.Fprintf(&out, "  ~ %s", c.Name)
+			if c.OldSuperclass != c.NewSuperclass {
+				fmt.Fprintf(&out, " (superclass %s -> %s)", c.OldSuperclass, c.NewSuperclass)
+			}
+			out.WriteString("\n")
+			for _, sel := range c.Added {
+				fmt.Fprintf(&out, "      + %s\n", sel)
+			}
+			for _, sel := range c.Removed {
+				fmt.Fprintf(&out, "      - %s\n", sel)
+			}
+		}
+	}
+	return out.String()
+}
+
+// objcMembers is the superclass and (sorted, +/- prefixed) selectors of an ObjC class or protocol
+type objcMembers struct {
+	Superclass string
+	Selectors  []string
+}
+
+// DiffObjC returns the ObjC class, protocol and method changes of an image from a (old) to b (new)
+// (either may be nil if the image is only in one version, e.g. a framework added in the newer DSC)
+func DiffObjC(image string, a, b *ObjC) (*ObjcDiff, error) {
+	oldClasses, oldProtos, err := a.members()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get old ObjC: %v", err)
+	}
+	newClasses, newProtos, err := b.members()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new ObjC: %v", err)
+	}
+	diff := &ObjcDiff{
+		Image:     image,
+		Classes:   diffObjcMembers(oldClasses, newClasses),
+		Protocols: diffObjcMembers(oldProtos, newProtos),
+	}
+	switch {
+	case a == nil && b != nil:
+		diff.Status = "added"
+	case a != nil && b == nil:
+		diff.Status = "removed"
+	}
+	return diff, nil
+}
+
+// members returns the ObjC classes and protocols of the MachO by name (or none if o is nil)
+func (o *ObjC) members() (map[string]objcMembers, map[string]objcMembers, error) {
+	classes := make(map[string]objcMembers)
+	protos := make(map[string]objcMembers)
+	if o == nil {
+		return classes, protos, nil
+	}
+	selectors := func(sels []string, classMethods, instanceMethods []objc.Method) []string {
+		for _, meth := range classMethods {
+			sels = append(sels, "+"+o.methodName(&meth))
+		}
+		for _, meth := range instanceMethods {
+			sels = append(sels, "-"+o.methodName(&meth))
+		}
+		slices.Sort(sels)
+		return slices.Compact(sels)
+	}
+	cs, err := o.file.GetObjCClasses()
+	if err != nil && !o.noObjC(o.file, "classes", err) {
+		return nil, nil, err
+	}
+	for _, c := range cs {
+		if _, ok := classes[c.Name]; !ok && !o.skipClass(c.Name) {
+			classes[c.Name] = objcMembers{Superclass: c.SuperClass, Selectors: selectors(nil, c.ClassMethods, c.InstanceMethods)}
+		}
+	}
+	ps, err := o.file.GetObjCProtocols()
+	if err != nil && !o.noObjC(o.file, "protocols", err) {
+		return nil, nil, err
+	}
+	for _, p := range ps {
+		if _, ok := protos[p.Name]; !ok {
+			sels := selectors(nil, p.ClassMethods, p.InstanceMethods)
+			protos[p.Name] = objcMembers{Selectors: selectors(sels, p.OptionalClassMethods, p.OptionalInstanceMethods)}
+		}
+	}
+	return classes, protos, nil
+}
+
+// diffObjcMembers returns the (name sorted) added, removed and changed classes (or protocols) from oldMembers to newMembers
+func diffObjcMembers(oldMembers, newMembers map[string]objcMembers) ObjcSetDiff {
+	var diff ObjcSetDiff
+	for name, nm := range newMembers {
+		om, ok := oldMembers[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		change := ObjcMemberDiff{Name: name}
+		for _, sel := range nm.Selectors {
+			if _, found := slices.BinarySearch(om.Selectors, sel); !found {
+				change.Added = append(change.Added, sel)
+			}
+		}
+		for _, sel := range om.Selectors {
+			if _, found := slices.BinarySearch(nm.Selectors, sel); !found {
+				change.Removed = append(change.Removed, sel)
+			}
+		}
+		if om.Superclass != nm.Superclass {
+			change.OldSuperclass, change.NewSuperclass = om.Superclass, nm.Superclass
+		}
+		if len(change.Added) > 0 || len(change.Removed) > 0 || om.Superclass != nm.Superclass {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for name := range oldMembers {
+		if _, ok := newMembers[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.SortFunc(diff.Changed, func(a, b ObjcMemberDiff) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return diff
+}
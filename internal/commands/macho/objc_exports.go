@@ -0,0 +1,70 @@
+package macho
+
+import (
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/trie"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// skipUnexported returns true if ExportedOnly is set and m doesn't export the class (i.e. it is internal)
+func (o *ObjC) skipUnexported(m *macho.File, name string) bool {
+	return o.conf.ExportedOnly && !o.exportedClasses(m)[name]
+}
+
+// exportedClasses returns the names of the classes m has an _OBJC_CLASS_$_ export for (cached per MachO)
+func (o *ObjC) exportedClasses(m *macho.File) map[string]bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if classes, ok := o.exported[m]; ok {
+		return classes
+	}
+	classes := make(map[string]bool)
+	for _, name := range o.exportNames(m) {
+		if class, ok := strings.CutPrefix(name, "_OBJC_CLASS_$_"); ok {
+			classes[class] = true
+		}
+	}
+	if o.exported == nil {
+		o.exported = make(map[*macho.File]map[string]bool)
+	}
+	o.exported[m] = classes
+	return classes
+}
+
+// exportNames returns the symbols in m's export trie (or dyld info exports)
+func (o *ObjC) exportNames(m *macho.File) []string {
+	var names []string
+	if o.isCacheImage(m) {
+		img, err := o.cache.Image(m.DylibID().Name)
+		if err != nil {
+			log.Warnf("failed to get cache image of %s: %v", machoName(m), err)
+			return nil
+		}
+		if err := img.ParsePublicSymbols(false); err != nil {
+			log.Debugf("failed to parse all the public symbols of %s: %v", img.Name, err)
+		}
+		for _, sym := range img.PublicSymbols {
+			if sym.Kind == dyld.EXPORT {
+				names = append(names, sym.Name)
+			}
+		}
+		return names
+	}
+	var exports []trie.TrieExport
+	var err error
+	if m.DyldExportsTrie() != nil {
+		exports, err = m.DyldExports()
+	} else {
+		exports, err = m.GetExports()
+	}
+	if err != nil {
+		log.Warnf("failed to read the exports of %s: %v", machoName(m), err)
+	}
+	for _, exp := range exports {
+		names = append(names, exp.Name)
+	}
+	return names
+}
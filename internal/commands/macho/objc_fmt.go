@@ -0,0 +1,585 @@
+package macho
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// methodDecl returns the decoded ObjC method declaration (without the +/- prefix)
+func methodDecl(m *objc.Method, isClass bool) string {
+	rtype := returnDeclType(m, isClass)
+	nargs := m.NumberOfArguments()
+	if nargs <= 2 {
+		return fmt.Sprintf("(%s)%s;", rtype, m.Name)
+	}
+	parts := strings.Split(m.Name, ":")
+	if len(parts) > 1 { // method has arguments based on SEL having ':'
+		var decl []string
+		for idx, part := range parts {
+			if len(part) == 0 || idx >= nargs-2 {
+				break
+			}
+			decl = append(decl, fmt.Sprintf("%s:(%s)%s", part, declType(m.ArgumentType(idx+3)), lastCapitalizedPart(part)))
+		}
+		return fmt.Sprintf("(%s)%s;", rtype, strings.Join(decl, " "))
+	}
+	// method has no arguments based on SEL not having ':'
+	return fmt.Sprintf("(%s)%s;", rtype, m.Name)
+}
+
+// returnDeclType returns the declared return type of a method (init family instance methods return instancetype)
+func returnDeclType(m *objc.Method, isClass bool) string {
+	rtype := declType(m.ReturnType())
+	if !isClass && rtype == "id" && isInitFamily(m.Name) {
+		rtype = "instancetype"
+	}
+	return rtype
+}
+
+// declType returns a decoded type as it is written in a declaration (protocol qualified objects are id<Proto>)
+func declType(typ string) string {
+	if rest, ok := strings.CutPrefix(typ, "<"); ok {
+		return "id<" + strings.TrimSuffix(rest, " *")
+	}
+	return typ
+}
+
+// isInitFamily returns whether a selector is in the init method family (i.e. returns an instance of the receiver)
+func isInitFamily(sel string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimLeft(sel, "_"), "init")
+	return ok && (len(rest) == 0 || rest[0] == ':' || unicode.IsUpper(rune(rest[0])))
+}
+
+// lastCapitalizedPart returns the lowercased trailing capitalized word of a selector part (used as the arg name)
+func lastCapitalizedPart(s string) string {
+	start := len(s)
+	for i := len(s) - 1; i >= 0; i-- {
+		if unicode.IsUpper(rune(s[i])) {
+			start = i
+		} else if start != len(s) {
+			break
+		}
+	}
+	if start == len(s) {
+		return s
+	}
+	return strings.ToLower(s[start:])
+}
+
+// dumpMethods returns a labeled block of ObjC methods where isClass selects the '+' (class) or '-' (instance) prefix
+// and super is the owner's superclass (whose methods are marked as overridden with MarkOverrides, if known)
+func (o *ObjC) dumpMethods(owner, super, label string, methods []objc.Method, isClass, verbose, addrs bool) string {
+	if len(methods) == 0 {
+		return ""
+	}
+	prefix := "-"
+	if isClass {
+		prefix = "+"
+	}
+	swiftStyle := o.conf.SwiftStyle && !o.conf.Headers
+	if o.conf.SortMethods { // sort a copy so the method list order is preserved for other dumps
+		methods = slices.Clone(methods)
+		for i := range methods {
+			if len(methods[i].Name) == 0 {
+				methods[i].Name = o.selectorName(methods[i].NameVMAddr)
+			}
+		}
+		slices.SortStableFunc(methods, func(a, b objc.Method) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+	}
+	s := bytes.NewBufferString(fmt.Sprintf("/* %s */\n", label))
+	for _, meth := range methods {
+		if len(meth.Name) == 0 {
+			meth.Name = o.selectorName(meth.NameVMAddr)
+		}
+		if !addrs && strings.HasPrefix(meth.Name, ".cxx_") {
+			continue
+		}
+		var override string
+		if o.conf.MarkOverrides && o.isOverride(super, prefix+meth.Name) {
+			override = " // override"
+		}
+		if verbose {
+			if addrs && meth.ImpVMAddr != 0 {
+				s.WriteString(fmt.Sprintf("// %#x\n", meth.ImpVMAddr))
+			}
+			if decl, ok := swiftDecl(&meth, isClass); swiftStyle && ok {
+				s.WriteString(decl + override + "\n")
+			} else {
+				s.WriteString(fmt.Sprintf("%s %s%s\n", prefix, o.methodDecl(&meth, isClass), override))
+			}
+		} else {
+			if name, ok := swiftName(meth.Name); swiftStyle && ok {
+				s.WriteString(fmt.Sprintf("%s %s.%s%s\n", prefix, owner, name, override))
+			} else {
+				s.WriteString(fmt.Sprintf("%s[%s %s];%s\n", prefix, owner, meth.Name, override))
+			}
+		}
+	}
+	return s.String()
+}
+
+var swiftPrepositions = []string{"With", "For", "From", "To", "At", "In", "By", "On", "Using"}
+
+// swiftSelector splits an ObjC selector into its (best-effort) Swift-imported base name and argument labels
+//
+//	e.g. tableView:numberOfRowsInSection: => tableView(_:numberOfRowsInSection:) and initWithFrame: => init(frame:)
+func swiftSelector(sel string) (string, []string, bool) {
+	if !strings.Contains(sel, ":") {
+		return sel, nil, len(sel) > 0
+	}
+	if !strings.HasSuffix(sel, ":") {
+		return "", nil, false
+	}
+	parts := strings.Split(strings.TrimSuffix(sel, ":"), ":")
+	for _, part := range parts {
+		if len(part) == 0 {
+			return "", nil, false // anonymous args are ambiguous
+		}
+	}
+	first := parts[0]
+	base, label := first, "_"
+	if rest, ok := strings.CutPrefix(first, "initWith"); ok && len(rest) > 0 && unicode.IsUpper(rune(rest[0])) {
+		base, label = "init", lowerCamel(rest)
+	} else {
+		split := -1
+		for _, prep := range swiftPrepositions { // fold the trailing prepositional phrase into the first label
+			if idx := strings.LastIndex(first, prep); idx > split && idx+len(prep) < len(first) && unicode.IsUpper(rune(first[idx+len(prep)])) {
+				split = idx
+			}
+		}
+		if split > 0 {
+			base, label = first[:split], lowerCamel(first[split:])
+		}
+	}
+	return base, append([]string{label}, parts[1:]...), true
+}
+
+// swiftName returns the Swift-style name of an ObjC selector (e.g. tableView(_:numberOfRowsInSection:))
+func swiftName(sel string) (string, bool) {
+	base, labels, ok := swiftSelector(sel)
+	if !ok {
+		return "", false
+	}
+	var name strings.Builder
+	name.WriteString(base + "(")
+	for _, label := range labels {
+		name.WriteString(label + ":")
+	}
+	name.WriteString(")")
+	return name.String(), true
+}
+
+// swiftDecl returns the Swift-style declaration of an ObjC method
+func swiftDecl(m *objc.Method, isClass bool) (string, bool) {
+	base, labels, ok := swiftSelector(m.Name)
+	if !ok || len(labels) != max(m.NumberOfArguments()-2, 0) {
+		return "", false
+	}
+	var args []string
+	for idx, part := range strings.Split(strings.TrimSuffix(m.Name, ":"), ":") {
+		if idx >= len(labels) {
+			break
+		}
+		arg := lastCapitalizedPart(part)
+		if labels[idx] == arg {
+			args = append(args, fmt.Sprintf("%s: %s", arg, swiftType(m.ArgumentType(idx+3))))
+		} else {
+			args = append(args, fmt.Sprintf("%s %s: %s", labels[idx], arg, swiftType(m.ArgumentType(idx+3))))
+		}
+	}
+	decl := fmt.Sprintf("%s(%s)", base, strings.Join(args, ", "))
+	if base == "init" {
+		return decl, true
+	}
+	decl = "func " + decl
+	if isClass {
+		decl = "class " + decl
+	}
+	if rtype := swiftType(m.ReturnType()); rtype != "Void" {
+		decl += " -> " + rtype
+	}
+	return decl, true
+}
+
+var swiftTypes = map[string]string{
+	"void":               "Void",
+	"id":                 "Any",
+	"id /* block */":     "Any",
+	"BOOL":               "Bool",
+	"Class":              "AnyClass",
+	"SEL":                "Selector",
+	"char":               "CChar",
+	"unsigned char":      "CUnsignedChar",
+	"short":              "CShort",
+	"unsigned short":     "CUnsignedShort",
+	"int":                "Int32",
+	"unsigned int":       "UInt32",
+	"long":               "Int",
+	"unsigned long":      "UInt",
+	"long long":          "Int",
+	"unsigned long long": "UInt",
+	"float":              "Float",
+	"double":             "Double",
+	"char *":             "UnsafeMutablePointer<CChar>",
+	"void *":             "UnsafeMutableRawPointer",
+	"NSString *":         "String",
+	"NSArray *":          "[Any]",
+	"NSDictionary *":     "[AnyHashable : Any]",
+	"NSSet *":            "Set<AnyHashable>",
+	"NSData *":           "Data",
+	"NSDate *":           "Date",
+	"NSURL *":            "URL",
+	"NSError *":          "Error",
+}
+
+// swiftType returns the Swift-imported form of a decoded ObjC type
+func swiftType(typ string) string {
+	typ = strings.TrimSpace(typ)
+	if t, ok := swiftTypes[typ]; ok {
+		return t
+	}
+	typ = strings.TrimPrefix(typ, "struct ")
+	if t, ok := strings.CutSuffix(typ, " **"); ok {
+		return fmt.Sprintf("UnsafeMutablePointer<%s?>", swiftType(t+" *"))
+	}
+	if t, ok := strings.CutSuffix(typ, " *"); ok {
+		return t
+	}
+	return typ
+}
+
+// lowerCamel lowercases the leading word (or acronym) of a Swift label (e.g. URLString => urlString)
+func lowerCamel(s string) string {
+	r := []rune(s)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break // keep the first letter of the next word
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// joinBlocks joins non-empty output blocks separated by a blank line
+func joinBlocks(blocks ...string) string {
+	var out []string
+	for _, b := range blocks {
+		if len(b) > 0 {
+			out = append(out, b)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// ivarAccess returns the access qualifier of a class's ivar as far as it is known (see hiddenIvars):
+// @private and @package ivars can't be told apart from each other, nor @public ones from the default @protected
+func (o *ObjC) ivarAccess(class, ivar string) string {
+	if o.hidden[class+"."+ivar] {
+		return "@private // or @package"
+	}
+	return "@protected"
+}
+
+// dumpClass returns the ObjC interface declaration for a class
+func (o *ObjC) dumpClass(c *objc.Class, verbose, addrs bool) string {
+	var iVars string
+	var props string
+
+	if o.conf.HideNSObject {
+		c = o.hideNSObjectMembers(c)
+	}
+
+	var subClass string
+	if c.ReadOnlyData.Flags.IsRoot() {
+		subClass = "<ROOT>"
+	} else if len(c.SuperClass) > 0 {
+		subClass = c.SuperClass
+	}
+
+	class := fmt.Sprintf("@interface %s : %s", c.Name, subClass)
+
+	if len(c.Protocols) > 0 {
+		var subProts []string
+		for _, prot := range c.Protocols {
+			subProts = append(subProts, prot.Name)
+		}
+		class += fmt.Sprintf("<%s>", strings.Join(subProts, ", "))
+	}
+	if len(c.Ivars) > 0 {
+		class += " {"
+	}
+	if verbose {
+		var comment string
+		if addrs {
+			comment += fmt.Sprintf(" // %#x", c.ClassPtr)
+		}
+		if c.IsSwift() {
+			if len(comment) > 0 {
+				comment += " (Swift)"
+			} else {
+				comment += " // (Swift)"
+			}
+		}
+		class += comment
+	}
+	if len(c.Ivars) > 0 {
+		s := bytes.NewBufferString("")
+		w := tabwriter.NewWriter(s, 0, 0, 1, ' ', 0)
+		if addrs {
+			fmt.Fprintf(w, "\n  /* instance variables */\t// +size   offset\n")
+		} else {
+			fmt.Fprintf(w, "\n  /* instance variables */\n")
+		}
+		access := "@protected" // the default ivar scope
+		for _, ivar := range c.Ivars {
+			if scope := o.ivarAccess(c.Name, ivar.Name); scope != access {
+				fmt.Fprintf(w, "  %s\n", scope)
+				access = scope
+			}
+			if verbose {
+				if addrs {
+					fmt.Fprintf(w, "  %s\n", ivar.WithAddrs())
+				} else {
+					fmt.Fprintf(w, "  %s\n", ivar.Verbose())
+				}
+			} else {
+				fmt.Fprintf(w, "  %s\n", &ivar)
+			}
+		}
+		w.Flush()
+		s.WriteString("}\n\n")
+		iVars = s.String()
+	} else {
+		iVars = "\n"
+	}
+	if len(c.Props) > 0 {
+		if len(c.Ivars) == 0 {
+			props += "\n"
+		}
+		props += o.dumpProperties(c.Props, verbose)
+		props += "\n"
+	}
+
+	return fmt.Sprintf(
+		"%s%s%s%s@end\n",
+		class,
+		iVars,
+		props,
+		joinBlocks(
+			o.dumpMethods(c.Name, c.SuperClass, "class methods", c.ClassMethods, true, verbose, addrs),
+			o.dumpMethods(c.Name, c.SuperClass, "instance methods", c.InstanceMethods, false, verbose, addrs),
+			o.dumpKVCKeys(c),
+		))
+}
+
+// dumpProperties returns the @property declarations (with their accessor selectors if PropertyAccessors is set)
+func (o *ObjC) dumpProperties(props []objc.Property, verbose bool) string {
+	var out string
+	for _, prop := range props {
+		if verbose {
+			out += fmt.Sprintf("@property %s%s%s;", prop.Attributes(), prop.Type(), prop.Name)
+		} else {
+			out += fmt.Sprintf("@property (%s) %s;", prop.EncodedAttributes, prop.Name)
+		}
+		if o.conf.PropertyAccessors {
+			getter, setter := propertyAccessors(&prop)
+			if len(setter) > 0 {
+				out += fmt.Sprintf(" // getter: %s, setter: %s", getter, setter)
+			} else {
+				out += fmt.Sprintf(" // getter: %s", getter)
+			}
+		}
+		out += "\n"
+	}
+	return out
+}
+
+// propertyAccessors returns a property's getter and setter selectors (the setter is empty for readonly properties)
+func propertyAccessors(prop *objc.Property) (getter, setter string) {
+	getter = prop.Name
+	if len(prop.Name) > 0 {
+		setter = "set" + strings.ToUpper(prop.Name[:1]) + prop.Name[1:] + ":"
+	}
+	for _, attr := range strings.Split(prop.EncodedAttributes, ",") {
+		switch {
+		case attr == "R":
+			setter = ""
+		case strings.HasPrefix(attr, "G"):
+			getter = attr[1:]
+		case strings.HasPrefix(attr, "S"):
+			setter = attr[1:]
+		}
+	}
+	return getter, setter
+}
+
+// kvcKeys returns the keys a class is KVC compliant for (derived from its properties and getter-like instance methods)
+func kvcKeys(c *objc.Class) []string {
+	var keys []string
+	for _, prop := range c.Props {
+		keys = append(keys, prop.Name)
+	}
+	for _, meth := range c.InstanceMethods {
+		if meth.NumberOfArguments() != 2 || meth.ReturnType() == "void" {
+			continue // getters take no args and return a value
+		}
+		if key := kvcGetterKey(meth.Name); len(key) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// kvcGetterKey returns the key for a KVC getter selector (<key>, _<key>, get<Key>, is<Key> or countOf<Key>)
+func kvcGetterKey(sel string) string {
+	sel = strings.TrimPrefix(sel, "_")
+	if len(sel) == 0 || !unicode.IsLower(rune(sel[0])) {
+		return ""
+	}
+	for _, prefix := range []string{"get", "is", "countOf"} {
+		if key, ok := strings.CutPrefix(sel, prefix); ok && len(key) > 0 && unicode.IsUpper(rune(key[0])) {
+			return strings.ToLower(key[:1]) + key[1:]
+		}
+	}
+	for _, r := range sel {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return ""
+		}
+	}
+	return sel
+}
+
+// dumpKVCKeys returns a comment block listing a class's KVC keys
+func (o *ObjC) dumpKVCKeys(c *objc.Class) string {
+	if !o.conf.KVCKeys || o.conf.Headers {
+		return ""
+	}
+	keys := kvcKeys(c)
+	if len(keys) == 0 {
+		return ""
+	}
+	s := bytes.NewBufferString("/* KVC keys */\n")
+	for _, key := range keys {
+		s.WriteString(fmt.Sprintf("//   %s\n", key))
+	}
+	return s.String()
+}
+
+// dumpCategory returns the ObjC interface declaration for a category
+func (o *ObjC) dumpCategory(c *objc.Category, verbose, addrs bool) string {
+	var protos string
+	if len(c.Protocols) > 0 {
+		var prots []string
+		for _, prot := range c.Protocols {
+			prots = append(prots, prot.Name)
+		}
+		protos += fmt.Sprintf(" <%s>", strings.Join(prots, ", "))
+	}
+
+	var className string
+	if c.Class != nil {
+		className = c.Class.Name + " "
+	}
+
+	var cat string
+	if verbose {
+		var comment string
+		if addrs {
+			comment += fmt.Sprintf(" // %#x", c.VMAddr)
+		}
+		if c.Class != nil && c.Class.IsSwift() {
+			if len(comment) > 0 {
+				comment += " (Swift)"
+			} else {
+				comment += " // (Swift)"
+			}
+		}
+		cat = fmt.Sprintf("@interface %s(%s)%s%s", className, c.Name, protos, comment)
+	} else {
+		cat = fmt.Sprintf("@interface %s(%s)%s", className, c.Name, protos)
+	}
+
+	owner := strings.TrimSpace(className) + "(" + c.Name + ")"
+	var super string
+	if c.Class != nil {
+		super = c.Class.SuperClass
+	}
+
+	var props string
+	if len(c.Properties) > 0 {
+		props = "\n" + o.dumpProperties(c.Properties, verbose) + "\n"
+	}
+
+	return fmt.Sprintf(
+		"%s\n%s%s@end\n",
+		cat,
+		props,
+		joinBlocks(
+			o.dumpMethods(owner, super, "class methods", c.ClassMethods, true, verbose, addrs),
+			o.dumpMethods(owner, super, "instance methods", c.InstanceMethods, false, verbose, addrs),
+		))
+}
+
+// dumpProtocol returns the ObjC protocol declaration with its @required and @optional method lists
+func (o *ObjC) dumpProtocol(p *objc.Protocol, verbose, addrs bool) string {
+	var props string
+
+	protocol := fmt.Sprintf("@protocol %s ", p.Name)
+
+	if len(p.Prots) > 0 {
+		var subProts []string
+		for _, prot := range p.Prots {
+			subProts = append(subProts, prot.Name)
+		}
+		protocol += fmt.Sprintf("<%s>", strings.Join(subProts, ", "))
+	}
+	if addrs {
+		protocol += fmt.Sprintf(" // %#x", p.Ptr)
+	}
+	if len(p.InstanceProperties) > 0 {
+		props += "\n"
+		for _, prop := range p.InstanceProperties {
+			if verbose {
+				props += fmt.Sprintf("@property %s%s%s;\n", prop.Attributes(), prop.Type(), prop.Name)
+			} else {
+				props += fmt.Sprintf("@property (%s) %s;\n", prop.EncodedAttributes, prop.Name)
+			}
+		}
+		props += "\n"
+	}
+
+	required := joinBlocks(
+		o.dumpMethods(p.Name, "", "class methods", p.ClassMethods, true, verbose, false),
+		o.dumpMethods(p.Name, "", "instance methods", p.InstanceMethods, false, verbose, false),
+	)
+	optional := joinBlocks(
+		o.dumpMethods(p.Name, "", "class methods", p.OptionalClassMethods, true, verbose, false),
+		o.dumpMethods(p.Name, "", "instance methods", p.OptionalInstanceMethods, false, verbose, false),
+	)
+	if len(optional) > 0 {
+		if len(required) > 0 {
+			required = "@required\n" + required + "\n"
+		}
+		optional = "@optional\n" + optional
+	}
+
+	return fmt.Sprintf(
+		"%s\n"+
+			"%s%s%s"+
+			"@end\n",
+		protocol,
+		props,
+		required,
+		optional,
+	)
+}
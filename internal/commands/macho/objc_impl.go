@@ -0,0 +1,92 @@
+package macho
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/swift"
+)
+
+// typeQualifiers are the (decoded) method type encoding qualifiers that precede a return type
+var typeQualifiers = []string{"const", "in", "inout", "out", "by copy", "by ref", "one way", "oneway", "atomic"}
+
+// stubReturn returns the return statement of a stubbed method body that returns the zero value of rtype (a decoded return type)
+// NOTE: anonymous struct/union return types can't be named so their stubs trap instead
+func stubReturn(rtype string) string {
+	typ := strings.TrimSpace(rtype)
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, q := range typeQualifiers {
+			if rest, ok := strings.CutPrefix(typ, q+" "); ok {
+				typ, trimmed = strings.TrimSpace(rest), true
+			}
+		}
+	}
+	switch {
+	case typ == "void":
+		return ""
+	case typ == "id" || typ == "instancetype" || typ == "Class" || typ == "SEL" ||
+		strings.HasPrefix(typ, "id<") || strings.HasPrefix(typ, "id ") || strings.HasSuffix(typ, "*"):
+		return "return nil;"
+	case strings.HasPrefix(typ, "struct ") || strings.HasPrefix(typ, "union "):
+		kind, rest, _ := strings.Cut(typ, " ")
+		name, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		if len(name) == 0 || strings.HasPrefix(name, "{") {
+			return "__builtin_trap();"
+		}
+		return fmt.Sprintf("return (%s %s){0};", kind, name)
+	default:
+		return "return 0;"
+	}
+}
+
+// dumpImplementation returns a class's @implementation with stubbed method bodies (for EmitImpl)
+func (o *ObjC) dumpImplementation(c *objc.Class) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "@implementation %s\n", c.Name)
+	for _, isClass := range []bool{true, false} {
+		methods, prefix := c.InstanceMethods, "-"
+		if isClass {
+			methods, prefix = c.ClassMethods, "+"
+		}
+		for _, meth := range methods {
+			if len(meth.Name) == 0 {
+				meth.Name = o.selectorName(meth.NameVMAddr)
+			}
+			if strings.HasPrefix(meth.Name, ".cxx_") {
+				continue // compiler generated
+			}
+			rtype := returnDeclType(&meth, isClass)
+			fmt.Fprintf(&out, "\n%s %s {\n", prefix, strings.TrimSuffix(o.methodDecl(&meth, isClass), ";"))
+			if ret := stubReturn(rtype); len(ret) > 0 {
+				fmt.Fprintf(&out, "  %s\n", ret)
+			}
+			out.WriteString("}\n")
+		}
+	}
+	out.WriteString("\n@end\n")
+	return out.String()
+}
+
+// writeImplementation writes the stub <Class>.m of a class next to its header (at hdrFile)
+func (o *ObjC) writeImplementation(hdrFile string, c *objc.Class) error {
+	var out string
+	if !o.conf.NoBanner {
+		out = fmt.Sprintf("//\n//   Generated by https://github.com/blacktop/ipsw (%s)\n//\n", o.conf.IpswVersion)
+	}
+	out += fmt.Sprintf("#import \"%s\"\n\n", filepath.Base(hdrFile))
+	out += swift.DemangleBlob(o.dumpImplementation(c))
+	out = reindent(out, o.conf.IndentStyle)
+	if o.conf.LineEnding == "crlf" {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	fname := strings.TrimSuffix(hdrFile, filepath.Ext(hdrFile)) + ".m"
+	log.Infof("Creating %s", fname)
+	if err := o.writeOutput(fname, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write implementation %s: %v", fname, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package macho
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// SelectorAddressMap outputs the resolved IMP vmaddr of each class (+) and instance (-) method of the ObjC classes
+// matching a given name or pattern as JSON (e.g. {"Foo": {"+shared": 6442450944, "-reload": 6442451200}})
+func (o *ObjC) SelectorAddressMap(pattern string) error {
+	re, err := o.compilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to compile regex: %v", err)
+	}
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	results := make([]map[string]map[string]uint64, len(ms)) // per image (so the first image defining a class wins)
+	if err := o.forEachImage(len(ms), func(i int) error {
+		m := ms[i]
+		classes, err := m.GetObjCClasses()
+		if err != nil && !o.noObjC(m, "classes", err) {
+			return err
+		}
+		results[i] = make(map[string]map[string]uint64)
+		for _, class := range classes {
+			if class.Name != pattern && !re.MatchString(class.Name) || o.skipClass(class.Name) {
+				continue
+			}
+			o.resolveClassImps(m, &class)
+			results[i][class.Name] = o.selectorAddresses(&class)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	imps := make(map[string]map[string]uint64)
+	for _, res := range results {
+		for name, sels := range res {
+			if _, ok := imps[name]; !ok {
+				imps[name] = sels
+			}
+		}
+	}
+	dat, err := json.MarshalIndent(imps, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal selector address map: %v", err)
+	}
+	fmt.Println(string(dat))
+	return nil
+}
+
+// selectorAddresses returns the IMP vmaddrs of a class's (IMP resolved) methods keyed by their +/- prefixed selector
+// NOTE: methods w/o an IMP are omitted
+func (o *ObjC) selectorAddresses(c *objc.Class) map[string]uint64 {
+	sels := make(map[string]uint64, len(c.ClassMethods)+len(c.InstanceMethods))
+	for _, meth := range c.ClassMethods {
+		if meth.ImpVMAddr != 0 {
+			sels["+"+o.methodName(&meth)] = meth.ImpVMAddr
+		}
+	}
+	for _, meth := range c.InstanceMethods {
+		if meth.ImpVMAddr != 0 {
+			sels["-"+o.methodName(&meth)] = meth.ImpVMAddr
+		}
+	}
+	return sels
+}
@@ -0,0 +1,55 @@
+package macho
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// headerIndentWidth is the number of spaces per indentation level in the generated headers
+const headerIndentWidth = 2
+
+// indentUnit returns the indentation of one level for an IndentStyle (or "" to leave the indentation as-is)
+func indentUnit(style string) (string, error) {
+	switch {
+	case len(style) == 0:
+		return "", nil
+	case style == "tabs":
+		return "\t", nil
+	case strings.HasPrefix(style, "spaces:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(style, "spaces:"))
+		if err != nil || n < 1 || n > 16 {
+			return "", fmt.Errorf("invalid indent style '%s' (spaces must be between 1 and 16)", style)
+		}
+		return strings.Repeat(" ", n), nil
+	default:
+		return "", fmt.Errorf("invalid indent style '%s' (must be 'tabs' or 'spaces:N')", style)
+	}
+}
+
+// reindent replaces the leading whitespace of each line of a generated header with the indentation of an IndentStyle
+// NOTE: a tab or headerIndentWidth spaces are one level; leftover spaces (e.g. continuation alignment) are kept
+func reindent(header, style string) string {
+	unit, err := indentUnit(style)
+	if err != nil || len(unit) == 0 {
+		return header
+	}
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " \t")
+		if len(body) == 0 || len(body) == len(line) {
+			continue
+		}
+		var levels, spaces int
+		for _, r := range line[:len(line)-len(body)] {
+			if r == '\t' {
+				levels, spaces = levels+1+spaces/headerIndentWidth, 0
+			} else {
+				spaces++
+			}
+		}
+		levels += spaces / headerIndentWidth
+		lines[i] = strings.Repeat(unit, levels) + strings.Repeat(" ", spaces%headerIndentWidth) + body
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,228 @@
+package macho
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// The JSON Dump schema keeps each class/protocol/category's methods, ivars and properties in their
+// binary (method/ivar/property list) order, which matters for layout and dispatch analysis.
+// Set SortMethods to sort methods and properties by name instead (ivars are always in layout order).
+
+// ObjcImage is the JSON Dump of an image's ObjC metadata
+type ObjcImage struct {
+	Image      string         `json:"image,omitempty"`
+	Runtime    *ImageInfo     `json:"runtime,omitempty"`
+	Protocols  []ObjcProtocol `json:"protocols,omitempty"`
+	Classes    []ObjcClass    `json:"classes,omitempty"`
+	Categories []ObjcCategory `json:"categories,omitempty"`
+}
+
+// ObjcMethod is a JSON Dump method
+type ObjcMethod struct {
+	Name  string `json:"name"`
+	Types string `json:"types,omitempty"`
+	Imp   uint64 `json:"imp,omitempty"`
+}
+
+// ObjcIvar is a JSON Dump ivar
+type ObjcIvar struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Offset uint32 `json:"offset"`
+}
+
+// ObjcProperty is a JSON Dump property
+type ObjcProperty struct {
+	Name       string `json:"name"`
+	Attributes string `json:"attributes"`
+}
+
+// ObjcClass is a JSON Dump class
+type ObjcClass struct {
+	Name            string         `json:"name"`
+	SuperClass      string         `json:"superclass,omitempty"`
+	Protocols       []string       `json:"protocols,omitempty"`
+	Ivars           []ObjcIvar     `json:"ivars,omitempty"`
+	Properties      []ObjcProperty `json:"properties,omitempty"`
+	ClassMethods    []ObjcMethod   `json:"class_methods,omitempty"`
+	InstanceMethods []ObjcMethod   `json:"instance_methods,omitempty"`
+	Swift           bool           `json:"swift,omitempty"`
+}
+
+// ObjcProtocol is a JSON Dump protocol
+type ObjcProtocol struct {
+	Name                    string         `json:"name"`
+	Protocols               []string       `json:"protocols,omitempty"`
+	Properties              []ObjcProperty `json:"properties,omitempty"`
+	ClassMethods            []ObjcMethod   `json:"class_methods,omitempty"`
+	InstanceMethods         []ObjcMethod   `json:"instance_methods,omitempty"`
+	OptionalClassMethods    []ObjcMethod   `json:"optional_class_methods,omitempty"`
+	OptionalInstanceMethods []ObjcMethod   `json:"optional_instance_methods,omitempty"`
+}
+
+// ObjcCategory is a JSON Dump category
+type ObjcCategory struct {
+	Name            string         `json:"name"`
+	Class           string         `json:"class,omitempty"`
+	Protocols       []string       `json:"protocols,omitempty"`
+	Properties      []ObjcProperty `json:"properties,omitempty"`
+	ClassMethods    []ObjcMethod   `json:"class_methods,omitempty"`
+	InstanceMethods []ObjcMethod   `json:"instance_methods,omitempty"`
+}
+
+// DumpJSON outputs the ObjC protocols, classes and categories of the MachO (and its deps) as JSON
+func (o *ObjC) DumpJSON() error {
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	var images []ObjcImage
+	for _, m := range ms {
+		img, err := o.jsonImage(m)
+		if err != nil {
+			return err
+		}
+		images = append(images, img)
+	}
+	dat, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ObjC dump: %v", err)
+	}
+	fmt.Println(string(dat))
+	return nil
+}
+
+func (o *ObjC) jsonImage(m *macho.File) (ObjcImage, error) {
+	img := ObjcImage{Image: machoName(m)}
+
+	if info, err := m.GetObjCImageInfo(); err == nil {
+		ii := newImageInfo(m, info)
+		img.Runtime = &ii
+	} else if !o.noObjC(m, "image info", err) {
+		return img, err
+	}
+
+	protos, err := m.GetObjCProtocols()
+	if err != nil && !o.noObjC(m, "protocols", err) {
+		return img, err
+	}
+	slices.SortStableFunc(protos, func(a, b objc.Protocol) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	seen := make(map[uint64]bool)
+	for _, proto := range protos {
+		if seen[proto.Ptr] { // prevent displaying duplicates
+			continue
+		}
+		seen[proto.Ptr] = true
+		img.Protocols = append(img.Protocols, ObjcProtocol{
+			Name:                    proto.Name,
+			Protocols:               protocolNames(proto.Prots),
+			Properties:              o.jsonProperties(proto.InstanceProperties),
+			ClassMethods:            o.jsonMethods(m, proto.ClassMethods),
+			InstanceMethods:         o.jsonMethods(m, proto.InstanceMethods),
+			OptionalClassMethods:    o.jsonMethods(m, proto.OptionalClassMethods),
+			OptionalInstanceMethods: o.jsonMethods(m, proto.OptionalInstanceMethods),
+		})
+	}
+
+	classes, err := m.GetObjCClasses()
+	if err != nil && !o.noObjC(m, "classes", err) {
+		return img, err
+	}
+	slices.SortStableFunc(classes, func(a, b objc.Class) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, class := range classes {
+		if o.skipClass(class.Name) || o.skipUnexported(m, class.Name) {
+			continue
+		}
+		var ivars []ObjcIvar
+		for _, ivar := range class.Ivars {
+			ivars = append(ivars, ObjcIvar{Name: ivar.Name, Type: ivarType(&ivar), Offset: ivar.Offset})
+		}
+		img.Classes = append(img.Classes, ObjcClass{
+			Name:            class.Name,
+			SuperClass:      class.SuperClass,
+			Protocols:       protocolNames(class.Protocols),
+			Ivars:           ivars,
+			Properties:      o.jsonProperties(class.Props),
+			ClassMethods:    o.jsonMethods(m, class.ClassMethods),
+			InstanceMethods: o.jsonMethods(m, class.InstanceMethods),
+			Swift:           class.IsSwift(),
+		})
+	}
+
+	cats, err := m.GetObjCCategories()
+	if err != nil && !o.noObjC(m, "categories", err) {
+		return img, err
+	}
+	o.resolveCategoryClasses(m, cats)
+	slices.SortStableFunc(cats, func(a, b objc.Category) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, cat := range cats {
+		var className string
+		if cat.Class != nil {
+			if o.skipSwiftSynthetic(cat.Class.Name) {
+				continue
+			}
+			className = cat.Class.Name
+		}
+		img.Categories = append(img.Categories, ObjcCategory{
+			Name:            cat.Name,
+			Class:           className,
+			Protocols:       protocolNames(cat.Protocols),
+			Properties:      o.jsonProperties(cat.Properties),
+			ClassMethods:    o.jsonMethods(m, cat.ClassMethods),
+			InstanceMethods: o.jsonMethods(m, cat.InstanceMethods),
+		})
+	}
+
+	return img, nil
+}
+
+// jsonMethods returns the JSON methods in method list order (or sorted by selector if SortMethods is set)
+func (o *ObjC) jsonMethods(m *macho.File, methods []objc.Method) []ObjcMethod {
+	var out []ObjcMethod
+	for _, meth := range methods {
+		if len(meth.Name) == 0 {
+			meth.Name = o.selectorName(meth.NameVMAddr)
+		}
+		out = append(out, ObjcMethod{Name: meth.Name, Types: meth.Types, Imp: o.resolveImp(m, meth.ImpVMAddr)})
+	}
+	if o.conf.SortMethods {
+		slices.SortStableFunc(out, func(a, b ObjcMethod) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+	}
+	return out
+}
+
+// jsonProperties returns the JSON properties in property list order (or sorted by name if SortMethods is set)
+func (o *ObjC) jsonProperties(props []objc.Property) []ObjcProperty {
+	var out []ObjcProperty
+	for _, prop := range props {
+		out = append(out, ObjcProperty{Name: prop.Name, Attributes: prop.EncodedAttributes})
+	}
+	if o.conf.SortMethods {
+		slices.SortStableFunc(out, func(a, b ObjcProperty) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+	}
+	return out
+}
+
+func protocolNames(protos []objc.Protocol) []string {
+	var names []string
+	for _, proto := range protos {
+		names = append(names, proto.Name)
+	}
+	return names
+}
@@ -0,0 +1,173 @@
+package macho
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// IvarLayout is an ivar's place in its class's instance layout
+type IvarLayout struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Offset uint32 `json:"offset"`
+	Size   uint32 `json:"size"`
+}
+
+// IvarLayoutChange is an ivar whose offset or size changed
+type IvarLayoutChange struct {
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`
+	OldOffset uint32 `json:"old_offset"`
+	NewOffset uint32 `json:"new_offset"`
+	OldSize   uint32 `json:"old_size"`
+	NewSize   uint32 `json:"new_size"`
+}
+
+// ClassLayoutDiff is the ivar layout change of a class common to two binaries
+type ClassLayoutDiff struct {
+	Image             string             `json:"image,omitempty"`
+	Class             string             `json:"class"`
+	OldInstanceSize   uint64             `json:"old_instance_size"`
+	NewInstanceSize   uint64             `json:"new_instance_size"`
+	InstanceSizeDelta int64              `json:"instance_size_delta"`
+	Added             []IvarLayout       `json:"added,omitempty"`
+	Removed           []IvarLayout       `json:"removed,omitempty"`
+	Changed           []IvarLayoutChange `json:"changed,omitempty"`
+}
+
+func (d ClassLayoutDiff) String() string {
+	var out strings.Builder
+	out.WriteString(d.Class)
+	if len(d.Image) > 0 {
+		fmt.Fprintf(&out, "\t(%s)", d.Image)
+	}
+	if d.InstanceSizeDelta != 0 {
+		fmt.Fprintf(&out, "\n  instance size: %#x -> %#x (%+d)", d.OldInstanceSize, d.NewInstanceSize, d.InstanceSizeDelta)
+	}
+	for _, ivar := range d.Changed {
+		fmt.Fprintf(&out, "\n  ~ %s %s: offset %#x -> %#x, size %d -> %d", ivar.Type, ivar.Name, ivar.OldOffset, ivar.NewOffset, ivar.OldSize, ivar.NewSize)
+	}
+	for _, ivar := range d.Added {
+		fmt.Fprintf(&out, "\n  + %s %s: offset %#x, size %d", ivar.Type, ivar.Name, ivar.Offset, ivar.Size)
+	}
+	for _, ivar := range d.Removed {
+		fmt.Fprintf(&out, "\n  - %s %s: offset %#x, size %d", ivar.Type, ivar.Name, ivar.Offset, ivar.Size)
+	}
+	return out.String()
+}
+
+// DiffIvarLayout returns the ivar layout changes (moved/resized, added and removed ivars and the instance size delta)
+// of the ObjC classes common to MachOs a (old) and b (new)
+func DiffIvarLayout(a, b *macho.File) ([]ClassLayoutDiff, error) {
+	oldClasses, err := layoutClasses(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get old ObjC classes: %v", err)
+	}
+	newClasses, err := layoutClasses(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new ObjC classes: %v", err)
+	}
+	return diffIvarLayouts(machoName(b), oldClasses, newClasses), nil
+}
+
+// layoutClasses returns the ObjC classes of m (or none if it has no ObjC)
+func layoutClasses(m *macho.File) ([]objc.Class, error) {
+	if !m.HasObjC() {
+		return nil, nil
+	}
+	classes, err := m.GetObjCClasses()
+	if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// diffIvarLayouts returns the (class name sorted) ivar layout changes of the classes in both old and new
+func diffIvarLayouts(image string, oldClasses, newClasses []objc.Class) []ClassLayoutDiff {
+	old := make(map[string]*objc.Class, len(oldClasses))
+	for i := range oldClasses {
+		old[oldClasses[i].Name] = &oldClasses[i]
+	}
+	var diffs []ClassLayoutDiff
+	for _, nc := range newClasses {
+		oc, ok := old[nc.Name]
+		if !ok {
+			continue
+		}
+		diff := ClassLayoutDiff{
+			Image:             image,
+			Class:             nc.Name,
+			OldInstanceSize:   oc.ReadOnlyData.InstanceSize,
+			NewInstanceSize:   nc.ReadOnlyData.InstanceSize,
+			InstanceSizeDelta: int64(nc.ReadOnlyData.InstanceSize) - int64(oc.ReadOnlyData.InstanceSize),
+		}
+		oldIvars := make(map[string]objc.Ivar, len(oc.Ivars))
+		for _, ivar := range oc.Ivars {
+			oldIvars[ivar.Name] = ivar
+		}
+		for _, ivar := range nc.Ivars {
+			oi, ok := oldIvars[ivar.Name]
+			if !ok {
+				diff.Added = append(diff.Added, newIvarLayout(ivar))
+				continue
+			}
+			delete(oldIvars, ivar.Name)
+			if oi.Offset != ivar.Offset || oi.Size != ivar.Size {
+				diff.Changed = append(diff.Changed, IvarLayoutChange{
+					Name:      ivar.Name,
+					Type:      ivarType(&ivar),
+					OldOffset: oi.Offset,
+					NewOffset: ivar.Offset,
+					OldSize:   oi.Size,
+					NewSize:   ivar.Size,
+				})
+			}
+		}
+		for _, ivar := range oc.Ivars { // in the old layout order
+			if _, ok := oldIvars[ivar.Name]; ok {
+				diff.Removed = append(diff.Removed, newIvarLayout(ivar))
+			}
+		}
+		if diff.InstanceSizeDelta != 0 || len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	slices.SortStableFunc(diffs, func(a, b ClassLayoutDiff) int {
+		return cmp.Compare(a.Class, b.Class)
+	})
+	return diffs
+}
+
+func newIvarLayout(ivar objc.Ivar) IvarLayout {
+	return IvarLayout{Name: ivar.Name, Type: ivarType(&ivar), Offset: ivar.Offset, Size: ivar.Size}
+}
+
+// DumpIvarLayoutDiff outputs the ivar layout changes of the ObjC classes of the MachO since old (its previous version)
+func (o *ObjC) DumpIvarLayoutDiff(old *macho.File) error {
+	diffs, err := DiffIvarLayout(old, o.file)
+	if err != nil {
+		return err
+	}
+	diffs = slices.DeleteFunc(diffs, func(d ClassLayoutDiff) bool {
+		return o.skipClass(d.Class)
+	})
+	if o.conf.JSON {
+		dat, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal ivar layout diff: %v", err)
+		}
+		fmt.Println(string(dat))
+		return nil
+	}
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	return nil
+}
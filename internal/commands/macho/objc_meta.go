@@ -0,0 +1,133 @@
+package macho
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/chroma/v2/quick"
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/internal/swift"
+)
+
+// maxMetaclassChain bounds the metaclass superclass chain walk (e.g. for corrupt or cyclic class data)
+const maxMetaclassChain = 64
+
+// metaclass is a class's metaclass (whose instance methods are the class's class methods)
+type metaclass struct {
+	*objc.Class
+	Isa   string   // the root metaclass
+	Chain []string // the superclass metaclasses up to the root class (e.g. [Bar, NSObject (root metaclass), NSObject])
+}
+
+// getMetaclass returns a class's metaclass and walks its superclass chain
+// NOTE: the chain stops at the first metaclass defined in another image (only its name is known)
+func getMetaclass(m *macho.File, c *objc.Class) (*metaclass, error) {
+	if c.IsaVMAddr == 0 {
+		return nil, fmt.Errorf("class %s has no isa", c.Name)
+	}
+	meta, err := m.GetObjCClass(c.IsaVMAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metaclass of %s at %#x: %v", c.Name, c.IsaVMAddr, err)
+	}
+	mc := &metaclass{Class: meta}
+	if isa, _, ok := metaclassAt(m, meta.IsaVMAddr); ok {
+		mc.Isa = isa
+	}
+	for cur := meta; len(mc.Chain) < maxMetaclassChain; {
+		if cur.ReadOnlyData.Flags.IsRoot() { // the root metaclass's superclass is the root class
+			if cur != meta {
+				mc.Chain[len(mc.Chain)-1] += " (root metaclass)"
+			}
+			mc.Chain = append(mc.Chain, cur.Name)
+			break
+		}
+		name, super, ok := metaclassAt(m, cur.SuperclassVMAddr)
+		if !ok {
+			break
+		}
+		mc.Chain = append(mc.Chain, name)
+		if super == nil {
+			break
+		}
+		cur = super
+	}
+	return mc, nil
+}
+
+// metaclassAt returns the name of the metaclass at addr (and the metaclass if it is defined in the MachO)
+func metaclassAt(m *macho.File, addr uint64) (string, *objc.Class, bool) {
+	if addr == 0 {
+		return "", nil, false
+	}
+	if meta, err := m.GetObjCClass(addr); err == nil {
+		return meta.Name, meta, true
+	}
+	if bind, err := m.GetBindName(addr); err == nil {
+		return strings.TrimPrefix(bind, "_OBJC_METACLASS_$_"), nil, true
+	}
+	return "", nil, false
+}
+
+// dumpMetaclass returns the interface declaration of a class's metaclass (with its root metaclass and superclass chain)
+func (o *ObjC) dumpMetaclass(c *objc.Class, mc *metaclass, verbose, addrs bool) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "@interface %s (metaclass)", c.Name)
+	if len(mc.Chain) > 0 {
+		fmt.Fprintf(&out, " : %s", strings.TrimSuffix(mc.Chain[0], " (root metaclass)"))
+	}
+	if addrs {
+		fmt.Fprintf(&out, " // %#x", c.IsaVMAddr)
+	}
+	out.WriteString("\n")
+	if len(mc.Isa) > 0 {
+		fmt.Fprintf(&out, "// isa: %s (root metaclass)\n", mc.Isa)
+	}
+	if len(mc.Chain) > 0 {
+		fmt.Fprintf(&out, "// superclass chain: %s -> %s\n", c.Name, strings.Join(mc.Chain, " -> "))
+	}
+	if len(mc.Ivars) > 0 {
+		s := bytes.NewBufferString("{\n  /* instance variables */\n")
+		w := tabwriter.NewWriter(s, 0, 0, 1, ' ', 0)
+		for _, ivar := range mc.Ivars {
+			switch {
+			case addrs:
+				fmt.Fprintf(w, "  %s\n", ivar.WithAddrs())
+			case verbose:
+				fmt.Fprintf(w, "  %s\n", ivar.Verbose())
+			default:
+				fmt.Fprintf(w, "  %s\n", &ivar)
+			}
+		}
+		w.Flush()
+		s.WriteString("}\n")
+		out.WriteString(s.String())
+	}
+	out.WriteString("\n")
+	// the metaclass's instance methods are the class's class methods (which have their IMPs resolved with Addrs)
+	out.WriteString(o.dumpMethods(c.Name, c.SuperClass, "metaclass methods", c.ClassMethods, true, verbose, addrs))
+	out.WriteString("@end\n")
+	return out.String()
+}
+
+// printMetaclass prints a class's metaclass after the class in Dump and DumpClass (see Metaclass)
+func (o *ObjC) printMetaclass(m *macho.File, c *objc.Class, verbose, addrs bool) {
+	mc, err := getMetaclass(m, c)
+	if err != nil {
+		log.Warnf("failed to get metaclass: %v", err)
+		return
+	}
+	out := o.dumpMetaclass(c, mc, verbose, addrs)
+	if verbose {
+		out = swift.DemangleBlob(out)
+	}
+	if o.conf.Color {
+		quick.Highlight(os.Stdout, out+"\n", "objc", "terminal256", o.conf.Theme)
+	} else {
+		fmt.Println(out)
+	}
+}
@@ -0,0 +1,73 @@
+package macho
+
+import (
+	"slices"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// defaultNSObjectMembers are the NSObject protocol's methods and properties (see nsobjectMembers),
+// used when it wasn't found in Foundation (e.g. w/o a DSC or FoundationPath)
+var defaultNSObjectMembers = []string{
+	"-autorelease", "-class", "-conformsToProtocol:", "-debugDescription", "-description", "-hash",
+	"-isEqual:", "-isKindOfClass:", "-isMemberOfClass:", "-isProxy", "-performSelector:",
+	"-performSelector:withObject:", "-performSelector:withObject:withObject:", "-release",
+	"-respondsToSelector:", "-retain", "-retainCount", "-self", "-superclass", "-zone",
+	"@debugDescription", "@description", "@hash", "@superclass",
+}
+
+// nsobjectMembers returns the NSObject protocol's (required and optional) methods (as -sel/+sel) and properties (as @name)
+func (o *ObjC) nsobjectMembers(p *objc.Protocol) []string {
+	var members []string
+	for _, meths := range [][]objc.Method{p.InstanceMethods, p.OptionalInstanceMethods} {
+		for _, meth := range meths {
+			members = append(members, "-"+o.methodName(&meth))
+		}
+	}
+	for _, meths := range [][]objc.Method{p.ClassMethods, p.OptionalClassMethods} {
+		for _, meth := range meths {
+			members = append(members, "+"+o.methodName(&meth))
+		}
+	}
+	for _, prop := range p.InstanceProperties {
+		members = append(members, "@"+prop.Name)
+	}
+	return members
+}
+
+// isNSObjectMember returns true if the member (-sel, +sel or @property) is declared by the NSObject protocol
+func (o *ObjC) isNSObjectMember(member string) bool {
+	members := o.foundation["nsobject"]
+	if len(members) == 0 {
+		members = defaultNSObjectMembers
+	}
+	_, found := slices.BinarySearch(members, member)
+	return found
+}
+
+// hideNSObjectMembers returns a copy of c w/o the methods and properties it has because it conforms to the NSObject protocol
+// (the root classes that implement them are returned as is)
+func (o *ObjC) hideNSObjectMembers(c *objc.Class) *objc.Class {
+	if c.ReadOnlyData.Flags.IsRoot() {
+		return c
+	}
+	hidden := *c
+	hidden.ClassMethods = slices.DeleteFunc(slices.Clone(c.ClassMethods), func(m objc.Method) bool {
+		return o.isNSObjectMember("+" + o.methodName(&m))
+	})
+	hidden.InstanceMethods = slices.DeleteFunc(slices.Clone(c.InstanceMethods), func(m objc.Method) bool {
+		return o.isNSObjectMember("-" + o.methodName(&m))
+	})
+	hidden.Props = slices.DeleteFunc(slices.Clone(c.Props), func(p objc.Property) bool {
+		return o.isNSObjectMember("@" + p.Name)
+	})
+	return &hidden
+}
+
+// methodName returns a method's selector (looking it up in the DSC if it wasn't read)
+func (o *ObjC) methodName(m *objc.Method) string {
+	if len(m.Name) == 0 {
+		return o.selectorName(m.NameVMAddr)
+	}
+	return m.Name
+}
@@ -0,0 +1,102 @@
+package macho
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/apex/log"
+	"github.com/blacktop/go-macho"
+)
+
+// maxSuperclassChain bounds the superclass chain walk of isOverride (e.g. for cyclic class data)
+const maxSuperclassChain = 64
+
+// nsobjectClassSelectors are the commonly overridden NSObject class methods that aren't NSObject protocol members
+// (used when the NSObject class wasn't found, e.g. w/o a DSC)
+var nsobjectClassSelectors = []string{
+	"+alloc", "+allocWithZone:", "+initialize", "+load", "+new",
+	"-copy", "-dealloc", "-finalize", "-init", "-mutableCopy",
+}
+
+// isOverride returns true if the method (-sel or +sel) is declared by the class super or one of its superclasses
+// NOTE: this is best-effort; the chain walk stops at the first superclass that isn't in the MachO, its deps or Foundation
+func (o *ObjC) isOverride(super, sel string) bool {
+	classes := o.superclasses()
+	for i := 0; len(super) > 0 && i < maxSuperclassChain; i++ {
+		c, ok := classes[super]
+		if !ok {
+			if super == "NSObject" {
+				_, found := slices.BinarySearch(nsobjectClassSelectors, sel)
+				return found || o.isNSObjectMember(sel)
+			}
+			return false
+		}
+		if _, found := slices.BinarySearch(c.Selectors, sel); found {
+			return true
+		}
+		super = c.Superclass
+	}
+	return false
+}
+
+// superclasses returns the superclass and selectors of the classes of the MachO, its deps and (lib)objc/Foundation by name (for MarkOverrides)
+func (o *ObjC) superclasses() map[string]objcMembers {
+	if o.supers != nil {
+		return o.supers
+	}
+	o.supers = make(map[string]objcMembers)
+	add := func(m *macho.File) {
+		classes, err := m.GetObjCClasses()
+		if err != nil && !errors.Is(err, macho.ErrObjcSectionNotFound) {
+			log.Debugf("failed to get ObjC classes of %s (for --overrides): %v", machoName(m), err)
+		}
+		for _, c := range classes {
+			if _, ok := o.supers[c.Name]; ok {
+				continue
+			}
+			var sels []string
+			for _, meth := range c.ClassMethods {
+				sels = append(sels, "+"+o.methodName(&meth))
+			}
+			for _, meth := range c.InstanceMethods {
+				sels = append(sels, "-"+o.methodName(&meth))
+			}
+			slices.Sort(sels)
+			o.supers[c.Name] = objcMembers{Superclass: c.SuperClass, Selectors: slices.Compact(sels)}
+		}
+	}
+	add(o.file)
+	for _, dep := range o.deps {
+		add(dep)
+	}
+	if o.cache != nil {
+		for _, name := range []string{"/usr/lib/libobjc.A.dylib", "Foundation", "CoreFoundation"} {
+			img, err := o.cache.Image(name)
+			if err != nil {
+				log.Debugf("failed to find %s (for --overrides): %v", name, err)
+				continue
+			}
+			m, err := img.GetMacho()
+			if err != nil {
+				log.Debugf("failed to get %s MachO (for --overrides): %v", name, err)
+				continue
+			}
+			add(m)
+		}
+	} else if len(o.conf.FoundationPath) > 0 {
+		paths, err := foundationBinaries(o.conf.FoundationPath)
+		if err != nil {
+			log.Debugf("failed to find Foundation binaries (for --overrides): %v", err)
+		}
+		for _, path := range paths {
+			m, closer, err := o.openFoundation(path)
+			if err != nil {
+				log.Debugf("failed to open %s (for --overrides): %v", path, err)
+				continue
+			}
+			add(m)
+			closer.Close()
+		}
+	}
+	return o.supers
+}
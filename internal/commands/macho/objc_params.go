@@ -0,0 +1,119 @@
+package macho
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/blacktop/go-macho/types/objc"
+)
+
+// paramPrepositions are the selector keyword words that introduce the parameter name (e.g. forKeyPath: -> keyPath)
+var paramPrepositions = []string{"with", "for", "and", "by", "at", "in", "of", "on", "to", "from", "using", "as", "into", "after"}
+
+// paramVerbs are the leading selector keyword words that aren't part of the parameter name (e.g. setDelegate: -> delegate)
+var paramVerbs = []string{"set", "add", "remove", "insert", "perform", "get", "is", "should", "did", "will", "can", "has", "init"}
+
+// paramReserved are the C/ObjC keywords and reserved names (e.g. self) that can't be used as parameter names
+var paramReserved = []string{
+	"auto", "bool", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum",
+	"extern", "float", "for", "goto", "if", "inline", "int", "long", "register", "restrict", "return", "short",
+	"signed", "sizeof", "static", "struct", "switch", "typedef", "union", "unsigned", "void", "volatile", "while",
+	"id", "in", "out", "inout", "oneway", "bycopy", "byref", "self", "super", "nil",
+}
+
+// methodDecl returns the decoded ObjC method declaration (without the +/- prefix) with the parameter names of NamedParams
+func (o *ObjC) methodDecl(m *objc.Method, isClass bool) string {
+	if o.conf.NamedParams {
+		return namedMethodDecl(m, isClass)
+	}
+	return methodDecl(m, isClass)
+}
+
+// namedMethodDecl returns the decoded ObjC method declaration (without the +/- prefix) with parameter names
+// synthesized from its selector keywords (see paramName), which are unique and valid C identifiers;
+// anonymous keywords (e.g. foo::) are named argN and a selector's trailing colon-less part is kept as-is
+func namedMethodDecl(m *objc.Method, isClass bool) string {
+	rtype := returnDeclType(m, isClass)
+	if !strings.Contains(m.Name, ":") {
+		return fmt.Sprintf("(%s)%s;", rtype, m.Name)
+	}
+	parts := strings.Split(m.Name, ":")
+	keywords, tail := parts[:len(parts)-1], parts[len(parts)-1]
+	nargs := m.NumberOfArguments() - 2
+	seen := make(map[string]bool, len(keywords))
+	decl := make([]string, 0, len(parts))
+	for idx, keyword := range keywords {
+		typ := "id" // the type encoding has fewer arguments than the selector
+		if idx < nargs {
+			typ = declType(m.ArgumentType(idx + 3))
+		}
+		name := paramName(keyword)
+		if len(name) == 0 {
+			name = fmt.Sprintf("arg%d", idx+1)
+		}
+		if seen[name] {
+			name = fmt.Sprintf("%s%d", name, idx+1)
+		}
+		seen[name] = true
+		decl = append(decl, fmt.Sprintf("%s:(%s)%s", keyword, typ, name))
+	}
+	if len(tail) > 0 {
+		decl = append(decl, tail)
+	}
+	return fmt.Sprintf("(%s)%s;", rtype, strings.Join(decl, " "))
+}
+
+// paramName returns the lowerCamelCase parameter name implied by a selector keyword: its words after the
+// last preposition (initWithFoo -> foo), else w/o a leading verb (setObject -> object), else the whole keyword;
+// C/ObjC keywords are prefixed with a/an (setDouble -> aDouble)
+func paramName(keyword string) string {
+	words := camelWords(strings.TrimLeft(keyword, "_"))
+	if len(words) == 0 {
+		return ""
+	}
+	start := 0
+	for i := len(words) - 2; i >= 0; i-- {
+		if slices.Contains(paramPrepositions, strings.ToLower(words[i])) {
+			start = i + 1
+			break
+		}
+	}
+	if start == 0 && len(words) > 1 && slices.Contains(paramVerbs, words[0]) {
+		start = 1
+	}
+	words = slices.Clone(words[start:])
+	if strings.ToUpper(words[0]) == words[0] { // acronym (e.g. URL -> url)
+		words[0] = strings.ToLower(words[0])
+	} else {
+		words[0] = strings.ToLower(words[0][:1]) + words[0][1:]
+	}
+	name := strings.Join(words, "")
+	if slices.Contains(paramReserved, name) {
+		article := "a"
+		if strings.ContainsRune("aeiou", rune(name[0])) {
+			article = "an"
+		}
+		name = article + strings.ToUpper(name[:1]) + name[1:]
+	}
+	return name
+}
+
+// camelWords splits a camelCase identifier into its words, keeping acronyms together (URLForResource -> URL, For, Resource)
+func camelWords(s string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		cur, prev := rune(s[i]), rune(s[i-1])
+		if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			unicode.IsUpper(prev) && i+1 < len(s) && unicode.IsLower(rune(s[i+1]))) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
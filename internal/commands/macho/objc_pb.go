@@ -0,0 +1,164 @@
+package macho
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/blacktop/go-macho"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The Protobuf Dump encodes the JSON Dump types with the field numbers of the schema in objc.proto
+// (keep both in sync with the JSON schema when adding fields)
+
+// DumpProtobuf writes the ObjC protocols, classes and categories of the MachO (and its deps) to w
+// as length-delimited Protobuf Image messages (one per image, for streaming)
+func (o *ObjC) DumpProtobuf(w io.Writer) error {
+	ms := []*macho.File{o.file}
+	if o.conf.Deps {
+		ms = append(ms, o.deps...)
+	}
+	for _, m := range ms {
+		img, err := o.jsonImage(m)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(protowire.AppendBytes(nil, img.appendProto(nil))); err != nil {
+			return fmt.Errorf("failed to write ObjC Protobuf dump of %s: %v", img.Image, err)
+		}
+	}
+	return nil
+}
+
+func (img ObjcImage) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, img.Image)
+	if img.Runtime != nil {
+		b = appendProtoMessage(b, 2, img.Runtime.appendProto(nil))
+	}
+	for _, p := range img.Protocols {
+		b = appendProtoMessage(b, 3, p.appendProto(nil))
+	}
+	for _, c := range img.Classes {
+		b = appendProtoMessage(b, 4, c.appendProto(nil))
+	}
+	for _, c := range img.Categories {
+		b = appendProtoMessage(b, 5, c.appendProto(nil))
+	}
+	return b
+}
+
+func (ii ImageInfo) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, ii.Image)
+	b = appendProtoVarint(b, 2, uint64(ii.Version))
+	b = appendProtoVarint(b, 3, uint64(ii.RawFlags))
+	for _, flag := range ii.Flags {
+		b = appendProtoRepeatedString(b, 4, flag)
+	}
+	b = appendProtoString(b, 5, ii.Swift)
+	b = appendProtoVarint(b, 6, uint64(ii.SwiftUnstableVersion))
+	b = appendProtoVarint(b, 7, uint64(ii.SwiftStableVersion))
+	b = appendProtoVarint(b, 8, uint64(int64(ii.SwiftMetadata)))
+	return appendProtoBool(b, 9, ii.SwiftReflection)
+}
+
+func (m ObjcMethod) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, m.Name)
+	b = appendProtoString(b, 2, m.Types)
+	return appendProtoVarint(b, 3, m.Imp)
+}
+
+func (i ObjcIvar) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, i.Name)
+	b = appendProtoString(b, 2, i.Type)
+	return appendProtoVarint(b, 3, uint64(i.Offset))
+}
+
+func (p ObjcProperty) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, p.Name)
+	return appendProtoString(b, 2, p.Attributes)
+}
+
+func (c ObjcClass) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, c.Name)
+	b = appendProtoString(b, 2, c.SuperClass)
+	for _, proto := range c.Protocols {
+		b = appendProtoRepeatedString(b, 3, proto)
+	}
+	for _, ivar := range c.Ivars {
+		b = appendProtoMessage(b, 4, ivar.appendProto(nil))
+	}
+	b = appendProtoProperties(b, 5, c.Properties)
+	b = appendProtoMethods(b, 6, c.ClassMethods)
+	b = appendProtoMethods(b, 7, c.InstanceMethods)
+	return appendProtoBool(b, 8, c.Swift)
+}
+
+func (p ObjcProtocol) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, p.Name)
+	for _, proto := range p.Protocols {
+		b = appendProtoRepeatedString(b, 2, proto)
+	}
+	b = appendProtoProperties(b, 3, p.Properties)
+	b = appendProtoMethods(b, 4, p.ClassMethods)
+	b = appendProtoMethods(b, 5, p.InstanceMethods)
+	b = appendProtoMethods(b, 6, p.OptionalClassMethods)
+	return appendProtoMethods(b, 7, p.OptionalInstanceMethods)
+}
+
+func (c ObjcCategory) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, c.Name)
+	b = appendProtoString(b, 2, c.Class)
+	for _, proto := range c.Protocols {
+		b = appendProtoRepeatedString(b, 3, proto)
+	}
+	b = appendProtoProperties(b, 4, c.Properties)
+	b = appendProtoMethods(b, 5, c.ClassMethods)
+	return appendProtoMethods(b, 6, c.InstanceMethods)
+}
+
+func appendProtoMethods(b []byte, num protowire.Number, methods []ObjcMethod) []byte {
+	for _, m := range methods {
+		b = appendProtoMessage(b, num, m.appendProto(nil))
+	}
+	return b
+}
+
+func appendProtoProperties(b []byte, num protowire.Number, props []ObjcProperty) []byte {
+	for _, p := range props {
+		b = appendProtoMessage(b, num, p.appendProto(nil))
+	}
+	return b
+}
+
+// appendProtoString appends a (proto3 singular) string field, omitting the default empty string
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if len(s) == 0 {
+		return b
+	}
+	return appendProtoRepeatedString(b, num, s)
+}
+
+// appendProtoRepeatedString appends an element of a repeated string field (which keeps empty strings)
+func appendProtoRepeatedString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendProtoVarint appends a (proto3 singular) integer field, omitting the default 0
+func appendProtoVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	return appendProtoVarint(b, num, protowire.EncodeBool(v))
+}
+
+// appendProtoMessage appends an embedded message field (empty messages are kept, e.g. a repeated element w/ only defaults)
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
@@ -0,0 +1,158 @@
+package macho
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// ctag is a tags file entry (kinds follow universal-ctags' ObjectiveC kinds)
+type ctag struct {
+	Name    string
+	File    string
+	Pattern string // the declaration line
+	Kind    string // i: interface, P: protocol, C: category, m: method, c: class method, p: property
+	Scope   string // e.g. interface:Foo (for members)
+}
+
+func (t ctag) String() string {
+	pattern := strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(t.Pattern)
+	line := fmt.Sprintf("%s\t%s\t/^%s$/;\"\t%s", t.Name, filepath.ToSlash(t.File), pattern, t.Kind)
+	if len(t.Scope) > 0 {
+		line += "\t" + t.Scope
+	}
+	return line
+}
+
+// addTags adds the tags of the classes, protocols, categories, methods and properties declared in a written header
+func (o *ObjC) addTags(fname, header string) {
+	rel, err := filepath.Rel(o.conf.Output, fname)
+	if err != nil {
+		log.Errorf("failed to get header path relative to the output folder: %v", err)
+		return
+	}
+	o.tags = append(o.tags, headerTags(rel, header)...)
+}
+
+// headerTags returns the tags of the declarations in a generated header
+func headerTags(file, header string) []ctag {
+	var tags []ctag
+	var scope string
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		decl := strings.TrimSpace(line)
+		tag := ctag{File: file, Pattern: line, Scope: scope}
+		switch {
+		case strings.HasPrefix(decl, "@interface "):
+			name, rest, _ := strings.Cut(strings.TrimPrefix(decl, "@interface "), " ")
+			if class, cat, ok := strings.Cut(name, "("); ok { // Foo(Bar)
+				name, rest = class, "("+cat+" "+rest
+			}
+			rest = strings.TrimSpace(rest)
+			switch {
+			case strings.HasPrefix(rest, "()"): // class extension (its members belong to the class)
+				scope = "interface:" + name
+				continue
+			case strings.HasPrefix(rest, "("):
+				cat, _, _ := strings.Cut(strings.TrimPrefix(rest, "("), ")")
+				tag.Name, tag.Kind, tag.Scope = name+"("+cat+")", "C", ""
+				scope = "category:" + tag.Name
+			default:
+				tag.Name, tag.Kind, tag.Scope = name, "i", ""
+				scope = "interface:" + name
+			}
+		case strings.HasPrefix(decl, "@protocol ") && !strings.HasSuffix(decl, ";"): // not a forward declaration
+			name, _, _ := strings.Cut(strings.TrimPrefix(decl, "@protocol "), " ")
+			tag.Name, tag.Kind, tag.Scope = strings.TrimSuffix(name, "<"), "P", ""
+			scope = "protocol:" + tag.Name
+		case decl == "@end":
+			scope = ""
+			continue
+		case len(scope) == 0:
+			continue
+		case strings.HasPrefix(decl, "- (") || strings.HasPrefix(decl, "+ ("):
+			tag.Name, tag.Kind = methodSelector(decl), "m"
+			if decl[0] == '+' {
+				tag.Kind = "c"
+			}
+		case strings.HasPrefix(decl, "@property "):
+			prop, _, _ := strings.Cut(decl, ";")
+			tag.Name, tag.Kind = trailingIdentifier(prop), "p"
+		default:
+			continue
+		}
+		if len(tag.Name) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// methodSelector returns the selector of a method declaration (e.g. setName:forKey: for "- (void)setName:(id)name forKey:(id)key;")
+func methodSelector(decl string) string {
+	decl = strings.TrimSuffix(strings.TrimSpace(decl[1:]), ";")
+	var sel, word, first string
+	depth := 0
+	for _, r := range decl {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth > 0:
+		case r == ':':
+			sel += word + ":"
+			word = ""
+		case r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9':
+			word += string(r)
+		default:
+			if len(first) == 0 {
+				first = word
+			}
+			word = ""
+		}
+	}
+	if len(sel) > 0 {
+		return sel
+	}
+	if len(first) > 0 {
+		return first
+	}
+	return word
+}
+
+// trailingIdentifier returns the identifier at the end of s (e.g. a property's name)
+func trailingIdentifier(s string) string {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndexFunc(s, func(r rune) bool {
+		return !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	return s[i+1:]
+}
+
+// writeTags writes the tags file of the generated headers at the output root
+func (o *ObjC) writeTags() error {
+	slices.SortStableFunc(o.tags, func(a, b ctag) int {
+		if a.Name != b.Name {
+			return cmp.Compare(a.Name, b.Name)
+		}
+		return cmp.Compare(a.File, b.File)
+	})
+	var out strings.Builder
+	out.WriteString("!_TAG_FILE_FORMAT\t2\t/extended format/\n")
+	out.WriteString("!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n")
+	out.WriteString("!_TAG_PROGRAM_NAME\tipsw\t//\n")
+	for _, tag := range o.tags {
+		out.WriteString(tag.String() + "\n")
+	}
+	fname := filepath.Join(o.conf.Output, "tags")
+	log.Infof("Creating %s", fname)
+	if err := o.writeOutput(fname, []byte(out.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fname, err)
+	}
+	return nil
+}
@@ -0,0 +1,1755 @@
+package macho
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/go-macho/types"
+	"github.com/blacktop/go-macho/types/objc"
+	"github.com/blacktop/ipsw/pkg/dyld"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func newTestObjC(t *testing.T) *ObjC {
+	t.Helper()
+	o := &ObjC{
+		conf: &ObjcConfig{},
+		foundation: map[string][]string{
+			"classes":   {"NSDictionary", "NSObject", "NSString"},
+			"protocols": {"NSCopying"},
+		},
+	}
+	if err := o.parseFilenameTemplate(); err != nil {
+		t.Fatalf("parseFilenameTemplate() error = %v", err)
+	}
+	return o
+}
+
+func TestObjC_forwardDeclarations(t *testing.T) {
+	type args struct {
+		classes []objc.Class
+		protos  []objc.Protocol
+	}
+	tests := []struct {
+		name  string
+		args  args
+		deps  map[string]string
+		class string
+		want  Imports
+	}{
+		{
+			name: "external superclass",
+			args: args{
+				classes: []objc.Class{{Name: "Foo", SuperClass: "Bar"}},
+			},
+			class: "Foo",
+			want:  Imports{Classes: []string{"Bar"}},
+		},
+		{
+			name: "local superclass",
+			args: args{
+				classes: []objc.Class{
+					{Name: "Foo", SuperClass: "Bar"},
+					{Name: "Bar", SuperClass: "NSObject"},
+				},
+			},
+			class: "Foo",
+			want:  Imports{Locals: []string{"Bar.h"}},
+		},
+		{
+			name: "foundation superclass",
+			args: args{
+				classes: []objc.Class{{Name: "Foo", SuperClass: "NSString"}},
+			},
+			class: "Foo",
+			want:  Imports{Classes: []string{}},
+		},
+		{
+			name: "protocol inherits local protocol",
+			args: args{
+				protos: []objc.Protocol{
+					{Name: "FooDelegate", Prots: []objc.Protocol{{Name: "BarDelegate"}, {Name: "NSCopying"}}},
+					{Name: "BarDelegate"},
+				},
+			},
+			class: "FooDelegate-Protocol",
+			want:  Imports{Locals: []string{"BarDelegate-Protocol.h"}, Protos: []string{}},
+		},
+		{
+			name: "protocol inherits external protocol",
+			args: args{
+				protos: []objc.Protocol{
+					{Name: "FooDelegate", Prots: []objc.Protocol{{Name: "BazDelegate"}}},
+				},
+			},
+			class: "FooDelegate-Protocol",
+			want:  Imports{Protos: []string{"BazDelegate"}},
+		},
+		{
+			name: "generic ivar",
+			args: args{
+				classes: []objc.Class{
+					{Name: "Foo", SuperClass: "NSObject", Ivars: []objc.Ivar{{Name: "_values", Type: `@"NSDictionary<NSString *, MyValue *>"`}}},
+					{Name: "MyValue", SuperClass: "NSObject"},
+				},
+			},
+			class: "Foo",
+			want:  Imports{Locals: []string{"MyValue.h"}, Classes: []string{}},
+		},
+		{
+			name: "conforms to dep protocol",
+			args: args{
+				classes: []objc.Class{{Name: "Foo", SuperClass: "NSObject", Protocols: []objc.Protocol{{Name: "BazDelegate"}, {Name: "QuxDelegate"}}}},
+			},
+			deps:  map[string]string{"BazDelegate": "Baz"},
+			class: "Foo",
+			want:  Imports{Imports: []string{"../Baz/BazDelegate-Protocol.h"}, Protos: []string{"QuxDelegate"}},
+		},
+		{
+			name: "protocol inherits dep protocol",
+			args: args{
+				protos: []objc.Protocol{
+					{Name: "FooDelegate", Prots: []objc.Protocol{{Name: "BazDelegate"}}},
+				},
+			},
+			deps:  map[string]string{"BazDelegate": "Baz"},
+			class: "FooDelegate-Protocol",
+			want:  Imports{Imports: []string{"../Baz/BazDelegate-Protocol.h"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestObjC(t)
+			if tt.deps != nil {
+				o.depProtos = tt.deps
+			}
+			got := o.forwardDeclarations(tt.args.classes, tt.args.protos)[tt.class]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("forwardDeclarations()[%s] = %#v, want %#v", tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjC_dumpMethodsSorted(t *testing.T) {
+	methods := []objc.Method{
+		{Name: "zeta", Types: "v16@0:8", ImpVMAddr: 0x1000},
+		{Name: "alpha", Types: "v16@0:8", ImpVMAddr: 0x2000},
+	}
+	o := newTestObjC(t)
+	o.conf.SortMethods = true
+	got := o.dumpMethods("Foo", "", "instance methods", methods, false, true, true)
+	want := "/* instance methods */\n" +
+		"// 0x2000\n" +
+		"- (void)alpha;\n" +
+		"// 0x1000\n" +
+		"- (void)zeta;\n"
+	if got != want {
+		t.Errorf("dumpMethods() = %q, want %q", got, want)
+	}
+	if methods[0].Name != "zeta" {
+		t.Errorf("dumpMethods() sorted the caller's method list")
+	}
+}
+
+func Test_typeRefs(t *testing.T) {
+	tests := []struct {
+		typ         string
+		wantClasses []string
+		wantProtos  []string
+	}{
+		{typ: `@"NSObject<FooDelegate>"`, wantProtos: []string{"FooDelegate"}},
+		{typ: `@"<FooDelegate>"`, wantProtos: []string{"FooDelegate"}},
+		{typ: "id<Foo, Bar>", wantProtos: []string{"Foo", "Bar"}},
+		{typ: "NSArray<MyClass *> *", wantClasses: []string{"NSArray", "MyClass"}},
+		{typ: "NSDictionary<NSString *, MyValue *> *", wantClasses: []string{"NSDictionary", "NSString", "MyValue"}},
+		{typ: "NSDictionary<NSString *, NSArray<id<Foo>> *> *", wantClasses: []string{"NSDictionary", "NSString", "NSArray"}, wantProtos: []string{"Foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			gotClasses, gotProtos := typeRefs(tt.typ)
+			if !reflect.DeepEqual(gotClasses, tt.wantClasses) || !reflect.DeepEqual(gotProtos, tt.wantProtos) {
+				t.Errorf("typeRefs() = %v, %v, want %v, %v", gotClasses, gotProtos, tt.wantClasses, tt.wantProtos)
+			}
+		})
+	}
+}
+
+func Test_methodDecl(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  objc.Method
+		isClass bool
+		want    string
+	}{
+		{
+			name:   "init",
+			method: objc.Method{Name: "init", Types: "@16@0:8"},
+			want:   "(instancetype)init;",
+		},
+		{
+			name:   "initWith",
+			method: objc.Method{Name: "initWithName:", Types: `@24@0:8@"NSString"16`},
+			want:   "(instancetype)initWithName:(NSString *)name;",
+		},
+		{
+			name:   "not init family",
+			method: objc.Method{Name: "initialize", Types: "@16@0:8"},
+			want:   "(id)initialize;",
+		},
+		{
+			name:    "class method",
+			method:  objc.Method{Name: "init", Types: "@16@0:8"},
+			isClass: true,
+			want:    "(id)init;",
+		},
+		{
+			name:   "protocol qualified param",
+			method: objc.Method{Name: "setDelegate:", Types: `v24@0:8@"<FooDelegate>"16`},
+			want:   "(void)setDelegate:(id<FooDelegate>)delegate;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := methodDecl(&tt.method, tt.isClass); got != tt.want {
+				t.Errorf("methodDecl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_namedMethodDecl(t *testing.T) {
+	tests := []struct {
+		name   string
+		method objc.Method
+		want   string
+	}{
+		{
+			name:   "no params",
+			method: objc.Method{Name: "count", Types: "Q16@0:8"},
+			want:   "(unsigned long long)count;",
+		},
+		{
+			name:   "keyword parts",
+			method: objc.Method{Name: "initWithFoo:bar:", Types: `@32@0:8@"NSString"16q24`},
+			want:   "(instancetype)initWithFoo:(NSString *)foo bar:(long long)bar;",
+		},
+		{
+			name:   "prepositions and verbs",
+			method: objc.Method{Name: "addObserver:forKeyPath:options:context:", Types: "v48@0:8@16@24Q32^v40"},
+			want:   "(void)addObserver:(id)observer forKeyPath:(id)keyPath options:(unsigned long long)options context:(void *)context;",
+		},
+		{
+			name:   "acronym",
+			method: objc.Method{Name: "URLForResource:withExtension:", Types: "@32@0:8@16@24"},
+			want:   "(id)URLForResource:(id)resource withExtension:(id)extension;",
+		},
+		{
+			name:   "reserved and duplicate names",
+			method: objc.Method{Name: "setDouble:andDouble:", Types: "v32@0:8d16d24"},
+			want:   "(void)setDouble:(double)aDouble andDouble:(double)aDouble2;",
+		},
+		{
+			name:   "anonymous keyword",
+			method: objc.Method{Name: "foo::", Types: "v32@0:8@16@24"},
+			want:   "(void)foo:(id)foo :(id)arg2;",
+		},
+		{
+			name:   "trailing colon-less part",
+			method: objc.Method{Name: "foo:bar", Types: "v24@0:8@16"},
+			want:   "(void)foo:(id)foo bar;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := namedMethodDecl(&tt.method, false); got != tt.want {
+				t.Errorf("namedMethodDecl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjC_inlineFoundation(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.InlineFoundation = true
+	classes := []objc.Class{{
+		Name:       "Foo",
+		SuperClass: "NSObject",
+		Protocols:  []objc.Protocol{{Name: "NSCopying"}},
+		Ivars:      []objc.Ivar{{Name: "_name", Type: `@"NSString"`}},
+	}}
+	got := o.forwardDeclarations(classes, nil)["Foo"]
+	want := Imports{Classes: []string{"NSObject", "NSString"}, Protos: []string{"NSCopying"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forwardDeclarations()[Foo] = %#v, want %#v", got, want)
+	}
+
+	output := t.TempDir()
+	fname := filepath.Join(output, "Foo.h")
+	if err := o.writeHeader(&headerInfo{FileName: fname, Name: "Foo", Imports: got}); err != nil {
+		t.Fatalf("writeHeader() error = %v", err)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Foundation") {
+		t.Errorf("header should not import Foundation:\n%s", data)
+	}
+	if !strings.Contains(string(data), "@class NSObject, NSString;\n@protocol NSCopying;\n") {
+		t.Errorf("header is missing the Foundation forward declarations:\n%s", data)
+	}
+}
+
+func TestObjC_dumpMethodKinds(t *testing.T) {
+	class := objc.Class{
+		Name:            "Foo",
+		SuperClass:      "NSObject",
+		ClassMethods:    []objc.Method{{Name: "sharedFoo", Types: "@16@0:8"}},
+		InstanceMethods: []objc.Method{{Name: "setName:", Types: "v24@0:8@16"}},
+	}
+	proto := objc.Protocol{
+		Name:                    "FooDelegate",
+		InstanceMethods:         []objc.Method{{Name: "fooDidLoad", Types: "v16@0:8"}},
+		OptionalClassMethods:    []objc.Method{{Name: "defaultDelegate", Types: "@16@0:8"}},
+		OptionalInstanceMethods: []objc.Method{{Name: "fooDidUnload", Types: "v16@0:8"}},
+	}
+	tests := []struct {
+		name string
+		dump func(o *ObjC) string
+		want string
+	}{
+		{
+			name: "class",
+			dump: func(o *ObjC) string { return o.dumpClass(&class, false, false) },
+			want: "@interface Foo : NSObject\n" +
+				"/* class methods */\n" +
+				"+[Foo sharedFoo];\n" +
+				"\n" +
+				"/* instance methods */\n" +
+				"-[Foo setName:];\n" +
+				"@end\n",
+		},
+		{
+			name: "class verbose",
+			dump: func(o *ObjC) string { return o.dumpClass(&class, true, false) },
+			want: "@interface Foo : NSObject\n" +
+				"/* class methods */\n" +
+				"+ (id)sharedFoo;\n" +
+				"\n" +
+				"/* instance methods */\n" +
+				"- (void)setName:(id)name;\n" +
+				"@end\n",
+		},
+		{
+			name: "category",
+			dump: func(o *ObjC) string {
+				return o.dumpCategory(&objc.Category{
+					Name:         "Bar",
+					Class:        &class,
+					ClassMethods: class.ClassMethods,
+				}, false, false)
+			},
+			want: "@interface Foo (Bar)\n" +
+				"/* class methods */\n" +
+				"+[Foo(Bar) sharedFoo];\n" +
+				"@end\n",
+		},
+		{
+			name: "protocol",
+			dump: func(o *ObjC) string { return o.dumpProtocol(&proto, false, false) },
+			want: "@protocol FooDelegate \n" +
+				"@required\n" +
+				"/* instance methods */\n" +
+				"-[FooDelegate fooDidLoad];\n" +
+				"\n" +
+				"@optional\n" +
+				"/* class methods */\n" +
+				"+[FooDelegate defaultDelegate];\n" +
+				"\n" +
+				"/* instance methods */\n" +
+				"-[FooDelegate fooDidUnload];\n" +
+				"@end\n",
+		},
+		{
+			name: "protocol verbose",
+			dump: func(o *ObjC) string { return o.dumpProtocol(&proto, true, false) },
+			want: "@protocol FooDelegate \n" +
+				"@required\n" +
+				"/* instance methods */\n" +
+				"- (void)fooDidLoad;\n" +
+				"\n" +
+				"@optional\n" +
+				"/* class methods */\n" +
+				"+ (id)defaultDelegate;\n" +
+				"\n" +
+				"/* instance methods */\n" +
+				"- (void)fooDidUnload;\n" +
+				"@end\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dump(newTestObjC(t)); got != tt.want {
+				t.Errorf("dump() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoundationCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foundation.json")
+	want := map[string][]string{
+		"classes":   {"NSObject", "NSString"},
+		"protocols": {"NSCopying"},
+	}
+	if err := writeFoundationCache(path, "UUID-1", want); err != nil {
+		t.Fatalf("writeFoundationCache() error = %v", err)
+	}
+	got, err := readFoundationCache(path, "UUID-1")
+	if err != nil {
+		t.Fatalf("readFoundationCache() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readFoundationCache() = %v, want %v", got, want)
+	}
+	if _, err := readFoundationCache(path, "UUID-2"); err == nil {
+		t.Errorf("readFoundationCache() with a different UUID should fail")
+	}
+}
+
+// BenchmarkObjC_scanFoundation compares scanning Foundation from the DSC at $IPSW_TEST_DSC to loading the cached names
+func BenchmarkObjC_scanFoundation(b *testing.B) {
+	dscPath := os.Getenv("IPSW_TEST_DSC")
+	if len(dscPath) == 0 {
+		b.Skip("IPSW_TEST_DSC not set")
+	}
+	f, err := dyld.Open(dscPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, cached := range []bool{false, true} {
+		conf := &ObjcConfig{}
+		if cached {
+			conf.FoundationCache = filepath.Join(b.TempDir(), "foundation.json")
+		}
+		b.Run(fmt.Sprintf("cached=%t", cached), func(b *testing.B) {
+			o := &ObjC{conf: conf, cache: f, foundation: make(map[string][]string)}
+			if cached { // prime the cache
+				if err := o.scanFoundation(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := o.scanFoundation(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestInventoryItem_fieldOrder(t *testing.T) {
+	got, err := json.Marshal(InventoryItem{Image: "/usr/lib/libFoo.dylib", Kind: "category", Name: "Foo(Bar)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"image":"/usr/lib/libFoo.dylib","kind":"category","name":"Foo(Bar)"}`
+	if string(got) != want {
+		t.Errorf("json.Marshal(InventoryItem) = %s, want %s", got, want)
+	}
+}
+
+func Test_ivarType(t *testing.T) {
+	tests := []struct {
+		name string
+		ivar objc.Ivar
+		want string
+	}{
+		{"object", objc.Ivar{Name: "_name", Type: `@"NSString"`}, "NSString *"},
+		{"scalar", objc.Ivar{Name: "_count", Type: "Q"}, "unsigned long long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ivarType(&tt.ivar); got != tt.want {
+				t.Errorf("ivarType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_swiftName(t *testing.T) {
+	tests := []struct {
+		sel    string
+		want   string
+		wantOk bool
+	}{
+		{"count", "count()", true},
+		{"setName:", "setName(_:)", true},
+		{"initWithFrame:", "init(frame:)", true},
+		{"initWithURL:", "init(url:)", true},
+		{"objectForKey:", "object(forKey:)", true},
+		{"tableView:numberOfRowsInSection:", "tableView(_:numberOfRowsInSection:)", true},
+		{"performSelector:withObject:afterDelay:", "performSelector(_:withObject:afterDelay:)", true},
+		{"foo::", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sel, func(t *testing.T) {
+			got, ok := swiftName(tt.sel)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("swiftName() = %q, %t, want %q, %t", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_swiftDecl(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  objc.Method
+		isClass bool
+		want    string
+	}{
+		{"class method", objc.Method{Name: "sharedFoo", Types: "@16@0:8"}, true, "class func sharedFoo() -> Any"},
+		{"instance method", objc.Method{Name: "setName:", Types: `v24@0:8@"NSString"16`}, false, "func setName(_ name: String)"},
+		{"init", objc.Method{Name: "initWithCount:", Types: "@24@0:8Q16"}, false, "init(count: UInt)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := swiftDecl(&tt.method, tt.isClass); got != tt.want {
+				t.Errorf("swiftDecl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_propertyAccessors(t *testing.T) {
+	tests := []struct {
+		attrs      string
+		name       string
+		wantGetter string
+		wantSetter string
+	}{
+		{attrs: `T@"NSString",C,N,V_title`, name: "title", wantGetter: "title", wantSetter: "setTitle:"},
+		{attrs: "TB,N,GisEnabled,V_enabled", name: "enabled", wantGetter: "isEnabled", wantSetter: "setEnabled:"},
+		{attrs: "Tq,N,SupdateCount:,V_count", name: "count", wantGetter: "count", wantSetter: "updateCount:"},
+		{attrs: `T@"NSArray",R,N`, name: "items", wantGetter: "items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter, setter := propertyAccessors(&objc.Property{Name: tt.name, EncodedAttributes: tt.attrs})
+			if getter != tt.wantGetter || setter != tt.wantSetter {
+				t.Errorf("propertyAccessors() = %q, %q, want %q, %q", getter, setter, tt.wantGetter, tt.wantSetter)
+			}
+		})
+	}
+}
+
+func Test_kvcKeys(t *testing.T) {
+	class := objc.Class{
+		Name: "Foo",
+		Props: []objc.Property{
+			{Name: "title"},
+		},
+		InstanceMethods: []objc.Method{
+			{Name: "isEnabled", Types: "B16@0:8"},
+			{Name: "getColor", Types: "@16@0:8"},
+			{Name: "countOfItems", Types: "Q16@0:8"},
+			{Name: "_count", Types: "Q16@0:8"},
+			{Name: "issue", Types: "@16@0:8"},
+			{Name: "reload", Types: "v16@0:8"},
+			{Name: "setTitle:", Types: "v24@0:8@16"},
+			{Name: ".cxx_destruct", Types: "@16@0:8"},
+		},
+	}
+	want := []string{"color", "count", "enabled", "issue", "items", "title"}
+	if got := kvcKeys(&class); !reflect.DeepEqual(got, want) {
+		t.Errorf("kvcKeys() = %v, want %v", got, want)
+	}
+}
+
+func Test_isSwiftSynthetic(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"NSObject", false},
+		{"_TtC7SwiftUI15AppDelegateShim", false},
+		{"_TtCs19__EmptyArrayStorage", true},
+		{"_TtGC7SwiftUI14_UIHostingViewVS_7AnyView_", true},
+		{"_TtC7SwiftUIP33_8825076C2763A50452A210CBE1FA4AF020PlatformViewHost", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSwiftSynthetic(tt.name); got != tt.want {
+				t.Errorf("isSwiftSynthetic() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjC_anonymousCategory(t *testing.T) {
+	o := newTestObjC(t)
+	class := objc.Class{Name: "Foo", SuperClass: "NSObject"}
+	cat := objc.Category{
+		Class:           &class,
+		InstanceMethods: []objc.Method{{Name: "reload", Types: "v16@0:8"}},
+	}
+	info := categoryHeaderFileInfo(cat)
+	if info.Kind != "extension" {
+		t.Errorf("categoryHeaderFileInfo().Kind = %s, want extension", info.Kind)
+	}
+	fname, err := o.headerFileName(info)
+	if err != nil {
+		t.Fatalf("headerFileName() error = %v", err)
+	}
+	if want := "Foo-Private.h"; fname != want {
+		t.Errorf("headerFileName() = %s, want %s", fname, want)
+	}
+	want := "@interface Foo ()\n" +
+		"/* instance methods */\n" +
+		"- (void)reload;\n" +
+		"@end\n"
+	if got := o.dumpCategory(&cat, true, false); got != want {
+		t.Errorf("dumpCategory() = %q, want %q", got, want)
+	}
+}
+
+func Test_splitPrivateMembers(t *testing.T) {
+	o := newTestObjC(t)
+	class := objc.Class{
+		Name:       "Foo",
+		SuperClass: "NSObject",
+		Props:      []objc.Property{{Name: "name"}, {Name: "_state"}},
+		ClassMethods: []objc.Method{
+			{Name: "sharedFoo", Types: "@16@0:8"},
+			{Name: "_resetShared", Types: "v16@0:8"},
+		},
+		InstanceMethods: []objc.Method{
+			{Name: "reload", Types: "v16@0:8"},
+			{Name: "_reloadIfNeeded", Types: "v16@0:8"},
+		},
+	}
+	ext := splitPrivateMembers(&class)
+	if ext == nil {
+		t.Fatal("splitPrivateMembers() = nil, want the private members")
+	}
+	if len(class.Props) != 1 || class.Props[0].Name != "name" ||
+		len(class.ClassMethods) != 1 || class.ClassMethods[0].Name != "sharedFoo" ||
+		len(class.InstanceMethods) != 1 || class.InstanceMethods[0].Name != "reload" {
+		t.Errorf("splitPrivateMembers() left class members %v %v %v, want only the public ones", class.Props, class.ClassMethods, class.InstanceMethods)
+	}
+	if splitPrivateMembers(&class) != nil {
+		t.Error("splitPrivateMembers() of a class w/o private members != nil")
+	}
+
+	bar := objc.Class{Name: "Bar"}
+	cats := []objc.Category{
+		{Name: "Extras", Class: &class},
+		{Class: &bar, InstanceMethods: []objc.Method{{Name: "_barHelper", Types: "v16@0:8"}}},
+		{Class: &class, InstanceMethods: []objc.Method{{Name: "invalidate", Types: "v16@0:8"}}},
+	}
+	cats = mergePrivateExtensions(cats, map[string]*objc.Category{"Foo": ext})
+	if len(cats) != 3 {
+		t.Fatalf("mergePrivateExtensions() = %d categories, want 3 (merged into Foo's extension)", len(cats))
+	}
+	info := categoryHeaderFileInfo(cats[2])
+	fname, err := o.headerFileName(info)
+	if err != nil {
+		t.Fatalf("headerFileName() error = %v", err)
+	}
+	if want := "Foo-Private.h"; fname != want {
+		t.Errorf("headerFileName() = %s, want %s", fname, want)
+	}
+	want := "@interface Foo ()\n" +
+		"/* class methods */\n" +
+		"+ (void)_resetShared;\n" +
+		"\n" +
+		"/* instance methods */\n" +
+		"- (void)_reloadIfNeeded;\n" +
+		"- (void)invalidate;\n" +
+		"@end\n"
+	cats[2].Properties = nil // property attributes aren't part of the fixture
+	if got := o.dumpCategory(&cats[2], true, false); got != want {
+		t.Errorf("dumpCategory() = %q, want %q", got, want)
+	}
+
+	cats = mergePrivateExtensions([]objc.Category{{Name: "Extras", Class: &class}}, map[string]*objc.Category{"Foo": ext})
+	if len(cats) != 2 || len(cats[1].Name) != 0 || cats[1].Class.Name != "Foo" || len(cats[1].Properties) != 1 {
+		t.Errorf("mergePrivateExtensions() = %v, want a new Foo extension with the private members", cats)
+	}
+}
+
+func TestObjC_headersBaseline(t *testing.T) {
+	baseline, output := t.TempDir(), t.TempDir()
+	for name, data := range map[string]string{
+		"A.h": "//\n//   Generated by https://github.com/blacktop/ipsw (Version: 1.0.0)\n//\n#ifndef A_h\n#define A_h\n\n\n#endif /* A_h */\n",
+		"B.h": "#ifndef B_h\n#define B_h\n#endif /* B_h */\n",
+		"D.h": "#ifndef D_h\n#define D_h\n#endif /* D_h */\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(baseline, "Foo"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(baseline, "Foo", name), []byte(data), 0o660); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an image that is no longer generated at all
+	if err := os.MkdirAll(filepath.Join(baseline, "Gone"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Gone.h", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(baseline, "Gone", name), nil, 0o660); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := newTestObjC(t)
+	o.conf = &ObjcConfig{Output: output, Baseline: baseline, IpswVersion: "Version: 2.0.0"}
+	o.changes = &headerChanges{written: make(map[string]bool), folders: make(map[string]bool)}
+	for _, name := range []string{"A", "B", "C"} {
+		hdr := &headerInfo{FileName: filepath.Join(output, "Foo", name+".h"), IpswVersion: o.conf.IpswVersion, Name: name}
+		if name == "B" {
+			hdr.Object = "@interface B : NSObject\n@end\n"
+		}
+		if name == "A" {
+			hdr.IsUmbrella = true
+		}
+		if err := o.writeHeader(hdr); err != nil {
+			t.Fatalf("writeHeader() error = %v", err)
+		}
+	}
+	if err := o.writeChanges(); err != nil {
+		t.Fatalf("writeChanges() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(output, "Foo", "A.h")); err == nil {
+		t.Errorf("unchanged header A.h should not be written")
+	}
+	got, err := os.ReadFile(filepath.Join(output, "changes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Added (1):\n  Foo/C.h\nModified (1):\n  Foo/B.h\nRemoved (1):\n  Foo/D.h\n"
+	if string(got) != want {
+		t.Errorf("changes.txt = %q, want %q", got, want)
+	}
+
+	// the images that weren't generated are only gone if the run generates all of them
+	o.conf.BaselineAll = true
+	o.changes.Removed = nil
+	if err := o.writeChanges(); err != nil {
+		t.Fatalf("writeChanges() error = %v", err)
+	}
+	if got, err = os.ReadFile(filepath.Join(output, "changes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	want = "Added (1):\n  Foo/C.h\nModified (1):\n  Foo/B.h\nRemoved (2):\n  Foo/D.h\n  Gone/Gone.h\n"
+	if string(got) != want {
+		t.Errorf("changes.txt with BaselineAll = %q, want %q", got, want)
+	}
+}
+
+func TestObjC_noObjC(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.Name = "foo"
+	m := &macho.File{}
+	if o.noObjC(m, "classes", fmt.Errorf("bad classes")) {
+		t.Errorf("noObjC() = true for a non missing section error")
+	}
+	for _, kind := range []string{"classes", "protocols", "classes"} {
+		if !o.noObjC(m, kind, fmt.Errorf("failed to get classes: %w", macho.ErrObjcSectionNotFound)) {
+			t.Errorf("noObjC() = false for a missing section error")
+		}
+	}
+	want := map[string][]string{"foo": {"classes", "protocols"}}
+	if got := o.Skipped(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Skipped() = %v, want %v", got, want)
+	}
+}
+
+func TestObjC_writeOutputArchive(t *testing.T) {
+	var buf bytes.Buffer
+	o := newTestObjC(t)
+	o.archive = tar.NewWriter(&buf)
+	for _, name := range []string{"A", "B"} {
+		if err := o.writeHeader(&headerInfo{FileName: filepath.Join("Foo", name+".h"), Name: name}); err != nil {
+			t.Fatalf("writeHeader() error = %v", err)
+		}
+	}
+	if err := o.archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, hdr.Name)
+	}
+	if want := []string{"Foo/A.h", "Foo/B.h"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("archive entries = %v, want %v", got, want)
+	}
+	if !IsTarGz("headers.tgz") || !IsTarGz("out/headers.tar.gz") || IsTarGz("headers") {
+		t.Errorf("IsTarGz() detected the wrong archive outputs")
+	}
+}
+
+func TestObjC_compilePattern(t *testing.T) {
+	name := "_TtC7Example3Foo$Bar(baz)"
+	o := newTestObjC(t)
+	if _, err := o.compilePattern("Foo$Bar(baz"); err == nil {
+		t.Errorf("compilePattern() expected a regex error")
+	}
+	o.conf.Literal = true
+	re, err := o.compilePattern("Foo$Bar(baz)")
+	if err != nil {
+		t.Fatalf("compilePattern() error = %v", err)
+	}
+	if !re.MatchString(name) {
+		t.Errorf("compilePattern() literal pattern did not match %s", name)
+	}
+}
+
+func Test_umbrellaImports(t *testing.T) {
+	o := newTestObjC(t)
+	// two protocol pointers sharing the same name generate the same header
+	var headers []string
+	for _, proto := range []objc.Protocol{{Name: "FooDelegate", Ptr: 0x1000}, {Name: "Bar", Ptr: 0x2000}, {Name: "FooDelegate", Ptr: 0x3000}} {
+		headers = append(headers, o.localHeader("protocol", proto.Name))
+	}
+	want := "#import \"FooDelegate-Protocol.h\"\n#import \"Bar-Protocol.h\"\n"
+	if got := umbrellaImports(headers); got != want {
+		t.Errorf("umbrellaImports() = %q, want %q", got, want)
+	}
+}
+
+func Test_swiftClassName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "_TtC7Example3Foo", want: "Foo", wantOk: true},
+		{name: "_TtCC7Example5Outer5Inner", want: "Inner", wantOk: true},
+		{name: "Foo"}, // @objc(Foo) renamed class
+		{name: "_TtCs19__EmptyArrayStorage"},
+		{name: "_TtC7Example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := swiftClassName(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("swiftClassName() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	o := newTestObjC(t)
+	o.conf.SwiftAliases = true
+	if got := o.swiftAliases("Foo"); got != nil {
+		t.Errorf("swiftAliases() = %v for an @objc renamed class, want none", got)
+	}
+	want := []string{"@compatibility_alias Foo _TtC7Example3Foo;"}
+	if got := o.swiftAliases("_TtC7Example3Foo"); !reflect.DeepEqual(got, want) {
+		t.Errorf("swiftAliases() = %v, want %v", got, want)
+	}
+}
+
+func Test_swiftModuleName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "_TtC7Example3Foo", want: "Example", wantOk: true},
+		{name: "_TtCC7Example5Outer5Inner", want: "Example", wantOk: true},
+		{name: "Example.Foo", want: "Example", wantOk: true},
+		{name: "Foo"}, // @objc(Foo) renamed class
+		{name: "_TtCs19__EmptyArrayStorage"},
+		{name: ".Foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := swiftModuleName(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("swiftModuleName() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	o := newTestObjC(t)
+	if o.skipClass("Foo") {
+		t.Errorf("skipClass() = true w/o a module filter")
+	}
+	o.conf.Module = "Example"
+	for name, want := range map[string]bool{"_TtC7Example3Foo": false, "_TtC5Other3Foo": true, "Foo": true} {
+		if got := o.skipClass(name); got != want {
+			t.Errorf("skipClass(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestObjC_jsonOrder(t *testing.T) {
+	methods := []objc.Method{{Name: "zeta", Types: "v16@0:8"}, {Name: "alpha", Types: "v16@0:8"}}
+	props := []objc.Property{{Name: "b", EncodedAttributes: "Tq,N"}, {Name: "a", EncodedAttributes: "Tq,N"}}
+	m := &macho.File{}
+	for _, sorted := range []bool{false, true} {
+		o := newTestObjC(t)
+		o.conf.SortMethods = sorted
+		wantMethods, wantProps := []string{"zeta", "alpha"}, []string{"b", "a"}
+		if sorted {
+			wantMethods, wantProps = []string{"alpha", "zeta"}, []string{"a", "b"}
+		}
+		var gotMethods, gotProps []string
+		for _, meth := range o.jsonMethods(m, methods) {
+			gotMethods = append(gotMethods, meth.Name)
+		}
+		for _, prop := range o.jsonProperties(props) {
+			gotProps = append(gotProps, prop.Name)
+		}
+		if !reflect.DeepEqual(gotMethods, wantMethods) || !reflect.DeepEqual(gotProps, wantProps) {
+			t.Errorf("SortMethods=%v order = %v, %v, want %v, %v", sorted, gotMethods, gotProps, wantMethods, wantProps)
+		}
+	}
+}
+
+func Test_resolveCategoryClassNames(t *testing.T) {
+	cats := []objc.Category{
+		{Name: "Named", Class: &objc.Class{Name: "NSString"}, CategoryT: objc.CategoryT{ClsVMAddr: 0x1000}},
+		{Name: "Empty", Class: &objc.Class{}, CategoryT: objc.CategoryT{ClsVMAddr: 0x2000}},
+		{Name: "Nil", CategoryT: objc.CategoryT{ClsVMAddr: 0x3000}},
+		{Name: "Unknown", CategoryT: objc.CategoryT{ClsVMAddr: 0x4000}},
+		{Name: "NoPtr"},
+	}
+	calls := 0
+	resolveCategoryClassNames(cats, func() map[uint64]string {
+		calls++
+		return map[uint64]string{0x1000: "Other", 0x2000: "UIView", 0x3000: "NSData"}
+	})
+	if calls != 1 {
+		t.Errorf("resolveCategoryClassNames() looked up names %d times, want 1", calls)
+	}
+	want := []string{"NSString", "UIView", "NSData", "", ""}
+	for i, cat := range cats {
+		var got string
+		if cat.Class != nil {
+			got = cat.Class.Name
+		}
+		if got != want[i] {
+			t.Errorf("category %s class = %q, want %q", cat.Name, got, want[i])
+		}
+	}
+	if got := categoryHeaderFileInfo(cats[1]); got.Class != "UIView" {
+		t.Errorf("categoryHeaderFileInfo() class = %q, want UIView", got.Class)
+	}
+
+	resolveCategoryClassNames(cats[:1], func() map[uint64]string {
+		t.Error("resolveCategoryClassNames() looked up names with no unresolved categories")
+		return nil
+	})
+}
+
+func Test_clangErrors(t *testing.T) {
+	out := []byte(`In file included from Foo/Foo.h:10:
+Foo/Bar.h:12:1: error: unknown type name 'Baz'
+Foo/Bar.h:14:5: warning: declaration is missing nullability
+Foo/Bar.h:20:9: error: expected ';' after method prototype
+Foo/Qux-Protocol.h:3:9: fatal error: 'Missing.h' file not found
+3 errors generated.
+`)
+	got := clangErrors(out)
+	want := map[string]int{"Foo/Bar.h": 2, "Foo/Qux-Protocol.h": 1}
+	if len(got) != len(want) {
+		t.Fatalf("clangErrors() = %v, want headers %v", got, want)
+	}
+	for header, n := range want {
+		if len(got[header]) != n {
+			t.Errorf("clangErrors()[%s] = %v, want %d error(s)", header, got[header], n)
+		}
+	}
+}
+
+func Test_ivarAccess(t *testing.T) {
+	syms := []macho.Symbol{
+		{Name: "_OBJC_CLASS_$_Foo", Type: types.N_SECT | types.N_EXT},
+		{Name: "_OBJC_IVAR_$_Foo._public", Type: types.N_SECT | types.N_EXT},
+		{Name: "_OBJC_IVAR_$_Foo._secret", Type: types.N_SECT | types.N_PEXT},
+		{Name: "_OBJC_CLASS_$_Hidden", Type: types.N_SECT | types.N_PEXT},
+		{Name: "_OBJC_IVAR_$_Hidden._ivar", Type: types.N_SECT | types.N_PEXT},
+	}
+	want := map[string]bool{"Foo._secret": true}
+	if got := hiddenIvars(syms); !reflect.DeepEqual(got, want) {
+		t.Errorf("hiddenIvars() = %v, want %v", got, want)
+	}
+
+	o := newTestObjC(t)
+	o.hidden = want
+	class := &objc.Class{
+		Name:       "Foo",
+		SuperClass: "NSObject",
+		Ivars: []objc.Ivar{
+			{Name: "_public", Type: "i"},
+			{Name: "_secret", Type: "i"},
+			{Name: "_other", Type: "i"},
+		},
+	}
+	got := o.dumpClass(class, false, false)
+	if strings.Contains(got, "@protected\n  i _public") { // a @public ivar looks like a @protected one
+		t.Errorf("dumpClass() marked the default ivar scope:\n%s", got)
+	}
+	if !strings.Contains(got, "  @private // or @package\n  i _secret;\n  @protected\n  i _other;") {
+		t.Errorf("dumpClass() = \n%s\nwant @private (or @package) _secret and @protected _other", got)
+	}
+}
+
+func TestObjC_forEachImage(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.Threads = 2
+	var mu sync.Mutex
+	var running, peak int
+	seen := make([]bool, 10)
+	if err := o.forEachImage(len(seen), func(i int) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		seen[i] = true
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}); err != nil {
+		t.Fatalf("forEachImage() error = %v", err)
+	}
+	if peak > 2 {
+		t.Errorf("forEachImage() ran %d images at once, want at most 2", peak)
+	}
+	if slices.Contains(seen, false) {
+		t.Errorf("forEachImage() skipped images: %v", seen)
+	}
+
+	want := fmt.Errorf("bad image")
+	if err := o.forEachImage(3, func(i int) error {
+		if i == 1 {
+			return want
+		}
+		return nil
+	}); err != want {
+		t.Errorf("forEachImage() error = %v, want %v", err, want)
+	}
+
+	o.conf.Threads = 0
+	if got := o.threads(); got < 1 || got > maxObjcThreads {
+		t.Errorf("threads() = %d, want 1-%d", got, maxObjcThreads)
+	}
+}
+
+func TestObjC_getStubsConcurrent(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.Threads = 4
+	o.stubs = make(map[*macho.File]map[uint64]uint64)
+	ms := []*macho.File{{}, {}, {}}
+	// the workers of forEachImage (e.g. SelectorAddressMap w/ Deps) resolve stubs of (possibly) the same MachOs at once
+	if err := o.forEachImage(3*len(ms), func(i int) error {
+		o.getStubs(ms[i%len(ms)])
+		return nil
+	}); err != nil {
+		t.Fatalf("forEachImage() error = %v", err)
+	}
+	if len(o.stubs) != len(ms) {
+		t.Errorf("getStubs() cached %d MachOs, want %d", len(o.stubs), len(ms))
+	}
+}
+
+func Test_newImageInfo(t *testing.T) {
+	section := func(name string) *types.Section {
+		return &types.Section{SectionHeader: types.SectionHeader{Name: name, Seg: "__TEXT"}}
+	}
+	tests := []struct {
+		name           string
+		sections       []string
+		wantMetadata   int
+		wantReflection bool
+	}{
+		{"objc only", []string{"__text", "__objc_methname"}, 0, false},
+		{"swift w/ reflection", []string{"__text", "__swift5_types", "__swift5_fieldmd", "__swift5_reflstr"}, 5, true},
+		{"swift w/o reflection", []string{"__swift5_types", "__swift5_protos"}, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &macho.File{}
+			for _, name := range tt.sections {
+				m.Sections = append(m.Sections, section(name))
+			}
+			got := newImageInfo(m, &objc.ImageInfo{})
+			if got.SwiftMetadata != tt.wantMetadata || got.SwiftReflection != tt.wantReflection {
+				t.Errorf("newImageInfo() swift metadata = %d, reflection = %v, want %d, %v", got.SwiftMetadata, got.SwiftReflection, tt.wantMetadata, tt.wantReflection)
+			}
+		})
+	}
+}
+
+func TestObjC_forwardImports(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.Name = "Example"
+	o.outHeaders = map[string]string{
+		"class:Foo":        "Example",
+		"class:Bar":        "OtherKit",
+		"protocol:Baz":     "OtherKit",
+		"protocol:Example": "Example",
+	}
+	imp := Imports{
+		Locals:  []string{"Super.h"},
+		Classes: []string{"Bar", "Foo", "Missing"},
+		Protos:  []string{"Baz", "Example", "Unknown"},
+	}
+	want := Imports{
+		Imports: []string{"../OtherKit/Bar.h", "../OtherKit/Baz-Protocol.h"},
+		Locals:  []string{"Example-Protocol.h", "Foo.h", "Super.h"},
+		Classes: []string{"Missing"},
+		Protos:  []string{"Unknown"},
+	}
+	if got := o.forwardImports(imp); !reflect.DeepEqual(got, want) {
+		t.Errorf("forwardImports() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(imp.Classes, []string{"Bar", "Foo", "Missing"}) {
+		t.Errorf("forwardImports() modified its input: %+v", imp)
+	}
+}
+
+func Test_diffIvarLayouts(t *testing.T) {
+	ivar := func(name, typ string, offset, size uint32) objc.Ivar {
+		return objc.Ivar{Name: name, Type: typ, Offset: offset, IvarT: objc.IvarT{Size: size}}
+	}
+	class := func(name string, size uint64, ivars ...objc.Ivar) objc.Class {
+		return objc.Class{Name: name, Ivars: ivars, ReadOnlyData: objc.ClassRO64{InstanceSize: size}}
+	}
+	old := []objc.Class{
+		class("Foo", 0x20, ivar("_a", "q", 0x8, 8), ivar("_b", "i", 0x10, 4), ivar("_gone", "@", 0x18, 8)),
+		class("Same", 0x10, ivar("_x", "q", 0x8, 8)),
+		class("Removed", 0x10),
+	}
+	new := []objc.Class{
+		class("Foo", 0x28, ivar("_a", "q", 0x8, 8), ivar("_new", "@", 0x10, 8), ivar("_b", "q", 0x18, 8)),
+		class("Same", 0x10, ivar("_x", "q", 0x8, 8)),
+		class("Added", 0x10),
+	}
+	want := []ClassLayoutDiff{{
+		Image:             "/System/Library/Frameworks/Example.framework/Example",
+		Class:             "Foo",
+		OldInstanceSize:   0x20,
+		NewInstanceSize:   0x28,
+		InstanceSizeDelta: 8,
+		Added:             []IvarLayout{{Name: "_new", Type: "id", Offset: 0x10, Size: 8}},
+		Removed:           []IvarLayout{{Name: "_gone", Type: "id", Offset: 0x18, Size: 8}},
+		Changed:           []IvarLayoutChange{{Name: "_b", Type: "long long", OldOffset: 0x10, NewOffset: 0x18, OldSize: 4, NewSize: 8}},
+	}}
+	if got := diffIvarLayouts("/System/Library/Frameworks/Example.framework/Example", old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffIvarLayouts() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_headerTags(t *testing.T) {
+	header := "#ifndef Foo_h\n" +
+		"#define Foo_h\n" +
+		"@class Bar;\n" +
+		"@protocol Baz;\n" +
+		"\n" +
+		"@interface Foo : NSObject<NSCopying>\n" +
+		"@property (readonly, nonatomic) NSString *name;\n" +
+		"/* class methods */\n" +
+		"+ (id)sharedFoo;\n" +
+		"/* instance methods */\n" +
+		"- (void)setName:(id)name forKey:(id)key;\n" +
+		"- (void)enumerate:(void (^)(id, BOOL *))block;\n" +
+		"@end\n" +
+		"@interface Foo (Bar)\n" +
+		"- (void)reload;\n" +
+		"@end\n" +
+		"@interface Foo ()\n" +
+		"- (void)_reset;\n" +
+		"@end\n" +
+		"@protocol FooDelegate <NSObject>\n" +
+		"@optional\n" +
+		"- (void)fooDidLoad:(id)foo;\n" +
+		"@end\n" +
+		"#endif /* Foo_h */\n"
+	var got []string
+	for _, tag := range headerTags("Example/Foo.h", header) {
+		got = append(got, tag.String())
+	}
+	want := []string{
+		"Foo\tExample/Foo.h\t/^@interface Foo : NSObject<NSCopying>$/;\"\ti",
+		"name\tExample/Foo.h\t/^@property (readonly, nonatomic) NSString *name;$/;\"\tp\tinterface:Foo",
+		"sharedFoo\tExample/Foo.h\t/^+ (id)sharedFoo;$/;\"\tc\tinterface:Foo",
+		"setName:forKey:\tExample/Foo.h\t/^- (void)setName:(id)name forKey:(id)key;$/;\"\tm\tinterface:Foo",
+		"enumerate:\tExample/Foo.h\t/^- (void)enumerate:(void (^)(id, BOOL *))block;$/;\"\tm\tinterface:Foo",
+		"Foo(Bar)\tExample/Foo.h\t/^@interface Foo (Bar)$/;\"\tC",
+		"reload\tExample/Foo.h\t/^- (void)reload;$/;\"\tm\tcategory:Foo(Bar)",
+		"_reset\tExample/Foo.h\t/^- (void)_reset;$/;\"\tm\tinterface:Foo",
+		"FooDelegate\tExample/Foo.h\t/^@protocol FooDelegate <NSObject>$/;\"\tP",
+		"fooDidLoad:\tExample/Foo.h\t/^- (void)fooDidLoad:(id)foo;$/;\"\tm\tprotocol:FooDelegate",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("headerTags() = %q, want %q", got, want)
+	}
+}
+
+func TestNewObjC_encrypted(t *testing.T) {
+	encrypted := &macho.File{FileTOC: macho.FileTOC{Loads: []macho.Load{
+		&macho.EncryptionInfo64{EncryptionInfo64Cmd: types.EncryptionInfo64Cmd{CryptID: 1}},
+	}}}
+	if _, err := NewObjC(encrypted, nil, &ObjcConfig{}); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("NewObjC() error = %v, want %v", err, ErrEncrypted)
+	}
+	decrypted := &macho.File{FileTOC: macho.FileTOC{Loads: []macho.Load{
+		&macho.EncryptionInfo{EncryptionInfoCmd: types.EncryptionInfoCmd{CryptID: types.NOT_ENCRYPTED_YET}},
+	}}}
+	if isEncrypted(decrypted) {
+		t.Error("isEncrypted() = true for a cryptid of 0")
+	}
+}
+
+func TestObjC_dumpMetaclass(t *testing.T) {
+	o := newTestObjC(t)
+	class := &objc.Class{Name: "Foo", SuperClass: "Bar", IsaVMAddr: 0x1000}
+	mc := &metaclass{
+		Class: &objc.Class{Name: "Foo", Ivars: []objc.Ivar{{Name: "_shared", Type: "i"}}},
+		Isa:   "NSObject",
+		Chain: []string{"Bar", "NSObject (root metaclass)", "NSObject"},
+	}
+	got := o.dumpMetaclass(class, mc, false, true)
+	for _, want := range []string{
+		"@interface Foo (metaclass) : Bar // 0x1000\n",
+		"// isa: NSObject (root metaclass)\n",
+		"// superclass chain: Foo -> Bar -> NSObject (root metaclass) -> NSObject\n",
+		"  /* instance variables */\n",
+		"_shared",
+		"@end\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dumpMetaclass() = \n%s\nwant it to contain %q", got, want)
+		}
+	}
+
+	root := &metaclass{Class: &objc.Class{Name: "NSObject"}, Chain: []string{"NSObject"}}
+	if got := o.dumpMetaclass(&objc.Class{Name: "NSObject"}, root, false, false); !strings.HasPrefix(got, "@interface NSObject (metaclass) : NSObject\n") {
+		t.Errorf("dumpMetaclass() = \n%s\nwant the root class as the root metaclass's superclass", got)
+	}
+}
+
+func TestObjC_dedupProtocols(t *testing.T) {
+	methods := func(names ...string) []objc.Method {
+		var meths []objc.Method
+		for _, name := range names {
+			meths = append(meths, objc.Method{Name: name, Types: "v16@0:8"})
+		}
+		return meths
+	}
+	protos := []objc.Protocol{
+		{Name: "FooDelegate", Ptr: 0x1000, InstanceMethods: methods("didFoo", "didBar")},
+		{Name: "FooDelegate", Ptr: 0x1000, InstanceMethods: methods("didFoo", "didBar")}, // same pointer
+		{Name: "FooDelegateCopy", Ptr: 0x2000, InstanceMethods: methods("didBar", "didFoo")},
+		{Name: "FooOptionalDelegate", Ptr: 0x3000, OptionalInstanceMethods: methods("didFoo", "didBar")},
+		{Name: "FooDataSource", Ptr: 0x4000, InstanceMethods: methods("didFoo", "didBar"), InstanceProperties: []objc.Property{{Name: "count", EncodedAttributes: "TQ,R"}}},
+		{Name: "FooMarker", Ptr: 0x5000},
+		{Name: "BarMarker", Ptr: 0x6000},
+		{Name: "FooMarker", Ptr: 0x5000}, // same pointer
+		{Name: "BazMarker", Ptr: 0x7000, Prots: []objc.Protocol{{Name: "NSObject"}}},
+		{Name: "QuxMarker", Ptr: 0x8000, Prots: []objc.Protocol{{Name: "NSObject"}}},
+	}
+	o := newTestObjC(t)
+	got, aliases := o.dedupProtocols(protos)
+	var names []string
+	for _, proto := range got {
+		names = append(names, proto.Name)
+	}
+	if want := []string{"FooDelegate", "FooOptionalDelegate", "FooDataSource", "FooMarker", "BarMarker", "BazMarker", "QuxMarker"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("dedupProtocols() = %v, want %v", names, want)
+	}
+	if want := map[uint64][]string{0x1000: {"FooDelegateCopy"}}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("dedupProtocols() aliases = %v, want %v", aliases, want)
+	}
+}
+
+func TestObjC_hideNSObjectMembers(t *testing.T) {
+	if !slices.IsSorted(defaultNSObjectMembers) {
+		t.Fatalf("defaultNSObjectMembers must be sorted (for binary search)")
+	}
+	class := &objc.Class{
+		Name:            "Foo",
+		SuperClass:      "NSObject",
+		ClassMethods:    []objc.Method{{Name: "class"}, {Name: "sharedFoo"}},
+		InstanceMethods: []objc.Method{{Name: "respondsToSelector:"}, {Name: "description"}, {Name: "bar"}},
+		Props:           []objc.Property{{Name: "description"}, {Name: "name"}},
+	}
+	o := newTestObjC(t)
+	got := o.hideNSObjectMembers(class)
+	var names []string
+	for _, meth := range append(slices.Clone(got.ClassMethods), got.InstanceMethods...) {
+		names = append(names, meth.Name)
+	}
+	if want := []string{"class", "sharedFoo", "bar"}; !reflect.DeepEqual(names, want) || len(got.Props) != 1 || got.Props[0].Name != "name" {
+		t.Errorf("hideNSObjectMembers() = %v (props: %v), want %v and the name property", names, got.Props, want)
+	}
+	if len(class.InstanceMethods) != 3 || len(class.Props) != 2 {
+		t.Errorf("hideNSObjectMembers() modified the class")
+	}
+
+	o.foundation["nsobject"] = o.nsobjectMembers(&objc.Protocol{
+		Name:            "NSObject",
+		InstanceMethods: []objc.Method{{Name: "description"}},
+	})
+	if got := o.hideNSObjectMembers(class); len(got.InstanceMethods) != 2 || len(got.Props) != 2 {
+		t.Errorf("hideNSObjectMembers() = %v (props: %v), want only description hidden w/ the Foundation NSObject protocol", got.InstanceMethods, got.Props)
+	}
+
+	class.ReadOnlyData.Flags = objc.RO_ROOT
+	if got := o.hideNSObjectMembers(class); got != class {
+		t.Errorf("hideNSObjectMembers() hid the members of a root class")
+	}
+}
+
+func TestSelectorMatch_String(t *testing.T) {
+	tests := []struct {
+		match SelectorMatch
+		want  string
+	}{
+		{SelectorMatch{Owner: "Foo", Kind: "class", Signature: "- (void)encodeWithCoder:(id)arg0;", Image: "Foundation"}, "Foo - (void)encodeWithCoder:(id)arg0;\t(Foundation)"},
+		{SelectorMatch{Owner: "NSCoding", Kind: "protocol", Signature: "- (void)encodeWithCoder:(id)arg0;"}, "<NSCoding> - (void)encodeWithCoder:(id)arg0;"},
+		{SelectorMatch{Owner: "Foo(Bar)", Kind: "category", Signature: "+ (id)shared;"}, "Foo(Bar) + (id)shared;"},
+		{SelectorMatch{Owner: "FooDelegate", Kind: "protocol", Optional: true, Signature: "- (void)fooDidFinish;"}, "<FooDelegate> @optional - (void)fooDidFinish;"},
+	}
+	for _, tt := range tests {
+		if got := tt.match.String(); got != tt.want {
+			t.Errorf("SelectorMatch.String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestObjC_tooFewMethods(t *testing.T) {
+	class := &objc.Class{
+		Name:            "Foo",
+		ClassMethods:    []objc.Method{{Name: "shared"}},
+		InstanceMethods: []objc.Method{{Name: "foo"}, {Name: "bar"}},
+	}
+	o := newTestObjC(t)
+	for min, want := range map[int]bool{0: false, 3: false, 4: true} {
+		o.conf.MinMethods = min
+		if got := o.tooFewMethods(class); got != want {
+			t.Errorf("tooFewMethods() = %v with MinMethods %d, want %v", got, min, want)
+		}
+	}
+}
+
+func Test_frameworkName(t *testing.T) {
+	tests := []struct {
+		installName string
+		want        string
+	}{
+		{"/System/Library/Frameworks/Foundation.framework/Foundation", "Foundation"},
+		{"/System/Library/Frameworks/AppKit.framework/Versions/C/AppKit", "AppKit"},
+		{"/usr/lib/libobjc.A.dylib", "libobjc"},
+		{"/usr/lib/libz.1.2.12.dylib", "libz"},
+		{"/usr/lib/libc++.1.dylib", "libc++"},
+		{"/usr/lib/swift/libswiftCore.dylib", "libswiftCore"},
+		{"@rpath/Foo.1.dylib", "Foo"},
+		{"@rpath/Foo.framework", "Foo"},
+		{"/usr/lib/libSystem.B.dylib", "libSystem"},
+		{"/usr/lib/1.dylib", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.installName, func(t *testing.T) {
+			if got := frameworkName(tt.installName); got != tt.want {
+				t.Errorf("frameworkName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_adoptersByProtocol(t *testing.T) {
+	classes := []objc.Class{
+		{Name: "Foo", Protocols: []objc.Protocol{{Name: "NSCoding"}, {Name: "NSCopying"}}},
+		{Name: "Bar", Protocols: []objc.Protocol{{Name: "NSCoding"}}},
+		{Name: "Bar", Protocols: []objc.Protocol{{Name: "NSCoding"}}}, // e.g. in a dep too
+		{Name: "Baz"},
+	}
+	want := map[string][]string{
+		"NSCoding":  {"Bar", "Foo"},
+		"NSCopying": {"Foo"},
+	}
+	if got := adoptersByProtocol(classes); !reflect.DeepEqual(got, want) {
+		t.Errorf("adoptersByProtocol() = %v, want %v", got, want)
+	}
+}
+
+func Test_reindent(t *testing.T) {
+	header := "@interface Foo : NSObject {\n  /* instance variables */\n  id _bar;\n}\n\n    int _baz;\n\t   x;\n@end\n"
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{"", header},
+		{"tabs", "@interface Foo : NSObject {\n\t/* instance variables */\n\tid _bar;\n}\n\n\t\tint _baz;\n\t\t x;\n@end\n"},
+		{"spaces:4", "@interface Foo : NSObject {\n    /* instance variables */\n    id _bar;\n}\n\n        int _baz;\n         x;\n@end\n"},
+		{"bogus", header},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if got := reindent(header, tt.style); got != tt.want {
+				t.Errorf("reindent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	for _, style := range []string{"spaces", "spaces:0", "spaces:x", "tab"} {
+		if _, err := indentUnit(style); err == nil {
+			t.Errorf("indentUnit(%q) should fail", style)
+		}
+	}
+}
+
+func TestObjC_skipEmpty(t *testing.T) {
+	stub := objc.Class{Name: "Stub"}
+	full := objc.Class{Name: "Full", Props: []objc.Property{{Name: "name"}}}
+	tests := []struct {
+		conf       ObjcConfig
+		stub, full bool
+	}{
+		{ObjcConfig{}, false, false},
+		{ObjcConfig{SkipEmpty: true}, true, false},
+		{ObjcConfig{OnlyEmpty: true}, false, true},
+	}
+	for _, tt := range tests {
+		o := &ObjC{conf: &tt.conf}
+		if got := o.skipEmpty(&stub); got != tt.stub {
+			t.Errorf("skipEmpty(Stub) = %v, want %v (%+v)", got, tt.stub, tt.conf)
+		}
+		if got := o.skipEmpty(&full); got != tt.full {
+			t.Errorf("skipEmpty(Full) = %v, want %v (%+v)", got, tt.full, tt.conf)
+		}
+	}
+}
+
+func TestObjC_headerExt(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.HeaderExt = ".hpp"
+	if got := o.localHeader("protocol", "Foo"); got != "Foo-Protocol.hpp" {
+		t.Errorf("localHeader() = %s, want Foo-Protocol.hpp", got)
+	}
+	imps := Imports{Locals: []string{"NSCopying-Protocol.hpp", "NSString.hpp", "Bar.hpp"}}
+	imps.uniq(o.foundation)
+	if want := []string{"Bar.hpp"}; !reflect.DeepEqual(imps.Locals, want) {
+		t.Errorf("uniq() Locals = %v, want %v", imps.Locals, want)
+	}
+	if err := checkHeaderExt(".hpp"); err != nil {
+		t.Errorf("checkHeaderExt(.hpp) error = %v", err)
+	}
+	for _, ext := range []string{"hpp", ".", ".h/x", ".h.bak"} {
+		if err := checkHeaderExt(ext); err == nil {
+			t.Errorf("checkHeaderExt(%q) should fail", ext)
+		}
+	}
+}
+
+func TestObjC_selectorAddresses(t *testing.T) {
+	o := newTestObjC(t)
+	class := objc.Class{
+		Name: "Foo",
+		ClassMethods: []objc.Method{
+			{Name: "reload", ImpVMAddr: 0x1000},
+		},
+		InstanceMethods: []objc.Method{
+			{Name: "reload", ImpVMAddr: 0x2000},
+			{Name: "noImp"},
+		},
+	}
+	want := map[string]uint64{"+reload": 0x1000, "-reload": 0x2000}
+	if got := o.selectorAddresses(&class); !reflect.DeepEqual(got, want) {
+		t.Errorf("selectorAddresses() = %v, want %v", got, want)
+	}
+}
+
+func Test_diffObjcMembers(t *testing.T) {
+	oldMembers := map[string]objcMembers{
+		"Foo":  {Superclass: "NSObject", Selectors: []string{"+shared", "-reload"}},
+		"Gone": {Superclass: "NSObject"},
+		"Same": {Superclass: "NSObject", Selectors: []string{"-run"}},
+	}
+	newMembers := map[string]objcMembers{
+		"Foo":  {Superclass: "Base", Selectors: []string{"+shared", "-refresh"}},
+		"New":  {Superclass: "NSObject"},
+		"Same": {Superclass: "NSObject", Selectors: []string{"-run"}},
+	}
+	diff := ObjcDiff{Image: "Foo.framework", Classes: diffObjcMembers(oldMembers, newMembers)}
+	want := ObjcSetDiff{
+		Added:   []string{"New"},
+		Removed: []string{"Gone"},
+		Changed: []ObjcMemberDiff{{Name: "Foo", OldSuperclass: "NSObject", NewSuperclass: "Base", Added: []string{"-refresh"}, Removed: []string{"-reload"}}},
+	}
+	if !reflect.DeepEqual(diff.Classes, want) {
+		t.Fatalf("diffObjcMembers() = %+v, want %+v", diff.Classes, want)
+	}
+	wantOut := "Foo.framework\n\nClasses:\n" +
+		"  + New\n" +
+		"  - Gone\n" +
+		"  ~ Foo (superclass NSObject -> Base)\n" +
+		"      + -refresh\n" +
+		"      - -reload\n"
+	if got := diff.String(); got != wantOut {
+		t.Errorf("String() = %q, want %q", got, wantOut)
+	}
+	if added := diffObjcMembers(nil, newMembers); len(added.Added) != 3 || added.Removed != nil {
+		t.Errorf("diffObjcMembers(nil, new) = %+v, want all classes added", added)
+	}
+}
+
+func TestObjC_isOverride(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.MarkOverrides = true
+	o.supers = map[string]objcMembers{
+		"Base":   {Superclass: "NSObject", Selectors: []string{"+shared", "-reload"}},
+		"Middle": {Superclass: "Base", Selectors: []string{"-layout"}},
+		"Foo":    {Superclass: "Middle"},
+	}
+	tests := []struct {
+		super, sel string
+		want       bool
+	}{
+		{"Middle", "-layout", true},
+		{"Middle", "-reload", true}, // inherited from Base
+		{"Middle", "+shared", true},
+		{"Middle", "-shared", false},
+		{"Middle", "-init", true}, // NSObject isn't known
+		{"Middle", "-description", true},
+		{"Middle", "-fooBar", false},
+		{"Unknown", "-init", false},
+		{"", "-init", false},
+	}
+	for _, tt := range tests {
+		if got := o.isOverride(tt.super, tt.sel); got != tt.want {
+			t.Errorf("isOverride(%s, %s) = %v, want %v", tt.super, tt.sel, got, tt.want)
+		}
+	}
+	got := o.dumpMethods("Foo", "Middle", "instance methods", []objc.Method{{Name: "reload", Types: "v16@0:8"}, {Name: "refresh", Types: "v16@0:8"}}, false, true, false)
+	if want := "/* instance methods */\n- (void)reload; // override\n- (void)refresh;\n"; got != want {
+		t.Errorf("dumpMethods() = %q, want %q", got, want)
+	}
+}
+
+func TestObjcImage_appendProto(t *testing.T) {
+	meth := ObjcMethod{Name: "init", Types: "@16@0:8", Imp: 0x1000}
+	want := []byte{0x0a, 4, 'i', 'n', 'i', 't', 0x12, 7, '@', '1', '6', '@', '0', ':', '8', 0x18, 0x80, 0x20}
+	if got := meth.appendProto(nil); !bytes.Equal(got, want) {
+		t.Errorf("ObjcMethod.appendProto() = %x, want %x", got, want)
+	}
+
+	img := ObjcImage{
+		Image:   "Foo",
+		Classes: []ObjcClass{{Name: "Foo", SuperClass: "NSObject", InstanceMethods: []ObjcMethod{meth}, Swift: true}},
+	}
+	b := img.appendProto(nil)
+	var fields []protowire.Number
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("ConsumeTag() error = %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields = append(fields, num)
+		if num == 4 { // Class
+			class, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("ConsumeBytes() error = %v", protowire.ParseError(n))
+			}
+			wantClass := protowire.AppendTag(nil, 1, protowire.BytesType)
+			wantClass = protowire.AppendString(wantClass, "Foo")
+			wantClass = protowire.AppendTag(wantClass, 2, protowire.BytesType)
+			wantClass = protowire.AppendString(wantClass, "NSObject")
+			wantClass = protowire.AppendTag(wantClass, 7, protowire.BytesType)
+			wantClass = protowire.AppendBytes(wantClass, want)
+			wantClass = protowire.AppendTag(wantClass, 8, protowire.VarintType)
+			wantClass = protowire.AppendVarint(wantClass, 1)
+			if !bytes.Equal(class, wantClass) {
+				t.Errorf("ObjcClass.appendProto() = %x, want %x", class, wantClass)
+			}
+		}
+		b = b[protowire.ConsumeFieldValue(num, typ, b):]
+	}
+	if want := []protowire.Number{1, 4}; !slices.Equal(fields, want) {
+		t.Errorf("ObjcImage.appendProto() fields = %v, want %v", fields, want)
+	}
+}
+
+func Test_fixupPtr(t *testing.T) {
+	const base = 0x100000000
+	mapped := func(addr uint64) bool { return base <= addr && addr < base+0x8000 }
+	// an arm64e threaded rebase (as in a class list or method list with absolute IMPs)
+	dcf := fixupchains.DyldChainedFixups{PointerFormat: fixupchains.DYLD_CHAINED_PTR_ARM64E}
+	slide := func(ptr uint64) uint64 {
+		target, ok := dcf.IsRebase(ptr, base)
+		if !ok {
+			return ptr
+		}
+		return target + base
+	}
+	tests := []struct {
+		name string
+		ptr  uint64
+		want uint64
+	}{
+		{"null", 0, 0},
+		{"vmaddr", base + 0x4000, base + 0x4000},
+		{"rebase", 1<<51 | (base + 0x4000), base + 0x4000}, // next: 1, target: vmaddr
+		{"auth rebase", 1<<63 | 1<<51 | 1<<49 | 0x1234<<32 | 0x4010, base + 0x4010}, // key: IB, diversity: 0x1234, target: offset
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixupPtr(tt.ptr, mapped, slide); got != tt.want {
+				t.Errorf("fixupPtr(%#x) = %#x, want %#x", tt.ptr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjC_dumpCategoryMethods(t *testing.T) {
+	o := newTestObjC(t)
+	var meths []categoryMethod
+	for _, cat := range []objc.Category{
+		{Name: "Zed", InstanceMethods: []objc.Method{{Name: "zed", Types: "v16@0:8"}}},
+		{Name: "Extras", ClassMethods: []objc.Method{{Name: "shared", Types: "@16@0:8", ImpVMAddr: 0x1000}}, InstanceMethods: []objc.Method{{Name: "reload", Types: "v16@0:8"}}},
+		{InstanceMethods: []objc.Method{{Name: "_private", Types: "v16@0:8"}}},
+	} {
+		meths = append(meths, o.categoryMethods(&cat)...)
+	}
+	o.sortCategoryMethods(meths)
+	want := "@interface Foo (category methods)\n" +
+		"/* class methods */\n" +
+		"// 0x1000\n" +
+		"+ (id)shared; // from category Extras\n" +
+		"/* instance methods */\n" +
+		"- (void)_private; // from class extension\n" +
+		"- (void)reload; // from category Extras\n" +
+		"- (void)zed; // from category Zed\n" +
+		"@end\n"
+	if got := o.dumpCategoryMethods("Foo", meths, true); got != want {
+		t.Errorf("dumpCategoryMethods() = %q, want %q", got, want)
+	}
+}
+
+func TestObjC_allowedClasses(t *testing.T) {
+	o := newTestObjC(t)
+	o.conf.SelectorAllowlist = []string{"reload", "setTitle:"}
+	classes := []objc.Class{
+		{Name: "Base", SuperClass: "NSObject", InstanceMethods: []objc.Method{{Name: "other"}}},
+		{Name: "Foo", SuperClass: "Base", Protocols: []objc.Protocol{{Name: "FooDelegate"}},
+			Ivars:           []objc.Ivar{{Name: "_cache"}},
+			InstanceMethods: []objc.Method{{Name: "reload"}, {Name: "other"}},
+			Props:           []objc.Property{{Name: "title"}, {Name: "count"}},
+		},
+		{Name: "Unrelated", SuperClass: "NSObject", ClassMethods: []objc.Method{{Name: "shared"}}},
+	}
+	got := o.allowedClasses(classes)
+	if len(got) != 2 || got[0].Name != "Base" || got[1].Name != "Foo" {
+		t.Fatalf("allowedClasses() = %v, want Base (superclass shell) and Foo", got)
+	}
+	if len(got[0].InstanceMethods) != 0 {
+		t.Errorf("allowedClasses() Base methods = %v, want none", got[0].InstanceMethods)
+	}
+	if foo := got[1]; len(foo.Ivars) != 0 || len(foo.InstanceMethods) != 1 || foo.InstanceMethods[0].Name != "reload" || len(foo.Props) != 1 || foo.Props[0].Name != "title" {
+		t.Errorf("allowedClasses() Foo = %+v, want only reload and title", foo)
+	}
+	if len(classes[1].InstanceMethods) != 2 || len(classes[1].Ivars) != 1 {
+		t.Errorf("allowedClasses() modified the input classes")
+	}
+	protos := o.allowedProtocols([]objc.Protocol{
+		{Name: "FooDelegate", Prots: []objc.Protocol{{Name: "BaseDelegate"}}},
+		{Name: "BaseDelegate"},
+		{Name: "Other", InstanceMethods: []objc.Method{{Name: "other"}}},
+	}, got)
+	if len(protos) != 2 || protos[0].Name != "FooDelegate" || protos[1].Name != "BaseDelegate" {
+		t.Errorf("allowedProtocols() = %v, want the protocols adopted by Foo", protos)
+	}
+}
+
+func Test_stubReturn(t *testing.T) {
+	tests := []struct {
+		rtype string
+		want  string
+	}{
+		{"void", ""},
+		{"one way void", ""},
+		{"id", "return nil;"},
+		{"instancetype", "return nil;"},
+		{"id<NSCopying>", "return nil;"},
+		{"id /* block */", "return nil;"},
+		{"const char *", "return nil;"},
+		{"SEL", "return nil;"},
+		{"BOOL", "return 0;"},
+		{"unsigned long long", "return 0;"},
+		{"double", "return 0;"},
+		{"struct CGRect", "return (struct CGRect){0};"},
+		{"struct CGPoint { double x; double y; }", "return (struct CGPoint){0};"},
+		{"struct { int x0; }", "__builtin_trap();"},
+	}
+	for _, tt := range tests {
+		if got := stubReturn(tt.rtype); got != tt.want {
+			t.Errorf("stubReturn(%q) = %q, want %q", tt.rtype, got, tt.want)
+		}
+	}
+}
+
+func TestObjC_dumpImplementation(t *testing.T) {
+	o := newTestObjC(t)
+	class := objc.Class{
+		Name:            "Foo",
+		ClassMethods:    []objc.Method{{Name: "shared", Types: "@16@0:8"}},
+		InstanceMethods: []objc.Method{{Name: ".cxx_destruct", Types: "v16@0:8"}, {Name: "initWithName:", Types: `@24@0:8@"NSString"16`}, {Name: "reload", Types: "v16@0:8"}},
+	}
+	want := "@implementation Foo\n" +
+		"\n+ (id)shared {\n  return nil;\n}\n" +
+		"\n- (instancetype)initWithName:(NSString *)name {\n  return nil;\n}\n" +
+		"\n- (void)reload {\n}\n" +
+		"\n@end\n"
+	if got := o.dumpImplementation(&class); got != want {
+		t.Errorf("dumpImplementation() = %q, want %q", got, want)
+	}
+}
+
+func TestObjC_skipUnexported(t *testing.T) {
+	o := newTestObjC(t)
+	m := &macho.File{}
+	o.exported = map[*macho.File]map[string]bool{m: {"Foo": true}}
+	if o.skipUnexported(m, "Internal") {
+		t.Errorf("skipUnexported() = true w/o ExportedOnly")
+	}
+	o.conf.ExportedOnly = true
+	if o.skipUnexported(m, "Foo") {
+		t.Errorf("skipUnexported(Foo) = true, want false (exported)")
+	}
+	if !o.skipUnexported(m, "Internal") {
+		t.Errorf("skipUnexported(Internal) = false, want true (no _OBJC_CLASS_$_ export)")
+	}
+}
@@ -0,0 +1,72 @@
+package macho
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+var clangDiagRE = regexp.MustCompile(`^(.+\.\w+):\d+:\d+: (?:fatal )?error: `)
+
+// VerifyHeaders parses the umbrella header(s) written by Headers with `clang -fsyntax-only` and summarizes the headers that failed to parse
+func (o *ObjC) VerifyHeaders() error {
+	clang, err := exec.LookPath("clang")
+	if err != nil {
+		return fmt.Errorf("--verify requires clang to be in your PATH: %v", err)
+	}
+	if len(o.umbrellas) == 0 {
+		return fmt.Errorf("no umbrella headers to verify (run Headers first)")
+	}
+	var failed []string
+	for _, umbrella := range o.umbrellas {
+		log.WithField("header", umbrella).Info("Verifying")
+		out, err := exec.Command(clang, "-fsyntax-only", "-x", "objective-c", "-fmodules", "-I", filepath.Dir(umbrella), umbrella).CombinedOutput()
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(*exec.ExitError); !ok {
+			return fmt.Errorf("failed to run clang on %s: %v", umbrella, err)
+		}
+		errs := clangErrors(out)
+		if len(errs) == 0 { // e.g. a driver error (no SDK, unsupported flag)
+			return fmt.Errorf("clang failed to parse %s: %s", umbrella, strings.TrimSpace(string(out)))
+		}
+		headers := make([]string, 0, len(errs))
+		for header := range errs {
+			headers = append(headers, header)
+		}
+		slices.Sort(headers)
+		for _, header := range headers {
+			log.Errorf("%s: %d error(s)", header, len(errs[header]))
+			if o.conf.Verbose {
+				for _, diag := range errs[header] {
+					log.Debug(diag)
+				}
+			}
+		}
+		failed = append(failed, headers...)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d header(s) failed to parse", len(failed))
+	}
+	return nil
+}
+
+// clangErrors groups the error diagnostics of clang's output by the header they're in
+func clangErrors(out []byte) map[string][]string {
+	errs := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if match := clangDiagRE.FindStringSubmatch(scanner.Text()); match != nil {
+			errs[match[1]] = append(errs[match[1]], scanner.Text())
+		}
+	}
+	return errs
+}
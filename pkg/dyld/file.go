@@ -87,6 +87,7 @@ type File struct {
 	dyldStartFnAddr uint64
 	objcOptRoAddr   uint64
 	islandStubs     map[uint64]uint64
+	regions         []CacheRegion
 	size            int64
 
 	r       map[mtypes.UUID]io.ReaderAt
@@ -1669,7 +1670,11 @@ func (f *File) GetImageContainingVMAddr(address uint64) (*CacheImage, error) {
 			return img, nil
 		}
 	}
-	return nil, fmt.Errorf("address %#x not in any dylib", address)
+	if uuid, mapping, err := f.GetMappingForVMAddress(address); err == nil {
+		// mapped, but not part of any image (e.g. the shared cache's global data or a subcache's __LINKEDIT)
+		return nil, fmt.Errorf("address %#x not in any dylib (it is in the non-image region of mapping %s in cache %s)", address, mapping.Name, uuid)
+	}
+	return nil, fmt.Errorf("address %#x not in any dylib (it is outside of all cache mappings)", address)
 }
 
 // HasImagePath returns the index of a given image path
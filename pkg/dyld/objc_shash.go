@@ -127,6 +127,15 @@ func (s *StringHash) Scramble() [256]uint32 {
 	return [256]uint32{0}
 }
 
+// Size returns the size of the (read) hash table in bytes
+func (s *StringHash) Size() int64 {
+	size := int64(binary.Size(s.shash) + len(s.Tab) + len(s.CheckBytes) + binary.Size(s.Offsets))
+	if s.Type == clsopt && s.ObjectOffsets != nil {
+		size += int64(binary.Size(s.ObjectOffsets) + binary.Size(s.DuplicateCount) + binary.Size(s.DuplicateOffsets))
+	}
+	return size
+}
+
 func (s *StringHash) Read(r io.ReadSeeker) error {
 
 	r.Seek(int64(s.FileOffset), io.SeekStart)
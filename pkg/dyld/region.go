@@ -0,0 +1,124 @@
+package dyld
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/blacktop/go-macho/types"
+)
+
+// CacheRegion is a named range of the shared cache that isn't part of any image (e.g. the ObjC selector table)
+type CacheRegion struct {
+	Name  string `json:"name"`
+	Start uint64 `json:"start"`
+	Size  uint64 `json:"size"`
+}
+
+func (r CacheRegion) String() string {
+	return fmt.Sprintf("%s (%#x-%#x)", r.Name, r.Start, r.Start+r.Size)
+}
+
+// Contains returns true if the region contains the given virtual address
+func (r CacheRegion) Contains(addr uint64) bool {
+	return r.Start <= addr && addr < r.Start+r.Size
+}
+
+// GetRegions returns the shared cache's (non-image) regions described by its header and ObjC optimizations
+func (f *File) GetRegions() []CacheRegion {
+	if f.regions != nil {
+		return f.regions
+	}
+
+	hdr := f.Headers[f.UUID]
+	// the header grew over time, so only trust the fields that end before the mappings start
+	has := func(fieldOffset uintptr) bool {
+		return uintptr(hdr.MappingOffset) > fieldOffset
+	}
+
+	regions := []CacheRegion{} // non-nil so a cache without any is only checked once
+	add := func(name string, start, size uint64) {
+		if start > 0 && size > 0 {
+			regions = append(regions, CacheRegion{Name: name, Start: start, Size: size})
+		}
+	}
+
+	if has(unsafe.Offsetof(hdr.PatchInfoSize)) {
+		add("patch info", hdr.PatchInfoAddr, hdr.PatchInfoSize)
+	}
+	if has(unsafe.Offsetof(hdr.ProgClosuresTrieSize)) {
+		add("program closures", hdr.ProgClosuresAddr, hdr.ProgClosuresSize)
+		add("program closures trie", hdr.ProgClosuresTrieAddr, hdr.ProgClosuresTrieSize)
+	}
+	if has(unsafe.Offsetof(hdr.OtherTrieSize)) {
+		add("dylibs image array", hdr.DylibsImageArrayAddr, hdr.DylibsImageArraySize)
+		add("dylibs trie", hdr.DylibsTrieAddr, hdr.DylibsTrieSize)
+		add("other image array", hdr.OtherImageArrayAddr, hdr.OtherImageArraySize)
+		add("other trie", hdr.OtherTrieAddr, hdr.OtherTrieSize)
+	}
+	if has(unsafe.Offsetof(hdr.ProgramTrieSize)) {
+		add("programs prebuilt loader set pool", hdr.ProgramsPblSetPoolAddr, hdr.ProgramsPblSetPoolSize)
+		add("program trie", hdr.ProgramTrieAddr, uint64(hdr.ProgramTrieSize))
+	}
+	if has(unsafe.Offsetof(hdr.SwiftOptsSize)) && hdr.SwiftOptsOffset > 0 {
+		add("swift optimizations", hdr.SharedRegionStart+hdr.SwiftOptsOffset, hdr.SwiftOptsSize)
+	}
+	if has(unsafe.Offsetof(hdr.RosettaReadWriteSize)) {
+		add("rosetta read-only", hdr.RosettaReadOnlyAddr, hdr.RosettaReadOnlySize)
+		add("rosetta read-write", hdr.RosettaReadWriteAddr, hdr.RosettaReadWriteSize)
+	}
+	if has(unsafe.Offsetof(hdr.DynamicDataMaxSize)) {
+		if hdr.ObjcOptsOffset > 0 {
+			add("objc optimizations", hdr.SharedRegionStart+hdr.ObjcOptsOffset, hdr.ObjcOptsSize)
+		}
+		if hdr.CacheAtlasOffset > 0 {
+			add("cache atlas", hdr.SharedRegionStart+hdr.CacheAtlasOffset, hdr.CacheAtlasSize)
+		}
+		if hdr.DynamicDataOffset > 0 {
+			add("dynamic data", hdr.SharedRegionStart+hdr.DynamicDataOffset, hdr.DynamicDataMaxSize)
+		}
+	}
+
+	for _, table := range []struct {
+		name string
+		hash func() (*StringHash, *types.UUID, error)
+	}{
+		{"objc selector table", f.getSelectorStringHash},
+		{"objc class table", f.getClassStringHash},
+		{"objc protocol table", f.getProtocolStringHash},
+	} {
+		shash, uuid, err := table.hash()
+		if err != nil || shash.shash == nil {
+			continue // no (or unsupported) ObjC optimizations
+		}
+		if start, err := f.GetVMAddressForUUID(*uuid, uint64(shash.FileOffset)); err == nil {
+			add(table.name, start, uint64(shash.Size()))
+		}
+	}
+
+	f.regions = regions
+	return f.regions
+}
+
+// GetRegionContainingVMAddr returns the smallest shared cache region containing a given virtual address,
+// falling back to the cache mapping it is in (e.g. for addresses in a subcache's __LINKEDIT)
+func (f *File) GetRegionContainingVMAddr(addr uint64) (*CacheRegion, error) {
+	var found *CacheRegion
+	regions := f.GetRegions()
+	for i := range regions {
+		if regions[i].Contains(addr) && (found == nil || regions[i].Size < found.Size) {
+			found = &regions[i]
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	uuid, mapping, err := f.GetMappingForVMAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &CacheRegion{
+		Name:  fmt.Sprintf("%s mapping (cache %s)", mapping.Name, uuid),
+		Start: mapping.Address,
+		Size:  mapping.Size,
+	}, nil
+}
@@ -463,6 +463,34 @@ func (f *File) SaveAddrToSymMap(dest string) error {
 	return nil
 }
 
+// ReadA2SCache reads an address-to-symbol map from a .a2s cache file
+func ReadA2SCache(cacheFile string) (map[uint64]string, error) {
+	a2sFile, err := os.Open(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	defer a2sFile.Close()
+
+	a2s := make(map[uint64]string)
+	if err := gob.NewDecoder(a2sFile).Decode(&a2s); err != nil {
+		return nil, fmt.Errorf("failed to decode addr2sym cache file %s: %v", cacheFile, err)
+	}
+
+	return a2s, nil
+}
+
+// WriteA2SCache writes an address-to-symbol map to a .a2s cache file
+func WriteA2SCache(cacheFile string, a2s map[uint64]string) error {
+	buff := new(bytes.Buffer)
+	if err := gob.NewEncoder(buff).Encode(a2s); err != nil {
+		return fmt.Errorf("failed to encode addr2sym map to binary: %v", err)
+	}
+	if err := os.WriteFile(cacheFile, buff.Bytes(), 0o660); err != nil {
+		return fmt.Errorf("failed to write addr2sym cache file %s: %v", cacheFile, err)
+	}
+	return nil
+}
+
 // GetCString returns a c-string at a given virtual address
 func (f *File) GetCString(strVMAdr uint64) (string, error) {
 